@@ -1,7 +1,14 @@
 import type {
+  AcceptanceResult,
+  ApiKeyInfo,
+  ApiKeyScope,
+  ApprovalComment,
   ApprovalRequest,
   ApprovalResolution,
+  ApprovalRisk,
   Artifact,
+  AutoApproveRule,
+  ContextPack,
   EdgeState,
   Envelope,
   GlobalMode,
@@ -9,11 +16,37 @@ import type {
   NodeConfigInput,
   NodeState,
   OrchestrationMode,
+  PlanState,
+  ProviderName,
+  RunBudget,
   RunState,
-  UUID
+  RunStatus,
+  SpecLoopState,
+  TurnEvaluation,
+  UsageTotals,
+  UUID,
+  WebhookSubscription,
+  WorkspaceCheckpoint
 } from "./types.js";
 import type { EventEnvelope } from "./events.js";
 
+export type ApiErrorCode =
+  | "invalid_request"
+  | "unauthorized"
+  | "not_found"
+  | "conflict"
+  | "internal";
+
+export interface ApiError {
+  code: ApiErrorCode;
+  message: string;
+  details?: Record<string, unknown>;
+}
+
+export interface ApiErrorResponse {
+  error: ApiError;
+}
+
 export interface ListDirectoryRequest {
   path?: string;
 }
@@ -34,14 +67,44 @@ export interface CreateRunRequest {
   mode?: OrchestrationMode;
   globalMode?: GlobalMode;
   cwd?: string;
+  /** Named env set (VUHLP_ENV_SET_<NAME>) for the run's tools. */
+  envSet?: string;
+  /** Docker image for sandboxed execution; overrides VUHLP_SANDBOX_IMAGE. */
+  sandboxImage?: string;
+  /** Read-only workspace: write/exec tools are rejected for the whole run. */
+  readOnly?: boolean;
+  /** Run in an automatically created git worktree; the original tree stays untouched. */
+  isolateWorkspace?: boolean;
+  /** Spend limits; defaults come from VUHLP_BUDGET_TOKENS / VUHLP_BUDGET_USD. */
+  budget?: RunBudget;
+  /** Work on a `run/<id>` branch and commit after every turn that changes files. */
+  autoCommit?: boolean;
 }
 
 export interface CreateRunResponse {
   run: RunState;
 }
 
+export interface PendingApprovalSummary {
+  approvalId: UUID;
+  nodeId: UUID;
+  tool: string;
+  /** Short single-line preview of the command/args for list rendering. */
+  preview: string;
+  risk?: ApprovalRisk;
+  requestedAt?: string;
+  /** Milliseconds since the approval was raised. */
+  ageMs?: number;
+}
+
+/** Run plus the approvals blocking it, so list clients can render an
+ * actionable queue without a detail fetch per run. */
+export interface RunListItem extends RunState {
+  pendingApprovals: PendingApprovalSummary[];
+}
+
 export interface ListRunsResponse {
-  runs: RunState[];
+  runs: RunListItem[];
 }
 
 export interface GetRunResponse {
@@ -51,6 +114,8 @@ export interface GetRunResponse {
 export interface GetRunEventsQuery {
   limit?: number;
   before?: string;
+  /** Comma-separated event types to include; omit for all. */
+  types?: string;
 }
 
 export interface RunEventsPageInfo {
@@ -65,6 +130,11 @@ export interface GetRunEventsResponse {
 
 export interface UpdateRunRequest {
   patch: Partial<Pick<RunState, "status" | "mode" | "globalMode" | "layout">>;
+  /** Recorded on the run when the patch stops it. */
+  cancellation?: {
+    reason?: string;
+    actor?: string;
+  };
 }
 
 export interface UpdateRunResponse {
@@ -118,6 +188,20 @@ export interface PostChatResponse {
   messageId: UUID;
 }
 
+export interface AddApprovalCommentRequest {
+  comment: {
+    file?: string;
+    hunk?: string;
+    line?: number;
+    body: string;
+  };
+}
+
+export interface AddApprovalCommentResponse {
+  approvalId: UUID;
+  comment: ApprovalComment;
+}
+
 export interface ListApprovalsResponse {
   approvals: Array<{ runId: UUID; approval: ApprovalRequest }>;
 }
@@ -157,6 +241,394 @@ export interface GetArtifactResponse {
   content: string;
 }
 
+export interface CreateRunBatchRequest {
+  /** Directory of spec markdown files, one run per spec. */
+  specsDir: string;
+  /** Frontmatter filter, e.g. "status=approved"; omit to take every spec. */
+  filter?: string;
+  mode?: OrchestrationMode;
+  globalMode?: GlobalMode;
+  cwd?: string;
+  provider?: ProviderName;
+}
+
+export interface CreateRunBatchResponse {
+  batchId: UUID;
+  runs: RunState[];
+  skipped: string[];
+}
+
+export interface GetBatchResponse {
+  batchId: UUID;
+  runs: RunState[];
+}
+
+export interface StopBatchResponse {
+  batchId: UUID;
+  stopped: UUID[];
+}
+
+export interface ProviderUsageBreakdown {
+  provider: ProviderName;
+  models: string[];
+  usage: UsageTotals;
+  /** Estimated USD cost; absent when no price is configured for the provider. */
+  costUsd?: number;
+}
+
+export interface ToolUsageStats {
+  calls: number;
+  failures: number;
+  /** Total wall-clock time spent in the tool, in milliseconds. */
+  durationMs: number;
+  /** Total size of tool outputs fed back to the model, in bytes. */
+  outputBytes: number;
+}
+
+export interface GetUsageResponse {
+  runId: UUID;
+  /** Present when the report is scoped to a single node. */
+  nodeId?: UUID;
+  usage: UsageTotals;
+  providers: ProviderUsageBreakdown[];
+  /** Sum of per-provider estimates; absent when no prices are configured. */
+  costUsd?: number;
+  toolCalls: {
+    total: number;
+    byTool: Record<string, ToolUsageStats>;
+  };
+}
+
+export interface GetToolStatsResponse {
+  /** Number of runs the aggregate covers. */
+  runs: number;
+  tools: Record<string, ToolUsageStats>;
+}
+
+export interface AddAutoApproveRuleRequest {
+  /** Tool name, "net", or "*"; defaults to "*" when only a command pattern is given. */
+  tool?: string;
+  /** Command prefix pattern (`*` wildcard) for command-style tools. */
+  command?: string;
+  /** Rule lifetime in ms (e.g. 3600000 for an hour); absent means until removed. */
+  ttlMs?: number;
+}
+
+export interface AddAutoApproveRuleResponse {
+  rule: AutoApproveRule;
+}
+
+export interface AdminHaltState {
+  halted: boolean;
+  reason?: string;
+  haltedAt?: string;
+  /** Runs paused by this halt; present on the halt response only. */
+  pausedRuns?: number;
+}
+
+export type ConversationImportFormat = "claude-code" | "cursor" | "aider" | "generic";
+
+export interface ImportConversationRequest {
+  format: ConversationImportFormat;
+  /** Raw transcript in the tool's export format (JSONL, markdown, or JSON). */
+  content: string;
+  label?: string;
+  provider?: ProviderName;
+  mode?: OrchestrationMode;
+  cwd?: string;
+}
+
+export interface CreateVariantRunsRequest {
+  /** Spec/instructions shared by every variant. */
+  spec: string;
+  /** One run per entry (at least two). */
+  variants: Array<{ provider: ProviderName; label?: string }>;
+  mode?: OrchestrationMode;
+  globalMode?: GlobalMode;
+  cwd?: string;
+}
+
+export interface CreateVariantRunsResponse {
+  batchId: UUID;
+  runs: RunState[];
+}
+
+export interface VariantComparisonEntry {
+  runId: UUID;
+  label: string;
+  provider: ProviderName;
+  status: RunStatus;
+  usage?: UsageTotals;
+  filesChanged: string[];
+  /** Diff artifact ids, in order; fetch via the artifacts endpoint. */
+  diffArtifacts: UUID[];
+  /** Latest model-judge evaluation of the variant's node, when enabled. */
+  evaluation?: TurnEvaluation;
+  /** Composite of completion status and judge score; higher wins. */
+  score: number;
+}
+
+export interface CompareBatchResponse {
+  batchId: UUID;
+  variants: VariantComparisonEntry[];
+  /** Highest-scoring variant; its patch series is ready to merge. */
+  winnerRunId?: UUID;
+}
+
+export interface CreateBootstrapRunRequest {
+  cwd?: string;
+  provider?: ProviderName;
+}
+
+export interface CreateBootstrapRunResponse {
+  run: RunState;
+  nodeId: UUID;
+}
+
+export type ConversationExportFormat = "aider" | "continue" | "generic";
+
+export interface ImportConversationResponse {
+  run: RunState;
+  nodeId: UUID;
+  /** Messages mapped into the run's event log. */
+  imported: number;
+}
+
+export interface RunTemplate {
+  name: string;
+  description?: string;
+  mode?: OrchestrationMode;
+  globalMode?: GlobalMode;
+  /** May contain {{param}} placeholders, like the other string fields. */
+  cwd?: string;
+  provider?: ProviderName;
+  roleTemplate?: string;
+  /** Initial instruction posted to the node; {{param}} placeholders required here. */
+  instructions: string;
+  /** Parameter names that must be supplied when instantiating. */
+  params?: string[];
+}
+
+export interface ListRunTemplatesResponse {
+  runTemplates: RunTemplate[];
+}
+
+export interface PutRunTemplateRequest {
+  template: Omit<RunTemplate, "name">;
+}
+
+export interface PutRunTemplateResponse {
+  runTemplate: RunTemplate;
+}
+
+export interface DeleteRunTemplateResponse {
+  name: string;
+}
+
+export interface CreateRunFromTemplateRequest {
+  name: string;
+  params?: Record<string, string>;
+}
+
+export interface CreateRunFromTemplateResponse {
+  run: RunState;
+}
+
+export interface SpecInfo {
+  name: string;
+  path: string;
+  updatedAt: string;
+}
+
+export interface ListSpecsResponse {
+  specs: SpecInfo[];
+}
+
+export interface GetSpecResponse {
+  name: string;
+  content: string;
+}
+
+export interface PutSpecRequest {
+  content: string;
+}
+
+export interface PutSpecResponse {
+  name: string;
+  path: string;
+  created: boolean;
+}
+
+export interface DeleteSpecResponse {
+  name: string;
+}
+
+export interface RunAcceptanceResponse {
+  specName: string;
+  results: AcceptanceResult[];
+  passed: number;
+  failed: number;
+}
+
+export interface RerunRunRequest {
+  /** Override the global mode of the clone, e.g. restart in PLANNING for a fresh plan. */
+  globalMode?: GlobalMode;
+}
+
+export interface RerunRunResponse {
+  run: RunState;
+}
+
+export interface GetContextPackResponse {
+  contextPack: ContextPack;
+  tokenEstimate: number;
+}
+
+export interface PinContextPackResponse {
+  contextPack: ContextPack;
+  artifact: Artifact;
+}
+
+export interface GenerateConsensusPlanRequest {
+  nodeId: UUID;
+  goal: string;
+  context?: string;
+}
+
+export interface GenerateConsensusPlanResponse {
+  plan: Artifact;
+  candidates: Artifact[];
+  reconciledBy: string;
+}
+
+/** One step of a plan being created; `dependsOn` refers to step names. */
+export interface CreatePlanStepInput {
+  name: string;
+  task: string;
+  /** Node that runs the step; defaults to the run's only node when omitted. */
+  nodeId?: UUID;
+  dependsOn?: string[];
+  /** Failed attempts below this retry with backoff; default 1 (no retry). */
+  maxAttempts?: number;
+  /** Per-attempt wall-clock limit; unlimited when omitted. */
+  timeoutSeconds?: number;
+  /** Extra environment for the step's commands, merged over the run's env set. */
+  env?: Record<string, string>;
+}
+
+export interface CreatePlanRequest {
+  goal?: string;
+  steps: CreatePlanStepInput[];
+  /** Max steps running at once; default 2. */
+  concurrency?: number;
+  /** Require explicit approval before start; defaults to VUHLP_PLAN_REVIEW. */
+  review?: boolean;
+}
+
+export interface PlanResponse {
+  plan: PlanState;
+}
+
+export interface StartSpecLoopRequest {
+  /** Name of the spec (under specs/ in the workspace) to implement. */
+  spec: string;
+  /** Node that runs the loop; defaults to the run's only node when omitted. */
+  nodeId?: UUID;
+  /** Iterations allowed before the loop fails; defaults to VUHLP_LOOP_MAX_ITERATIONS. */
+  maxIterations?: number;
+}
+
+export interface SpecLoopResponse {
+  loop: SpecLoopState;
+}
+
+/** Replaces the run's spend limits; raising them lets a budget_exceeded run resume. */
+export interface UpdateRunBudgetRequest {
+  budget: RunBudget;
+}
+
+export interface ListCheckpointsResponse {
+  runId: UUID;
+  checkpoints: WorkspaceCheckpoint[];
+}
+
+export interface GetFileAtTurnResponse {
+  runId: UUID;
+  path: string;
+  turn: number;
+  content: string;
+}
+
+export interface ReadinessCheck {
+  name: string;
+  ok: boolean;
+  detail?: string;
+}
+
+export interface GetReadinessResponse {
+  ready: boolean;
+  checks: ReadinessCheck[];
+}
+
+export interface RunPatchSeriesEntry {
+  sequence: number;
+  name: string;
+  content: string;
+}
+
+export interface GetRunPatchSeriesResponse {
+  runId: UUID;
+  patches: RunPatchSeriesEntry[];
+}
+
+export interface CreateApiKeyRequest {
+  name: string;
+  scopes: ApiKeyScope[];
+}
+
+export interface CreateApiKeyResponse {
+  /** Plaintext key; shown once and never persisted. */
+  key: string;
+  apiKey: ApiKeyInfo;
+}
+
+export interface ListApiKeysResponse {
+  apiKeys: ApiKeyInfo[];
+}
+
+export interface DeleteApiKeyResponse {
+  apiKeyId: UUID;
+}
+
+export interface CreateWebhookRequest {
+  url: string;
+  secret?: string;
+  events?: string[];
+}
+
+export interface CreateWebhookResponse {
+  webhook: WebhookSubscription;
+  /** HMAC signing secret, echoed only here; GET /api/webhooks never returns it. */
+  secret?: string;
+}
+
+export interface ListWebhooksResponse {
+  webhooks: WebhookSubscription[];
+}
+
+export interface DeleteWebhookResponse {
+  webhookId: UUID;
+}
+
+export interface ReloadConfigResponse {
+  /** .env file that was re-read; null when none was found at startup. */
+  envPath: string | null;
+  /** Env keys whose values changed. */
+  changed: string[];
+  /** Webhook subscriptions loaded after the reload. */
+  webhooks: number;
+}
+
 export interface GetRoleTemplateResponse {
   name: string;
   content: string;
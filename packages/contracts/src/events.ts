@@ -1,15 +1,24 @@
 import type {
+  AcceptanceResult,
+  ApprovalComment,
   ApprovalResolution,
+  ApprovalRisk,
   Artifact,
   EdgeState,
   Envelope,
+  FileAccess,
   ISO8601,
   NodeState,
   NodeStatus,
   ProviderName,
   OrchestrationMode,
   GlobalMode,
+  PlanState,
+  PlanStepStatus,
   RunState,
+  RunSummary,
+  SpecLoopState,
+  TurnEvaluation,
   UsageTotals,
   UUID,
   ToolCall,
@@ -20,12 +29,15 @@ export type EventType =
   | "run.patch"
   | "run.mode"
   | "run.stalled"
+  | "run.summary"
   | "node.patch"
   | "node.heartbeat"
   | "node.log"
   | "node.deleted"
   | "node.progress"
   | "turn.status"
+  | "turn.evaluated"
+  | "turn.files"
   | "edge.created"
   | "edge.deleted"
   | "handoff.sent"
@@ -34,12 +46,24 @@ export type EventType =
   | "message.assistant.final"
   | "message.assistant.thinking.delta"
   | "message.assistant.thinking.final"
+  | "model.stream.stalled"
   | "tool.proposed"
   | "tool.started"
   | "tool.completed"
+  | "guardrail.flagged"
   | "approval.requested"
+  | "approval.comment"
+  | "option.selected"
+  | "approval.vote"
   | "approval.resolved"
+  | "approval.expired"
   | "artifact.created"
+  | "checkpoint.created"
+  | "plan.updated"
+  | "plan.step"
+  | "plan.replanned"
+  | "loop.updated"
+  | "acceptance.results"
   | "telemetry.usage";
 
 export type TurnStatus =
@@ -80,6 +104,12 @@ export interface RunStalledEvent extends BaseEvent {
   };
 }
 
+/** Model-generated wrap-up, emitted when a run reaches a terminal status. */
+export interface RunSummaryEvent extends BaseEvent {
+  type: "run.summary";
+  summary: RunSummary;
+}
+
 export interface NodePatchEvent extends BaseEvent {
   type: "node.patch";
   nodeId: UUID;
@@ -117,6 +147,20 @@ export interface TurnStatusEvent extends BaseEvent {
   detail?: string;
 }
 
+export interface TurnEvaluatedEvent extends BaseEvent {
+  type: "turn.evaluated";
+  nodeId: UUID;
+  evaluation: TurnEvaluation;
+}
+
+export interface TurnFilesEvent extends BaseEvent {
+  type: "turn.files";
+  nodeId: UUID;
+  turnId: UUID;
+  /** Every file path the turn's tools read or wrote, in call order. */
+  files: FileAccess[];
+}
+
 export interface EdgeCreatedEvent extends BaseEvent {
   type: "edge.created";
   edge: EdgeState;
@@ -163,6 +207,14 @@ export interface MessageAssistantThinkingFinalEvent extends BaseEvent {
   content: string;
 }
 
+export interface ModelStreamStalledEvent extends BaseEvent {
+  type: "model.stream.stalled";
+  nodeId: UUID;
+  elapsedMs: number;
+  timeoutMs: number;
+  willRetry: boolean;
+}
+
 export interface ToolProposedEvent extends BaseEvent {
   type: "tool.proposed";
   nodeId: UUID;
@@ -183,12 +235,49 @@ export interface ToolCompletedEvent extends BaseEvent {
   error?: { message: string };
 }
 
+export interface GuardrailFlaggedEvent extends BaseEvent {
+  type: "guardrail.flagged";
+  nodeId: UUID;
+  toolId: UUID;
+  reasons: string[];
+}
+
 export interface ApprovalRequestedEvent extends BaseEvent {
   type: "approval.requested";
   approvalId: UUID;
   nodeId: UUID;
+  /** The full call, args included, so reviewers need no prior events. */
   tool: ToolCall;
   context?: string;
+  summary?: string;
+  risk?: ApprovalRisk;
+  /** Workspace root the approved call would run against. */
+  cwd?: string;
+}
+
+export interface ApprovalCommentEvent extends BaseEvent {
+  type: "approval.comment";
+  approvalId: UUID;
+  nodeId: UUID;
+  comment: ApprovalComment;
+}
+
+export interface OptionSelectedEvent extends BaseEvent {
+  type: "option.selected";
+  nodeId: UUID;
+  toolId: UUID;
+  optionId: string;
+  label: string;
+  summary: string;
+}
+
+export interface ApprovalVoteEvent extends BaseEvent {
+  type: "approval.vote";
+  approvalId: UUID;
+  nodeId: UUID;
+  approver: string;
+  approvals: number;
+  requiredApprovals: number;
 }
 
 export interface ApprovalResolvedEvent extends BaseEvent {
@@ -197,11 +286,69 @@ export interface ApprovalResolvedEvent extends BaseEvent {
   resolution: ApprovalResolution;
 }
 
+export interface ApprovalExpiredEvent extends BaseEvent {
+  type: "approval.expired";
+  approvalId: UUID;
+  nodeId: UUID;
+  /** How the timed-out approval was auto-resolved. */
+  action: "denied" | "approved";
+  timeoutMs: number;
+}
+
 export interface ArtifactCreatedEvent extends BaseEvent {
   type: "artifact.created";
   artifact: Artifact;
 }
 
+export interface CheckpointCreatedEvent extends BaseEvent {
+  type: "checkpoint.created";
+  nodeId: UUID;
+  turn: number;
+  tree: string;
+}
+
+/** Full plan snapshot; emitted on creation and every status change. */
+export interface PlanUpdatedEvent extends BaseEvent {
+  type: "plan.updated";
+  plan: PlanState;
+}
+
+/** One step changed status; `plan.updated` follows with the full picture. */
+export interface PlanStepEvent extends BaseEvent {
+  type: "plan.step";
+  planId: UUID;
+  stepId: UUID;
+  nodeId: UUID;
+  status: PlanStepStatus;
+  error?: string;
+}
+
+/** A step failure triggered a re-planning iteration; the revised plan follows as `plan.updated`. */
+export interface PlanReplannedEvent extends BaseEvent {
+  type: "plan.replanned";
+  planId: UUID;
+  /** The failed step the revision reacts to. */
+  stepId: UUID;
+  /** 1-based re-planning iteration. */
+  iteration: number;
+  reason: string;
+}
+
+/** Full spec-loop snapshot; emitted whenever the loop starts, iterates, or settles. */
+export interface LoopUpdatedEvent extends BaseEvent {
+  type: "loop.updated";
+  loop: SpecLoopState;
+}
+
+/** Outcome of running a spec's acceptance tests as the final verification gate. */
+export interface AcceptanceResultsEvent extends BaseEvent {
+  type: "acceptance.results";
+  specName: string;
+  results: AcceptanceResult[];
+  passed: number;
+  failed: number;
+}
+
 export interface TelemetryUsageEvent extends BaseEvent {
   type: "telemetry.usage";
   provider: ProviderName;
@@ -213,12 +360,15 @@ export type EventEnvelope =
   | RunPatchEvent
   | RunModeEvent
   | RunStalledEvent
+  | RunSummaryEvent
   | NodePatchEvent
   | NodeHeartbeatEvent
   | NodeLogEvent
   | NodeDeletedEvent
   | NodeProgressEvent
   | TurnStatusEvent
+  | TurnEvaluatedEvent
+  | TurnFilesEvent
   | EdgeCreatedEvent
   | EdgeDeletedEvent
   | HandoffSentEvent
@@ -227,10 +377,22 @@ export type EventEnvelope =
   | MessageAssistantFinalEvent
   | MessageAssistantThinkingDeltaEvent
   | MessageAssistantThinkingFinalEvent
+  | ModelStreamStalledEvent
   | ToolProposedEvent
   | ToolStartedEvent
   | ToolCompletedEvent
+  | GuardrailFlaggedEvent
   | ApprovalRequestedEvent
+  | ApprovalCommentEvent
+  | OptionSelectedEvent
+  | ApprovalVoteEvent
   | ApprovalResolvedEvent
+  | ApprovalExpiredEvent
   | ArtifactCreatedEvent
+  | CheckpointCreatedEvent
+  | PlanUpdatedEvent
+  | PlanStepEvent
+  | PlanReplannedEvent
+  | LoopUpdatedEvent
+  | AcceptanceResultsEvent
   | TelemetryUsageEvent;
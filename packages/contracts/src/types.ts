@@ -3,7 +3,14 @@ export type ISO8601 = string;
 
 export type ContractVersion = "1";
 
-export type RunStatus = "queued" | "running" | "paused" | "stopped" | "completed" | "failed";
+export type RunStatus =
+  | "queued"
+  | "running"
+  | "paused"
+  | "budget_exceeded"
+  | "stopped"
+  | "completed"
+  | "failed";
 export type OrchestrationMode = "AUTO" | "INTERACTIVE";
 export type GlobalMode = "PLANNING" | "IMPLEMENTATION";
 
@@ -13,7 +20,7 @@ export type NodeStatus = "idle" | "running" | "blocked" | "failed";
 
 export type EdgeType = "handoff" | "report";
 
-export type ArtifactKind = "diff" | "prompt" | "log" | "transcript" | "contextpack" | "report";
+export type ArtifactKind = "diff" | "prompt" | "log" | "transcript" | "contextpack" | "report" | "plan";
 
 export type EdgeManagementScope = "none" | "self" | "all";
 
@@ -31,6 +38,18 @@ export interface UsageTotals {
   totalTokens: number;
 }
 
+/**
+ * Spend limits for a run, enforced as telemetry accumulates: hitting
+ * either cap interrupts the run with status "budget_exceeded". Raising
+ * the budget lets the run resume.
+ */
+export interface RunBudget {
+  /** Total tokens (prompt + completion) allowed; absent means unlimited. */
+  maxTokens?: number;
+  /** Estimated spend in USD allowed; needs VUHLP_PRICE_<PROVIDER> to accrue. */
+  maxCostUsd?: number;
+}
+
 export interface GraphPosition {
   x: number;
   y: number;
@@ -52,17 +71,182 @@ export interface RunState {
   id: UUID;
   contractVersion: ContractVersion;
   status: RunStatus;
+  /** 1-based place in the run queue while status is "queued"; cleared on dequeue. */
+  queuePosition?: number;
   mode: OrchestrationMode;
   globalMode: GlobalMode;
   createdAt: ISO8601;
   updatedAt: ISO8601;
   usage?: UsageTotals;
+  /** Cumulative estimated spend in USD, for providers with a configured price. */
+  costUsd?: number;
+  /** Spend limits; hitting one interrupts the run with status "budget_exceeded". */
+  budget?: RunBudget;
   nodes: Record<UUID, NodeState>;
   nodeConfigs: Record<UUID, NodeConfig>;
   edges: Record<UUID, EdgeState>;
   artifacts: Record<UUID, Artifact>;
+  /** Pending approvals, mirrored here so snapshots survive a daemon restart. */
+  approvals?: Record<UUID, ApprovalRequest>;
+  /** Who decided recent approvals, newest last, for review without replaying events. */
+  approvalDecisions?: ApprovalDecision[];
+  /** Dependency-ordered plan being executed over the run's nodes, when one exists. */
+  plan?: PlanState;
+  /** Spec loop driving a node until acceptance tests pass, when one exists. */
+  loop?: SpecLoopState;
   cwd?: string;
+  /** Named env set injected into the run's command-running tools. */
+  envSet?: string;
+  /** Docker image; when set, command and run_tests execute inside a per-run container. */
+  sandboxImage?: string;
+  /** Rejects every write/exec tool for the whole run; reads stay available. */
+  readOnly?: boolean;
+  /** Copy-on-write isolation: the run operates in this git worktree of `base`. */
+  worktree?: { path: string; branch: string; base: string };
+  /** Auto-commit bookkeeping: the run's branch and the commit range it produced. */
+  autoCommit?: AutoCommitState;
   layout?: GraphLayout;
+  /** Run this one was cloned from via the rerun endpoint. */
+  parentRunId?: UUID;
+  /** Batch this run belongs to when created via bulk spec creation. */
+  batchId?: UUID;
+  /** Why and by whom the run was stopped; set when status becomes "stopped". */
+  cancellation?: CancellationInfo;
+  /** Model-generated wrap-up, filled in when the run reaches a terminal status. */
+  summary?: RunSummary;
+  /** Workspace git state at run start, for anchoring exports and comparisons. */
+  workspaceStart?: WorkspaceSnapshot;
+  /** Workspace git state when the run reached a terminal status. */
+  workspaceEnd?: WorkspaceSnapshot;
+}
+
+export type CancellationSource = "user" | "system";
+
+export interface CancellationInfo {
+  /** Distinguishes explicit user cancels from system cancels (timeout, budget). */
+  source: CancellationSource;
+  reason?: string;
+  actor?: string;
+  at: ISO8601;
+}
+
+/**
+ * State of a run's automatic git history: the branch created for the
+ * run and the commit range its work landed on. The run's full diff is
+ * `base..head`, so review and rollback are plain git operations.
+ */
+export interface AutoCommitState {
+  branch: string;
+  /** Commit the branch started from. */
+  base: string;
+  /** Latest auto-commit; absent until the first turn changes files. */
+  head?: string;
+  /** Auto-commits made so far. */
+  count: number;
+}
+
+/** Model-generated wrap-up of a finished run: what changed, what was verified, what's left. */
+export interface RunSummary {
+  text: string;
+  model: string;
+  generatedAt: ISO8601;
+}
+
+export interface WorkspaceSnapshot {
+  /** HEAD commit SHA, when the workspace is a git checkout. */
+  commit?: string;
+  /** Hash of `git status --porcelain` output; absent when the tree is clean. */
+  dirtyHash?: string;
+  capturedAt: ISO8601;
+}
+
+export type PlanStepStatus = "pending" | "running" | "completed" | "failed" | "skipped";
+
+export interface PlanStep {
+  id: UUID;
+  name: string;
+  /** The task message the assigned node receives when the step starts. */
+  task: string;
+  /** Node that runs the step; steps on different nodes can run in parallel. */
+  nodeId: UUID;
+  /** Ids of steps that must complete first; empty means the step is a root. */
+  dependsOn: UUID[];
+  status: PlanStepStatus;
+  /** 1-based; incremented each time the step's task is dispatched. */
+  attempt: number;
+  /** Tries allowed before the step fails for good; default 1 (no retry). */
+  maxAttempts: number;
+  /** Wall-clock limit per attempt; the step's turn is interrupted and failed when exceeded. */
+  timeoutSeconds?: number;
+  /** Extra environment for commands the step's tools run, merged over the run's env set. */
+  env?: Record<string, string>;
+  error?: string;
+  startedAt?: ISO8601;
+  completedAt?: ISO8601;
+}
+
+export type PlanStatus = "pending" | "running" | "completed" | "failed" | "cancelled";
+
+/**
+ * A dependency-ordered plan executed over the run's nodes. Steps whose
+ * dependencies are all completed run concurrently, bounded by
+ * `concurrency`; a failed step fails the plan and skips its dependents.
+ */
+export interface PlanState {
+  id: UUID;
+  runId: UUID;
+  goal?: string;
+  status: PlanStatus;
+  steps: PlanStep[];
+  /** Max steps running at once (worker pool bound); default 2. */
+  concurrency: number;
+  /** When true, the plan cannot start until someone approves it. */
+  reviewRequired: boolean;
+  /** Re-planning iterations used so far; bounded by VUHLP_PLAN_REPLAN_LIMIT. */
+  replanCount: number;
+  reviewedBy?: string;
+  reviewedAt?: ISO8601;
+  createdAt: ISO8601;
+  updatedAt: ISO8601;
+}
+
+export type SpecLoopStatus = "running" | "passed" | "failed" | "cancelled";
+
+/**
+ * An agentic implement/verify loop over a single node: the spec is fed
+ * through the node's full tool loop, its acceptance tests run after each
+ * turn, and failures go back as the next prompt until everything passes
+ * or `maxIterations` is spent.
+ */
+export interface SpecLoopState {
+  id: UUID;
+  runId: UUID;
+  specName: string;
+  nodeId: UUID;
+  status: SpecLoopStatus;
+  /** 1-based iteration currently running, or the last one that ran. */
+  iteration: number;
+  maxIterations: number;
+  /** Why the loop settled; set when status is "failed". */
+  error?: string;
+  createdAt: ISO8601;
+  updatedAt: ISO8601;
+}
+
+/** A runnable criterion parsed from a spec's "# Acceptance tests" bullets. */
+export interface AcceptanceTest {
+  /** The bullet's prose, or the command itself when the bullet is bare. */
+  name: string;
+  command: string;
+}
+
+export interface AcceptanceResult extends AcceptanceTest {
+  ok: boolean;
+  /** null when the command timed out or was killed by a signal. */
+  exitCode: number | null;
+  durationMs: number;
+  /** Tail of combined stdout/stderr. */
+  output: string;
 }
 
 export interface NodeCapabilities {
@@ -111,12 +295,47 @@ export interface NodeState {
   summary: string;
   lastActivityAt: ISO8601;
   usage?: UsageTotals;
+  /** Cumulative estimated spend in USD, for providers with a configured price. */
+  costUsd?: number;
   capabilities: NodeCapabilities;
   permissions: NodePermissions;
   session: NodeSession;
   connection?: NodeConnection;
   inboxCount?: number;
   todos?: TodoItem[];
+  lastEvaluation?: TurnEvaluation;
+  /** Set when a frozen context pack has been recorded for audit. */
+  pinnedContextPackId?: UUID;
+  /** Id of the turn currently running, for targeted cancellation. */
+  currentTurnId?: UUID;
+  /** Session-scoped auto-approve rules, checked before the approval gate. */
+  autoApprove?: AutoApproveRule[];
+}
+
+/**
+ * A session-scoped rule that lets matching tool calls skip the approval
+ * gate, so routine operations stop interrupting the reviewer. Rules
+ * never cover destructive or protected-path calls; those still pause.
+ */
+export interface AutoApproveRule {
+  id: UUID;
+  /** Tool name, "net" for every network tool, or "*" for any tool. */
+  tool: string;
+  /** Command prefix pattern (`*` wildcard) for command-style tools. */
+  command?: string;
+  /** Rule stops matching after this instant; absent means no expiry. */
+  expiresAt?: ISO8601;
+  createdAt: ISO8601;
+}
+
+export interface TurnEvaluation {
+  /** 0 (no progress) to 1 (clear progress toward the goal). */
+  progress: number;
+  risk: "low" | "medium" | "high";
+  needsHuman: boolean;
+  rationale: string;
+  model: string;
+  ts: ISO8601;
 }
 
 export interface NodeConfig {
@@ -168,6 +387,31 @@ export interface Artifact {
   metadata?: ArtifactMetadata;
 }
 
+export interface WorkspaceCheckpoint {
+  turn: number;
+  nodeId: UUID;
+  ts: ISO8601;
+  tree: string;
+}
+
+export type ApiKeyScope = "read" | "approve" | "full";
+
+export interface ApiKeyInfo {
+  id: UUID;
+  name: string;
+  scopes: ApiKeyScope[];
+  createdAt: ISO8601;
+  lastUsedAt?: ISO8601;
+}
+
+export interface WebhookSubscription {
+  id: UUID;
+  url: string;
+  /** Event types to deliver; empty or omitted means all events. */
+  events?: string[];
+  createdAt: ISO8601;
+}
+
 export interface ArtifactRef {
   type: string;
   ref: string;
@@ -254,17 +498,68 @@ export interface ToolCall {
   args: Record<string, unknown>;
 }
 
+export interface ApprovalComment {
+  id: UUID;
+  /** File the comment refers to, when the tool call carries a diff. */
+  file?: string;
+  /** Hunk header (e.g. "@@ -10,6 +10,8 @@") the comment is anchored to. */
+  hunk?: string;
+  line?: number;
+  body: string;
+  createdAt: ISO8601;
+}
+
 export interface ApprovalResolution {
   status: "approved" | "denied" | "modified";
   modifiedArgs?: Record<string, unknown>;
   reason?: string;
+  /** Review comments carried along when denying with feedback. */
+  comments?: ApprovalComment[];
+  /** Identity of the resolver, counted toward multi-approver policies. */
+  approver?: string;
+  /**
+   * How far an approval reaches beyond this call: "turn" covers later
+   * calls of the same tool until the turn ends, "session" also records
+   * an auto-approve rule on the node. Omitted means this call only.
+   * Destructive commands, deny/approve patterns, and protected-path
+   * writes still pause regardless of scope.
+   */
+  scope?: "turn" | "session";
+}
+
+/** One resolved approval: which identity decided what, and when. */
+export interface ApprovalDecision {
+  approvalId: UUID;
+  nodeId: UUID;
+  tool: string;
+  status: "approved" | "denied" | "modified";
+  approver?: string;
+  ts: ISO8601;
 }
 
+/** Coarse risk classification of a pending approval, for reviewer UIs. */
+export type ApprovalRisk = "read" | "write" | "exec" | "net" | "agent" | "destructive";
+
 export interface ApprovalRequest {
   approvalId: UUID;
   nodeId: UUID;
+  /** The full call, args included (command text, patch body, file paths). */
   tool: ToolCall;
   context?: string;
+  /** One-line rendering of what the call would do, for list views. */
+  summary?: string;
+  risk?: ApprovalRisk;
+  /** Workspace root the approved call would run against. */
+  cwd?: string;
+  /** "destructive" marks commands the classifier flagged (rm -rf, force-push, DROP TABLE); clients should render a red warning. */
+  severity?: "destructive";
+  /** When the approval was raised; lets clients show queue age. */
+  requestedAt?: ISO8601;
+  comments?: ApprovalComment[];
+  /** Distinct approvals needed before the tool runs (policy-driven; default 1). */
+  requiredApprovals?: number;
+  /** Approvers recorded so far when requiredApprovals > 1. */
+  approvedBy?: string[];
 }
 
 
@@ -284,6 +579,14 @@ export interface ChatMessage {
   interrupt?: boolean;
 }
 
+export type FileAccessOp = "read" | "write" | "delete" | "list";
+
+/** One file path a tool touched, recorded for per-turn audit manifests. */
+export interface FileAccess {
+  path: string;
+  op: FileAccessOp;
+}
+
 export interface ToolEvent {
   id: UUID;
   nodeId: UUID;
@@ -0,0 +1,58 @@
+import type express from "express";
+import type { ApiError, ApiErrorCode } from "@vuhlp/contracts";
+
+const STATUS_BY_CODE: Record<ApiErrorCode, number> = {
+  invalid_request: 400,
+  unauthorized: 401,
+  not_found: 404,
+  conflict: 409,
+  internal: 500
+};
+
+/**
+ * Error with a stable machine-readable code for API responses. Thrown where
+ * the failure mode is known up front; plain Errors from the runtime and
+ * stores are classified by message in classifyError instead.
+ */
+export class ApiRequestError extends Error {
+  readonly code: ApiErrorCode;
+  readonly details?: Record<string, unknown>;
+
+  constructor(code: ApiErrorCode, message: string, details?: Record<string, unknown>) {
+    super(message);
+    this.name = "ApiRequestError";
+    this.code = code;
+    this.details = details;
+  }
+}
+
+function classifyError(error: unknown, fallback: ApiErrorCode): ApiError {
+  if (error instanceof ApiRequestError) {
+    return { code: error.code, message: error.message, details: error.details };
+  }
+  const message = error instanceof Error ? error.message : String(error);
+  if (/not found/i.test(message)) {
+    return { code: "not_found", message };
+  }
+  if (/already exists|already in use|conflicts with/i.test(message)) {
+    return { code: "conflict", message };
+  }
+  if (/is required|invalid|must be|not supported|does not support|disabled/i.test(message)) {
+    return { code: "invalid_request", message };
+  }
+  return { code: fallback, message };
+}
+
+/**
+ * Sends the typed error envelope { error: { code, message, details? } } with
+ * the status implied by the code. The fallback code applies when the error
+ * carries no code and its message matches no known pattern.
+ */
+export function sendError(
+  res: express.Response,
+  error: unknown,
+  fallback: ApiErrorCode = "internal"
+): void {
+  const apiError = classifyError(error, fallback);
+  res.status(STATUS_BY_CODE[apiError.code]).json({ error: apiError });
+}
@@ -0,0 +1,89 @@
+import crypto from "node:crypto";
+import type express from "express";
+import { ConsoleLogger, type Logger } from "@vuhlp/providers";
+import { ApiRequestError, sendError } from "./errors.js";
+
+interface StoredResponse {
+  fingerprint: string;
+  status: number;
+  body: unknown;
+  expiresAt: number;
+}
+
+const DEFAULT_TTL_MS = 3_600_000;
+
+function ttlMs(): number {
+  const raw = process.env.VUHLP_IDEMPOTENCY_TTL;
+  if (raw === undefined || raw.trim().length === 0) {
+    return DEFAULT_TTL_MS;
+  }
+  const parsed = Number(raw);
+  return Number.isFinite(parsed) && parsed > 0 ? parsed : DEFAULT_TTL_MS;
+}
+
+/**
+ * Replays stored responses for mutating requests that carry an
+ * Idempotency-Key header, so network retries against POST endpoints do
+ * not create duplicate runs or turns. Entries expire after
+ * VUHLP_IDEMPOTENCY_TTL ms (default one hour). Reusing a key with a
+ * different request body is rejected as a conflict.
+ */
+export function idempotencyMiddleware(logger?: Logger): express.RequestHandler {
+  const log = logger ?? new ConsoleLogger({ scope: "idempotency" });
+  const entries = new Map<string, StoredResponse>();
+
+  const prune = (now: number): void => {
+    for (const [key, entry] of entries) {
+      if (entry.expiresAt <= now) {
+        entries.delete(key);
+      }
+    }
+  };
+
+  return (req, res, next) => {
+    if (req.method !== "POST") {
+      next();
+      return;
+    }
+    const keyHeader = req.headers["idempotency-key"];
+    const key = typeof keyHeader === "string" ? keyHeader.trim() : undefined;
+    if (!key) {
+      next();
+      return;
+    }
+    const now = Date.now();
+    prune(now);
+    const fingerprint = crypto
+      .createHash("sha256")
+      .update(`${req.method} ${req.path}\n${JSON.stringify(req.body ?? null)}`)
+      .digest("hex");
+    const stored = entries.get(key);
+    if (stored) {
+      if (stored.fingerprint !== fingerprint) {
+        sendError(
+          res,
+          new ApiRequestError("conflict", "Idempotency-Key was already used with a different request")
+        );
+        return;
+      }
+      log.info("replaying idempotent response", { key, path: req.path, status: stored.status });
+      res.setHeader("Idempotency-Replayed", "true");
+      res.status(stored.status).json(stored.body);
+      return;
+    }
+    const originalJson = res.json.bind(res);
+    res.json = (body: unknown) => {
+      // Only successful responses are replayable; failures may be retried.
+      if (res.statusCode < 400) {
+        entries.set(key, {
+          fingerprint,
+          status: res.statusCode,
+          body,
+          expiresAt: now + ttlMs()
+        });
+      }
+      return originalJson(body);
+    };
+    next();
+  };
+}
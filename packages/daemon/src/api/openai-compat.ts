@@ -0,0 +1,178 @@
+import type express from "express";
+import { ConsoleLogger, type Logger } from "@vuhlp/providers";
+import type { ProviderName, UUID } from "@vuhlp/contracts";
+import type { Runtime } from "../runtime/runtime.js";
+import { ApiRequestError, sendError } from "./errors.js";
+import { newId } from "../runtime/utils.js";
+
+const DEFAULT_COMPLETION_TIMEOUT_MS = 300_000;
+
+interface ChatMessagePart {
+  type: string;
+  text?: string;
+}
+
+interface ChatMessage {
+  role: string;
+  content: string | ChatMessagePart[];
+}
+
+interface ChatCompletionRequest {
+  model?: string;
+  messages?: ChatMessage[];
+  stream?: boolean;
+  user?: string;
+}
+
+interface Conversation {
+  runId: UUID;
+  nodeId: UUID;
+}
+
+function completionTimeoutMs(): number {
+  const raw = process.env.VUHLP_CHAT_TIMEOUT;
+  if (raw === undefined || raw.trim().length === 0) {
+    return DEFAULT_COMPLETION_TIMEOUT_MS;
+  }
+  const parsed = Number(raw);
+  return Number.isFinite(parsed) && parsed > 0 ? parsed : DEFAULT_COMPLETION_TIMEOUT_MS;
+}
+
+function providerForModel(model: string | undefined): ProviderName {
+  const normalized = (model ?? "").toLowerCase();
+  if (normalized.includes("claude")) {
+    return "claude";
+  }
+  if (normalized.includes("codex") || normalized.includes("gpt")) {
+    return "codex";
+  }
+  if (normalized.includes("gemini")) {
+    return "gemini";
+  }
+  const fallback = (process.env.VUHLP_CHAT_PROVIDER ?? "claude").toLowerCase();
+  return fallback === "codex" || fallback === "gemini" || fallback === "custom"
+    ? fallback
+    : "claude";
+}
+
+function textContent(message: ChatMessage): string {
+  if (typeof message.content === "string") {
+    return message.content;
+  }
+  return message.content
+    .filter((part) => part.type === "text" && typeof part.text === "string")
+    .map((part) => part.text)
+    .join("\n");
+}
+
+/**
+ * OpenAI-compatible chat facade: POST /v1/chat/completions maps each
+ * conversation onto a run with a single node, so stock chat clients can
+ * drive the agent while tool use and approvals run underneath. The
+ * conversation is identified by the X-Conversation-Id header (or the
+ * OpenAI `user` field); unidentified requests get a fresh run per call.
+ */
+export function registerOpenAiCompat(app: express.Express, runtime: Runtime, logger?: Logger): void {
+  const log = logger ?? new ConsoleLogger({ scope: "openai-compat" });
+  const conversations = new Map<string, Conversation>();
+
+  const resolveConversation = (key: string | undefined, model: string | undefined): Conversation => {
+    if (key) {
+      const existing = conversations.get(key);
+      if (existing) {
+        try {
+          runtime.getRun(existing.runId);
+          return existing;
+        } catch {
+          conversations.delete(key);
+        }
+      }
+    }
+    const run = runtime.createRun({ mode: "INTERACTIVE" });
+    const node = runtime.createNode(run.id, {
+      label: key ? `chat ${key}` : "chat",
+      provider: providerForModel(model),
+      roleTemplate: "implementer"
+    });
+    const conversation: Conversation = { runId: run.id, nodeId: node.id };
+    if (key) {
+      conversations.set(key, conversation);
+    }
+    log.info("chat conversation mapped to run", { key, runId: run.id, nodeId: node.id });
+    return conversation;
+  };
+
+  const waitForCompletion = (
+    conversation: Conversation
+  ): Promise<{ content: string; usage?: { promptTokens: number; completionTokens: number; totalTokens: number } }> => {
+    return new Promise((resolve, reject) => {
+      let usage: { promptTokens: number; completionTokens: number; totalTokens: number } | undefined;
+      const unsubscribe = runtime.onEvent((event) => {
+        if (event.runId !== conversation.runId) {
+          return;
+        }
+        if (event.type === "telemetry.usage" && event.nodeId === conversation.nodeId) {
+          usage = event.usage;
+          return;
+        }
+        if (event.type === "message.assistant.final" && event.nodeId === conversation.nodeId) {
+          cleanup();
+          resolve({ content: event.content, usage });
+        }
+      });
+      const timer = setTimeout(() => {
+        cleanup();
+        reject(new ApiRequestError("internal", "timed out waiting for the completion"));
+      }, completionTimeoutMs());
+      const cleanup = (): void => {
+        clearTimeout(timer);
+        unsubscribe();
+      };
+    });
+  };
+
+  app.post("/v1/chat/completions", async (req, res) => {
+    try {
+      const body = req.body as ChatCompletionRequest;
+      if (body.stream) {
+        throw new ApiRequestError(
+          "invalid_request",
+          "streaming is not supported; subscribe to the WebSocket feed instead"
+        );
+      }
+      const lastUser = [...(body.messages ?? [])].reverse().find((message) => message.role === "user");
+      const content = lastUser ? textContent(lastUser).trim() : "";
+      if (!content) {
+        throw new ApiRequestError("invalid_request", "messages must include a user message with text content");
+      }
+      const header = req.headers["x-conversation-id"];
+      const key = typeof header === "string" && header.trim() ? header.trim() : body.user;
+      const conversation = resolveConversation(key, body.model);
+      const pending = waitForCompletion(conversation);
+      runtime.postMessage(conversation.runId, conversation.nodeId, content);
+      const result = await pending;
+      res.json({
+        id: `chatcmpl-${newId()}`,
+        object: "chat.completion",
+        created: Math.floor(Date.now() / 1000),
+        model: body.model ?? "vuhlp",
+        choices: [
+          {
+            index: 0,
+            message: { role: "assistant", content: result.content },
+            finish_reason: "stop"
+          }
+        ],
+        usage: result.usage
+          ? {
+            prompt_tokens: result.usage.promptTokens,
+            completion_tokens: result.usage.completionTokens,
+            total_tokens: result.usage.totalTokens
+          }
+          : undefined
+      });
+    } catch (error) {
+      sendError(res, error, "invalid_request");
+    }
+  });
+}
@@ -1,21 +1,52 @@
 import express from "express";
 import type { ParsedQs } from "qs";
 import http from "http";
-import { WebSocketServer } from "ws";
+import https from "https";
+import { WebSocketServer, type WebSocket } from "ws";
 import type { Runtime } from "../runtime/runtime.js";
+import { ApiRequestError, sendError } from "./errors.js";
+import { idempotencyMiddleware } from "./idempotency.js";
+import { registerOpenAiCompat } from "./openai-compat.js";
+import { renderApprovalLinkPage, renderApprovalLinkResult } from "../runtime/approval-links.js";
 import type {
+  AddApprovalCommentRequest,
+  AddAutoApproveRuleRequest,
+  ApiKeyScope,
+  CreateApiKeyRequest,
+  CreateBootstrapRunRequest,
   CreateEdgeRequest,
   CreateNodeRequest,
+  CreateRunBatchRequest,
+  CreateRunFromTemplateRequest,
   CreateRunRequest,
   CreateTemplateRequest,
+  CreateVariantRunsRequest,
+  CreateWebhookRequest,
+  CreatePlanRequest,
+  GenerateConsensusPlanRequest,
+  ImportConversationRequest,
   PostChatRequest,
+  PutRunTemplateRequest,
+  PutSpecRequest,
+  RerunRunRequest,
   ResolveApprovalRequest,
+  StartSpecLoopRequest,
+  UpdateRunBudgetRequest,
   UpdateRunRequest,
   UpdateNodeRequest,
   UpdateTemplateRequest
 } from "@vuhlp/contracts";
 
-export function createServer(runtime: Runtime): http.Server {
+export interface ServerTlsOptions {
+  cert: string;
+  key: string;
+}
+
+export interface ServerOptions {
+  tls?: ServerTlsOptions;
+}
+
+export function createServer(runtime: Runtime, options?: ServerOptions): http.Server {
   const app = express();
   app.use(express.json({ limit: "4mb" }));
 
@@ -32,12 +63,44 @@ export function createServer(runtime: Runtime): http.Server {
     return undefined;
   };
 
+  const parseEventTypes = (value: string | undefined): string[] | undefined => {
+    if (!value) {
+      return undefined;
+    }
+    const types = value
+      .split(",")
+      .map((type) => type.trim())
+      .filter((type) => type.length > 0);
+    return types.length > 0 ? types : undefined;
+  };
+
+
 
+  // Strict same-origin by default: CORS headers are only sent for origins
+  // listed in VUHLP_CORS_ORIGINS ("*" restores the old wildcard behavior).
+  const corsOrigins = (process.env.VUHLP_CORS_ORIGINS ?? "")
+    .split(",")
+    .map((origin) => origin.trim().replace(/\/+$/, ""))
+    .filter((origin) => origin.length > 0);
+  const corsMethods = process.env.VUHLP_CORS_METHODS ?? "GET,POST,PATCH,DELETE,OPTIONS";
+  const corsHeaders = process.env.VUHLP_CORS_HEADERS ?? "Content-Type, Authorization, X-Api-Key";
 
   app.use((req, res, next) => {
-    res.setHeader("Access-Control-Allow-Origin", "*");
-    res.setHeader("Access-Control-Allow-Methods", "GET,POST,PATCH,DELETE,OPTIONS");
-    res.setHeader("Access-Control-Allow-Headers", "Content-Type");
+    const origin = req.headers.origin;
+    const allowed =
+      corsOrigins.includes("*")
+        ? "*"
+        : origin && corsOrigins.includes(origin.replace(/\/+$/, ""))
+          ? origin
+          : null;
+    if (allowed) {
+      res.setHeader("Access-Control-Allow-Origin", allowed);
+      res.setHeader("Access-Control-Allow-Methods", corsMethods);
+      res.setHeader("Access-Control-Allow-Headers", corsHeaders);
+      if (allowed !== "*") {
+        res.setHeader("Vary", "Origin");
+      }
+    }
     if (req.method === "OPTIONS") {
       res.status(204).end();
       return;
@@ -45,28 +108,107 @@ export function createServer(runtime: Runtime): http.Server {
     next();
   });
 
+  const extractApiKey = (req: express.Request): string | undefined => {
+    const header = req.headers.authorization;
+    if (typeof header === "string" && header.toLowerCase().startsWith("bearer ")) {
+      return header.slice("bearer ".length).trim();
+    }
+    const apiKeyHeader = req.headers["x-api-key"];
+    return typeof apiKeyHeader === "string" ? apiKeyHeader : undefined;
+  };
+
+  const requiredScope = (req: express.Request): ApiKeyScope => {
+    if (req.path.startsWith("/api/keys")) {
+      return "full";
+    }
+    if (req.method === "GET" || req.method === "HEAD") {
+      return "read";
+    }
+    if (req.path.startsWith("/api/approvals/")) {
+      return "approve";
+    }
+    return "full";
+  };
+
+  // No-op until the first key exists; after that every request must carry a
+  // key whose scopes cover the route (read-only / approve-only / full).
+  app.use((req, res, next) => {
+    // Signed approval links (/a/...) carry their own HMAC token; requiring
+    // the API key too would defeat their point.
+    if (req.path === "/healthz" || req.path === "/readyz" || req.path.startsWith("/a/")) {
+      next();
+      return;
+    }
+    void (async () => {
+      if (!(await runtime.authEnforced())) {
+        next();
+        return;
+      }
+      const info = await runtime.verifyApiKey(extractApiKey(req), requiredScope(req));
+      if (!info) {
+        sendError(res, new ApiRequestError("unauthorized", "missing or insufficient API key"));
+        return;
+      }
+      // Routes that record decisions use the key's name as the identity
+      // of record, so "who approved this" never relies on client claims.
+      res.locals.identity = info.name;
+      next();
+    })().catch((error) => {
+      sendError(res, error);
+    });
+  });
+
+  app.use(idempotencyMiddleware());
+
+  app.get("/healthz", (_req, res) => {
+    res.json({ ok: true });
+  });
+
+  app.get("/readyz", async (_req, res) => {
+    try {
+      const result = await runtime.checkReadiness();
+      res.status(result.ready ? 200 : 503).json(result);
+    } catch (error) {
+      sendError(res, error);
+    }
+  });
+
   app.get("/api/fs/list", async (req, res) => {
     try {
       const dirPath = typeof req.query.path === "string" ? req.query.path : undefined;
       const result = await runtime.listDirectory(dirPath);
       res.json(result);
     } catch (error) {
-      res.status(500).json({ error: String(error) });
+      sendError(res, error);
     }
   });
 
-  app.post("/api/runs", (req, res) => {
+  app.post("/api/runs", async (req, res) => {
     const body = req.body as CreateRunRequest;
-    const run = runtime.createRun({
-      mode: body?.mode,
-      globalMode: body?.globalMode,
-      cwd: body?.cwd
-    });
-    res.json({ run });
+    try {
+      let run = runtime.createRun({
+        mode: body?.mode,
+        globalMode: body?.globalMode,
+        cwd: body?.cwd,
+        envSet: body?.envSet,
+        sandboxImage: body?.sandboxImage,
+        readOnly: body?.readOnly,
+        budget: body?.budget
+      });
+      if (body?.isolateWorkspace) {
+        run = await runtime.isolateWorkspace(run.id);
+      }
+      if (body?.autoCommit) {
+        run = await runtime.enableAutoCommit(run.id);
+      }
+      res.json({ run });
+    } catch (error) {
+      sendError(res, error, "invalid_request");
+    }
   });
 
   app.get("/api/runs", (_req, res) => {
-    res.json({ runs: runtime.listRuns() });
+    res.json({ runs: runtime.listRunSummaries() });
   });
 
   app.get("/api/runs/:id", (req, res) => {
@@ -74,17 +216,328 @@ export function createServer(runtime: Runtime): http.Server {
       const run = runtime.getRun(req.params.id);
       res.json({ run });
     } catch (error) {
-      res.status(404).json({ error: String(error) });
+      sendError(res, error, "not_found");
     }
   });
 
   app.patch("/api/runs/:id", (req, res) => {
     try {
       const body = req.body as UpdateRunRequest;
-      const run = runtime.updateRun(req.params.id, body.patch ?? {});
+      const run = runtime.updateRun(req.params.id, body.patch ?? {}, body.cancellation);
+      res.json({ run });
+    } catch (error) {
+      sendError(res, error, "invalid_request");
+    }
+  });
+
+  app.post("/api/runs/:id/pause", (req, res) => {
+    try {
+      const run = runtime.pauseRun(req.params.id);
+      res.json({ run });
+    } catch (error) {
+      sendError(res, error, "invalid_request");
+    }
+  });
+
+  app.post("/api/runs/:id/resume", (req, res) => {
+    try {
+      const run = runtime.resumeRun(req.params.id);
+      res.json({ run });
+    } catch (error) {
+      sendError(res, error, "invalid_request");
+    }
+  });
+
+  app.put("/api/runs/:id/budget", (req, res) => {
+    try {
+      const body = req.body as UpdateRunBudgetRequest;
+      const run = runtime.setRunBudget(req.params.id, body?.budget ?? {});
+      res.json({ run });
+    } catch (error) {
+      sendError(res, error, "invalid_request");
+    }
+  });
+
+  app.get("/api/run-templates", async (_req, res) => {
+    try {
+      const runTemplates = await runtime.listRunTemplates();
+      res.json({ runTemplates });
+    } catch (error) {
+      sendError(res, error);
+    }
+  });
+
+  app.put("/api/run-templates/:name", async (req, res) => {
+    try {
+      const body = req.body as PutRunTemplateRequest | undefined;
+      if (!body?.template) {
+        sendError(res, new ApiRequestError("invalid_request", "template is required"));
+        return;
+      }
+      const runTemplate = await runtime.putRunTemplate(req.params.name, body.template);
+      res.json({ runTemplate });
+    } catch (error) {
+      sendError(res, error, "invalid_request");
+    }
+  });
+
+  app.delete("/api/run-templates/:name", async (req, res) => {
+    try {
+      await runtime.deleteRunTemplate(req.params.name);
+      res.json({ name: req.params.name });
+    } catch (error) {
+      sendError(res, error, "not_found");
+    }
+  });
+
+  app.post("/api/runs/from-template", async (req, res) => {
+    try {
+      const body = req.body as CreateRunFromTemplateRequest | undefined;
+      if (!body?.name) {
+        sendError(res, new ApiRequestError("invalid_request", "template name is required"));
+        return;
+      }
+      const run = await runtime.createRunFromTemplate(body.name, body.params);
+      res.json({ run });
+    } catch (error) {
+      sendError(res, error, "invalid_request");
+    }
+  });
+
+  app.post("/api/runs/bootstrap", (req, res) => {
+    try {
+      const body = req.body as CreateBootstrapRunRequest | undefined;
+      const result = runtime.createBootstrapRun({ cwd: body?.cwd, provider: body?.provider });
+      res.json(result);
+    } catch (error) {
+      sendError(res, error, "invalid_request");
+    }
+  });
+
+  app.post("/api/runs/import", (req, res) => {
+    try {
+      const body = req.body as ImportConversationRequest | undefined;
+      if (!body?.format || typeof body.content !== "string") {
+        sendError(res, new ApiRequestError("invalid_request", "format and content are required"));
+        return;
+      }
+      const result = runtime.importConversation({
+        format: body.format,
+        content: body.content,
+        label: body.label,
+        provider: body.provider,
+        mode: body.mode,
+        cwd: body.cwd
+      });
+      res.json(result);
+    } catch (error) {
+      sendError(res, error, "invalid_request");
+    }
+  });
+
+  app.post("/api/runs/batch", async (req, res) => {
+    try {
+      const body = req.body as CreateRunBatchRequest | undefined;
+      if (!body?.specsDir || typeof body.specsDir !== "string") {
+        sendError(res, new ApiRequestError("invalid_request", "specsDir is required"));
+        return;
+      }
+      const result = await runtime.createRunBatch({
+        specsDir: body.specsDir,
+        filter: body.filter,
+        mode: body.mode,
+        globalMode: body.globalMode,
+        cwd: body.cwd,
+        provider: body.provider
+      });
+      res.json(result);
+    } catch (error) {
+      sendError(res, error, "invalid_request");
+    }
+  });
+
+  app.post("/api/runs/variants", async (req, res) => {
+    try {
+      const body = req.body as CreateVariantRunsRequest | undefined;
+      if (!body?.spec || !Array.isArray(body.variants)) {
+        sendError(res, new ApiRequestError("invalid_request", "spec and variants are required"));
+        return;
+      }
+      const result = await runtime.createVariantRuns({
+        spec: body.spec,
+        variants: body.variants,
+        mode: body.mode,
+        globalMode: body.globalMode,
+        cwd: body.cwd
+      });
+      res.json(result);
+    } catch (error) {
+      sendError(res, error, "invalid_request");
+    }
+  });
+
+  app.get("/api/batches/:id/compare", (req, res) => {
+    try {
+      res.json(runtime.compareBatch(req.params.id));
+    } catch (error) {
+      sendError(res, error, "not_found");
+    }
+  });
+
+  app.get("/api/batches/:id", (req, res) => {
+    const runs = runtime.listBatchRuns(req.params.id);
+    if (runs.length === 0) {
+      sendError(res, new ApiRequestError("not_found", `Batch ${req.params.id} not found`));
+      return;
+    }
+    res.json({ batchId: req.params.id, runs });
+  });
+
+  app.post("/api/batches/:id/stop", (req, res) => {
+    try {
+      const runs = runtime.listBatchRuns(req.params.id);
+      if (runs.length === 0) {
+        sendError(res, new ApiRequestError("not_found", `Batch ${req.params.id} not found`));
+        return;
+      }
+      const stopped = runtime.stopBatch(req.params.id);
+      res.json({ batchId: req.params.id, stopped });
+    } catch (error) {
+      sendError(res, error);
+    }
+  });
+
+  app.post("/api/runs/:id/rerun", (req, res) => {
+    try {
+      const body = req.body as RerunRunRequest | undefined;
+      const run = runtime.rerunRun(req.params.id, { globalMode: body?.globalMode });
       res.json({ run });
     } catch (error) {
-      res.status(400).json({ error: String(error) });
+      sendError(res, error, "not_found");
+    }
+  });
+
+  app.get("/api/runs/:id/usage", async (req, res) => {
+    try {
+      const result = await runtime.getUsage(req.params.id);
+      res.json(result);
+    } catch (error) {
+      sendError(res, error, "not_found");
+    }
+  });
+
+  app.get("/api/runs/:id/nodes/:nodeId/usage", async (req, res) => {
+    try {
+      const result = await runtime.getUsage(req.params.id, req.params.nodeId);
+      res.json(result);
+    } catch (error) {
+      sendError(res, error, "not_found");
+    }
+  });
+
+  app.get("/api/stats/tools", async (_req, res) => {
+    try {
+      const result = await runtime.getToolStats();
+      res.json(result);
+    } catch (error) {
+      sendError(res, error, "internal");
+    }
+  });
+
+  app.get("/api/audit", async (_req, res) => {
+    try {
+      const result = await runtime.exportAudit();
+      res.json(result);
+    } catch (error) {
+      sendError(res, error, "internal");
+    }
+  });
+
+  app.get("/api/admin/halt", (_req, res) => {
+    res.json(runtime.haltState());
+  });
+
+  app.post("/api/admin/halt", (req, res) => {
+    try {
+      const body = req.body as { reason?: string } | undefined;
+      res.json(runtime.halt(typeof body?.reason === "string" ? body.reason : undefined));
+    } catch (error) {
+      sendError(res, error, "internal");
+    }
+  });
+
+  app.post("/api/admin/resume", (_req, res) => {
+    try {
+      res.json(runtime.resumeFromHalt());
+    } catch (error) {
+      sendError(res, error, "internal");
+    }
+  });
+
+  app.get("/api/runs/:id/specs", async (req, res) => {
+    try {
+      const specs = await runtime.listSpecs(req.params.id);
+      res.json({ specs });
+    } catch (error) {
+      sendError(res, error, "not_found");
+    }
+  });
+
+  app.get("/api/runs/:id/specs/:name", async (req, res) => {
+    try {
+      const spec = await runtime.getSpec(req.params.id, req.params.name);
+      res.json(spec);
+    } catch (error) {
+      sendError(res, error, "not_found");
+    }
+  });
+
+  app.put("/api/runs/:id/specs/:name", async (req, res) => {
+    try {
+      const body = req.body as PutSpecRequest | undefined;
+      if (typeof body?.content !== "string") {
+        sendError(res, new ApiRequestError("invalid_request", "content is required"));
+        return;
+      }
+      const result = await runtime.putSpec(req.params.id, req.params.name, body.content);
+      res.status(result.created ? 201 : 200).json(result);
+    } catch (error) {
+      sendError(res, error, "invalid_request");
+    }
+  });
+
+  app.delete("/api/runs/:id/specs/:name", async (req, res) => {
+    try {
+      await runtime.deleteSpec(req.params.id, req.params.name);
+      res.json({ name: req.params.name });
+    } catch (error) {
+      sendError(res, error, "not_found");
+    }
+  });
+
+  app.post("/api/runs/:id/specs/:name/acceptance", async (req, res) => {
+    try {
+      const result = await runtime.runAcceptance(req.params.id, req.params.name);
+      res.json(result);
+    } catch (error) {
+      sendError(res, error, "invalid_request");
+    }
+  });
+
+  app.get("/api/runs/:id/export", async (req, res) => {
+    try {
+      const format = getQueryString(req.query.format);
+      if (format !== "aider" && format !== "continue" && format !== "generic") {
+        sendError(res, new ApiRequestError("invalid_request", "format must be aider, continue, or generic"));
+        return;
+      }
+      const nodeId = getQueryString(req.query.nodeId);
+      const result = await runtime.exportConversation(req.params.id, format, nodeId);
+      res.setHeader("Content-Type", result.contentType);
+      res.setHeader("Content-Disposition", `attachment; filename="${result.filename}"`);
+      res.send(result.content);
+    } catch (error) {
+      sendError(res, error, "not_found");
     }
   });
 
@@ -94,22 +547,35 @@ export function createServer(runtime: Runtime): http.Server {
       const beforeParam = getQueryString(req.query.before);
       const limit = limitParam ? Number.parseInt(limitParam, 10) : DEFAULT_EVENTS_PAGE_SIZE;
       if (!Number.isFinite(limit) || limit <= 0 || limit > MAX_EVENTS_PAGE_SIZE) {
-        res.status(400).json({ error: `limit must be between 1 and ${MAX_EVENTS_PAGE_SIZE}` });
+        sendError(
+          res,
+          new ApiRequestError("invalid_request", `limit must be between 1 and ${MAX_EVENTS_PAGE_SIZE}`)
+        );
         return;
       }
       let before: number | undefined;
       if (beforeParam) {
         const parsed = Number.parseInt(beforeParam, 10);
         if (!Number.isFinite(parsed) || parsed < 0) {
-          res.status(400).json({ error: "before must be a non-negative integer cursor" });
+          sendError(res, new ApiRequestError("invalid_request", "before must be a non-negative integer cursor"));
           return;
         }
         before = parsed;
       }
-      const result = await runtime.getEvents(req.params.id, { limit, before });
+      const types = parseEventTypes(getQueryString(req.query.types));
+      const result = await runtime.getEvents(req.params.id, { limit, before, types });
+      res.json(result);
+    } catch (error) {
+      sendError(res, error, "not_found");
+    }
+  });
+
+  app.post("/api/admin/reload", async (_req, res) => {
+    try {
+      const result = await runtime.reloadConfig();
       res.json(result);
     } catch (error) {
-      res.status(404).json({ error: String(error) });
+      sendError(res, error);
     }
   });
 
@@ -118,7 +584,7 @@ export function createServer(runtime: Runtime): http.Server {
       const result = await runtime.listTemplates();
       res.json(result);
     } catch (error) {
-      res.status(500).json({ error: String(error) });
+      sendError(res, error);
     }
   });
 
@@ -127,7 +593,7 @@ export function createServer(runtime: Runtime): http.Server {
       const template = await runtime.getRoleTemplate(req.params.name);
       res.json(template);
     } catch (error) {
-      res.status(400).json({ error: String(error) });
+      sendError(res, error, "invalid_request");
     }
   });
 
@@ -137,7 +603,7 @@ export function createServer(runtime: Runtime): http.Server {
       const result = await runtime.createTemplate(body.name, body.content);
       res.status(201).json(result);
     } catch (error) {
-      res.status(400).json({ error: String(error) });
+      sendError(res, error, "invalid_request");
     }
   });
 
@@ -147,7 +613,7 @@ export function createServer(runtime: Runtime): http.Server {
       const result = await runtime.updateTemplate(req.params.name, body.content);
       res.json(result);
     } catch (error) {
-      res.status(400).json({ error: String(error) });
+      sendError(res, error, "invalid_request");
     }
   });
 
@@ -156,7 +622,7 @@ export function createServer(runtime: Runtime): http.Server {
       const result = await runtime.deleteTemplate(req.params.name);
       res.json(result);
     } catch (error) {
-      res.status(400).json({ error: String(error) });
+      sendError(res, error, "invalid_request");
     }
   });
 
@@ -165,7 +631,7 @@ export function createServer(runtime: Runtime): http.Server {
       await runtime.deleteRun(req.params.id);
       res.json({ runId: req.params.id });
     } catch (error) {
-      res.status(404).json({ error: String(error) });
+      sendError(res, error, "not_found");
     }
   });
 
@@ -175,7 +641,7 @@ export function createServer(runtime: Runtime): http.Server {
       const node = runtime.createNode(req.params.id, body.node);
       res.json({ node });
     } catch (error) {
-      res.status(400).json({ error: String(error) });
+      sendError(res, error, "invalid_request");
     }
   });
 
@@ -185,7 +651,7 @@ export function createServer(runtime: Runtime): http.Server {
       const node = runtime.updateNode(req.params.id, req.params.nodeId, body.patch ?? {}, body.config);
       res.json({ node });
     } catch (error) {
-      res.status(400).json({ error: String(error) });
+      sendError(res, error, "invalid_request");
     }
   });
 
@@ -194,7 +660,30 @@ export function createServer(runtime: Runtime): http.Server {
       await runtime.deleteNode(req.params.id, req.params.nodeId);
       res.json({ nodeId: req.params.nodeId });
     } catch (error) {
-      res.status(400).json({ error: String(error) });
+      sendError(res, error, "invalid_request");
+    }
+  });
+
+  app.post("/api/runs/:id/nodes/:nodeId/auto-approve", (req, res) => {
+    try {
+      const body = req.body as AddAutoApproveRuleRequest;
+      const rule = runtime.addAutoApproveRule(req.params.id, req.params.nodeId, {
+        tool: body?.tool,
+        command: body?.command,
+        ttlMs: body?.ttlMs
+      });
+      res.json({ rule });
+    } catch (error) {
+      sendError(res, error, "invalid_request");
+    }
+  });
+
+  app.delete("/api/runs/:id/nodes/:nodeId/auto-approve/:ruleId", (req, res) => {
+    try {
+      runtime.removeAutoApproveRule(req.params.id, req.params.nodeId, req.params.ruleId);
+      res.json({ ruleId: req.params.ruleId });
+    } catch (error) {
+      sendError(res, error, "invalid_request");
     }
   });
 
@@ -203,7 +692,7 @@ export function createServer(runtime: Runtime): http.Server {
       await runtime.resetNode(req.params.id, req.params.nodeId);
       res.json({ ok: true });
     } catch (error) {
-      res.status(400).json({ error: String(error) });
+      sendError(res, error, "invalid_request");
     }
   });
 
@@ -212,7 +701,7 @@ export function createServer(runtime: Runtime): http.Server {
       await runtime.startNodeProcess(req.params.id, req.params.nodeId);
       res.json({ ok: true });
     } catch (error) {
-      res.status(400).json({ error: String(error) });
+      sendError(res, error, "invalid_request");
     }
   });
 
@@ -221,7 +710,7 @@ export function createServer(runtime: Runtime): http.Server {
       await runtime.stopNodeProcess(req.params.id, req.params.nodeId);
       res.json({ ok: true });
     } catch (error) {
-      res.status(400).json({ error: String(error) });
+      sendError(res, error, "invalid_request");
     }
   });
 
@@ -230,7 +719,66 @@ export function createServer(runtime: Runtime): http.Server {
       await runtime.interruptNodeProcess(req.params.id, req.params.nodeId);
       res.json({ ok: true });
     } catch (error) {
-      res.status(400).json({ error: String(error) });
+      sendError(res, error, "invalid_request");
+    }
+  });
+
+  app.post("/api/runs/:id/nodes/:nodeId/turns/:turnId/cancel", async (req, res) => {
+    try {
+      const body = req.body as { reason?: string; actor?: string } | undefined;
+      await runtime.cancelTurn(req.params.id, req.params.nodeId, req.params.turnId, {
+        reason: body?.reason,
+        actor: body?.actor
+      });
+      res.json({ ok: true });
+    } catch (error) {
+      sendError(res, error, "invalid_request");
+    }
+  });
+
+  app.get("/api/runs/:id/checkpoints", async (req, res) => {
+    try {
+      const result = await runtime.listCheckpoints(req.params.id);
+      res.json(result);
+    } catch (error) {
+      sendError(res, error, "not_found");
+    }
+  });
+
+  app.get("/api/runs/:id/files", async (req, res) => {
+    try {
+      const filePath = getQueryString(req.query.path);
+      const turnParam = getQueryString(req.query.turn);
+      if (!filePath) {
+        sendError(res, new ApiRequestError("invalid_request", "path query parameter is required"));
+        return;
+      }
+      const turn = turnParam ? Number.parseInt(turnParam, 10) : NaN;
+      if (!Number.isFinite(turn) || turn <= 0) {
+        sendError(res, new ApiRequestError("invalid_request", "turn must be a positive integer"));
+        return;
+      }
+      const result = await runtime.getFileAtTurn(req.params.id, filePath, turn);
+      res.json(result);
+    } catch (error) {
+      sendError(res, error, "not_found");
+    }
+  });
+
+  app.get("/api/runs/:id/patch", async (req, res) => {
+    try {
+      const format = getQueryString(req.query.format);
+      if (format === "series") {
+        const result = await runtime.getRunPatchSeries(req.params.id);
+        res.json(result);
+        return;
+      }
+      const patch = await runtime.getRunPatch(req.params.id);
+      res.setHeader("Content-Type", "text/x-patch; charset=utf-8");
+      res.setHeader("Content-Disposition", `attachment; filename="${patch.filename}"`);
+      res.send(patch.content);
+    } catch (error) {
+      sendError(res, error, "not_found");
     }
   });
 
@@ -239,7 +787,132 @@ export function createServer(runtime: Runtime): http.Server {
       const result = await runtime.getArtifactContent(req.params.id, req.params.artifactId);
       res.json(result);
     } catch (error) {
-      res.status(404).json({ error: String(error) });
+      sendError(res, error, "not_found");
+    }
+  });
+
+  app.get("/api/runs/:id/nodes/:nodeId/context", async (req, res) => {
+    try {
+      const result = await runtime.getContextPack(req.params.id, req.params.nodeId);
+      if (getQueryString(req.query.format) === "markdown") {
+        res.setHeader("Content-Type", "text/markdown; charset=utf-8");
+        res.send(result.markdown);
+        return;
+      }
+      res.json({ contextPack: result.contextPack, tokenEstimate: result.tokenEstimate });
+    } catch (error) {
+      sendError(res, error, "not_found");
+    }
+  });
+
+  app.post("/api/runs/:id/nodes/:nodeId/context/pin", async (req, res) => {
+    try {
+      const result = await runtime.pinContextPack(req.params.id, req.params.nodeId);
+      res.json(result);
+    } catch (error) {
+      sendError(res, error, "invalid_request");
+    }
+  });
+
+  app.post("/api/runs/:id/plan/draft", async (req, res) => {
+    try {
+      const body = req.body as GenerateConsensusPlanRequest;
+      const result = await runtime.generateConsensusPlan(req.params.id, body.nodeId, {
+        goal: body.goal,
+        context: body.context
+      });
+      res.json({
+        plan: result.plan,
+        candidates: result.candidates,
+        reconciledBy: result.result.reconciledBy
+      });
+    } catch (error) {
+      sendError(res, error, "invalid_request");
+    }
+  });
+
+  app.post("/api/runs/:id/plan", (req, res) => {
+    try {
+      const body = req.body as CreatePlanRequest;
+      const plan = runtime.createPlan(req.params.id, body);
+      res.json({ plan });
+    } catch (error) {
+      sendError(res, error, "invalid_request");
+    }
+  });
+
+  app.get("/api/runs/:id/plan", (req, res) => {
+    try {
+      const plan = runtime.getPlan(req.params.id);
+      res.json({ plan });
+    } catch (error) {
+      sendError(res, error, "not_found");
+    }
+  });
+
+  app.put("/api/runs/:id/plan", (req, res) => {
+    try {
+      const body = req.body as CreatePlanRequest;
+      const plan = runtime.updatePlan(req.params.id, body);
+      res.json({ plan });
+    } catch (error) {
+      sendError(res, error, "invalid_request");
+    }
+  });
+
+  app.post("/api/runs/:id/plan/approve", (req, res) => {
+    try {
+      const identity = typeof res.locals.identity === "string" ? res.locals.identity : undefined;
+      const plan = runtime.approvePlan(req.params.id, identity);
+      res.json({ plan });
+    } catch (error) {
+      sendError(res, error, "invalid_request");
+    }
+  });
+
+  app.post("/api/runs/:id/plan/start", (req, res) => {
+    try {
+      const plan = runtime.startPlan(req.params.id);
+      res.json({ plan });
+    } catch (error) {
+      sendError(res, error, "invalid_request");
+    }
+  });
+
+  app.post("/api/runs/:id/plan/cancel", (req, res) => {
+    try {
+      const plan = runtime.cancelPlan(req.params.id);
+      res.json({ plan });
+    } catch (error) {
+      sendError(res, error, "invalid_request");
+    }
+  });
+
+  app.post("/api/runs/:id/loop", async (req, res) => {
+    try {
+      const body = req.body as StartSpecLoopRequest;
+      const loop = await runtime.startSpecLoop(req.params.id, body);
+      res.json({ loop });
+    } catch (error) {
+      sendError(res, error, "invalid_request");
+    }
+  });
+
+  app.get("/api/runs/:id/loop", (req, res) => {
+    try {
+      const loop = runtime.getSpecLoop(req.params.id);
+      res.json({ loop });
+    } catch (error) {
+      sendError(res, error, "not_found");
+    }
+  });
+
+  app.post("/api/runs/:id/loop/cancel", (req, res) => {
+    try {
+      const loop = runtime.cancelSpecLoop(req.params.id);
+      res.json({ loop });
+    } catch (error) {
+      sendError(res, error, "invalid_request");
     }
   });
 
@@ -249,7 +922,7 @@ export function createServer(runtime: Runtime): http.Server {
       const edge = runtime.createEdge(req.params.id, body.edge);
       res.json({ edge });
     } catch (error) {
-      res.status(400).json({ error: String(error) });
+      sendError(res, error, "invalid_request");
     }
   });
 
@@ -258,7 +931,7 @@ export function createServer(runtime: Runtime): http.Server {
       runtime.deleteEdge(req.params.id, req.params.edgeId);
       res.json({ edgeId: req.params.edgeId });
     } catch (error) {
-      res.status(400).json({ error: String(error) });
+      sendError(res, error, "invalid_request");
     }
   });
 
@@ -268,7 +941,7 @@ export function createServer(runtime: Runtime): http.Server {
       const message = runtime.postMessage(req.params.id, body.nodeId, body.content, body.interrupt);
       res.json({ messageId: message.id });
     } catch (error) {
-      res.status(400).json({ error: String(error) });
+      sendError(res, error, "invalid_request");
     }
   });
 
@@ -276,35 +949,192 @@ export function createServer(runtime: Runtime): http.Server {
     res.json({ approvals: runtime.listApprovals() });
   });
 
+  app.post("/api/approvals/:id/comments", (req, res) => {
+    const body = req.body as AddApprovalCommentRequest;
+    try {
+      const result = runtime.addApprovalComment(req.params.id, body.comment);
+      res.json(result);
+    } catch (error) {
+      sendError(res, error, "invalid_request");
+    }
+  });
+
   app.post("/api/approvals/:id/resolve", (req, res) => {
     const body = req.body as ResolveApprovalRequest;
     try {
+      // When auth is enforced the authenticated key's name overrides any
+      // client-claimed approver; otherwise the claim stands.
+      const identity = typeof res.locals.identity === "string" ? res.locals.identity : undefined;
+      const resolution = identity ? { ...body.resolution, approver: identity } : body.resolution;
       if (body.runId) {
-        runtime.resolveApproval(body.runId, req.params.id, body.resolution);
+        runtime.resolveApproval(body.runId, req.params.id, resolution);
       } else {
-        runtime.resolveApprovalById(req.params.id, body.resolution);
+        runtime.resolveApprovalById(req.params.id, resolution);
+      }
+      res.json({ approvalId: req.params.id, resolution });
+    } catch (error) {
+      sendError(res, error, "invalid_request");
+    }
+  });
+
+  // Signed approval links. GET renders the decision page and never
+  // mutates (notification apps prefetch URLs); POST applies the decision
+  // with the same token. A resolved approval makes its link dead.
+  app.get("/a/:id", (req, res) => {
+    const token = typeof req.query.token === "string" ? req.query.token : "";
+    try {
+      const { approval } = runtime.getApprovalForLink(req.params.id, token);
+      res.type("html").send(renderApprovalLinkPage(approval, token));
+    } catch (error) {
+      const message = error instanceof Error ? error.message : String(error);
+      res.status(404).type("html").send(renderApprovalLinkResult(message));
+    }
+  });
+
+  app.post("/a/:id", express.urlencoded({ extended: false }), (req, res) => {
+    const token = typeof req.query.token === "string" ? req.query.token : "";
+    const action = typeof req.body.action === "string" ? req.body.action : "";
+    try {
+      runtime.getApprovalForLink(req.params.id, token);
+      if (action !== "approve" && action !== "deny") {
+        throw new ApiRequestError("invalid_request", `unknown action: ${action}`);
       }
-      res.json({ approvalId: req.params.id, resolution: body.resolution });
+      runtime.resolveApprovalById(req.params.id, {
+        status: action === "approve" ? "approved" : "denied",
+        approver: "approval-link",
+        reason: "resolved via signed approval link"
+      });
+      res.type("html").send(renderApprovalLinkResult(action === "approve" ? "Approved." : "Denied."));
+    } catch (error) {
+      const message = error instanceof Error ? error.message : String(error);
+      res.status(400).type("html").send(renderApprovalLinkResult(message));
+    }
+  });
+
+  app.get("/api/keys", async (_req, res) => {
+    try {
+      const apiKeys = await runtime.listApiKeys();
+      res.json({ apiKeys });
+    } catch (error) {
+      sendError(res, error);
+    }
+  });
+
+  app.post("/api/keys", async (req, res) => {
+    try {
+      const body = req.body as CreateApiKeyRequest;
+      const result = await runtime.createApiKey(body.name, body.scopes);
+      res.json(result);
+    } catch (error) {
+      sendError(res, error, "invalid_request");
+    }
+  });
+
+  app.delete("/api/keys/:id", async (req, res) => {
+    try {
+      await runtime.revokeApiKey(req.params.id);
+      res.json({ apiKeyId: req.params.id });
+    } catch (error) {
+      sendError(res, error, "not_found");
+    }
+  });
+
+  app.get("/api/webhooks", async (_req, res) => {
+    try {
+      const webhooks = await runtime.listWebhooks();
+      res.json({ webhooks });
+    } catch (error) {
+      sendError(res, error);
+    }
+  });
+
+  app.post("/api/webhooks", async (req, res) => {
+    try {
+      const body = req.body as CreateWebhookRequest;
+      const { webhook, secret } = await runtime.createWebhook(body);
+      res.json({ webhook, secret });
     } catch (error) {
-      res.status(400).json({ error: String(error) });
+      sendError(res, error, "invalid_request");
     }
   });
 
-  const server = http.createServer(app);
+  app.delete("/api/webhooks/:id", async (req, res) => {
+    try {
+      await runtime.deleteWebhook(req.params.id);
+      res.json({ webhookId: req.params.id });
+    } catch (error) {
+      sendError(res, error, "not_found");
+    }
+  });
+
+  registerOpenAiCompat(app, runtime);
+
+  const parseMsEnv = (name: string, fallback: number): number => {
+    const raw = process.env[name];
+    if (raw === undefined || raw.trim().length === 0) {
+      return fallback;
+    }
+    const parsed = Number(raw);
+    return Number.isFinite(parsed) && parsed >= 0 ? parsed : fallback;
+  };
+
+  const server = options?.tls
+    ? https.createServer({ cert: options.tls.cert, key: options.tls.key }, app)
+    : http.createServer(app);
+  // Long model turns hold connections open well past Node's defaults;
+  // request timeout defaults to off, keep-alive stays above typical proxies.
+  server.requestTimeout = parseMsEnv("VUHLP_HTTP_REQUEST_TIMEOUT", 0);
+  server.keepAliveTimeout = parseMsEnv("VUHLP_HTTP_KEEPALIVE_TIMEOUT", 65_000);
+  server.headersTimeout = server.keepAliveTimeout + 1_000;
+
   const wss = new WebSocketServer({ server, path: "/ws" });
 
+  const pingInterval = parseMsEnv("VUHLP_WS_PING_INTERVAL", 15_000);
+  if (pingInterval > 0) {
+    const alive = new WeakSet<WebSocket>();
+    wss.on("connection", (socket) => {
+      alive.add(socket);
+      socket.on("pong", () => alive.add(socket));
+    });
+    const pingTimer = setInterval(() => {
+      for (const socket of wss.clients) {
+        if (!alive.has(socket)) {
+          socket.terminate();
+          continue;
+        }
+        alive.delete(socket);
+        socket.ping();
+      }
+    }, pingInterval);
+    server.on("close", () => clearInterval(pingTimer));
+  }
+
   wss.on("connection", (socket, req) => {
     const url = new URL(req.url ?? "/ws", `http://${req.headers.host ?? "localhost"}`);
     const runId = url.searchParams.get("runId");
-    const unsubscribe = runtime.onEvent((event) => {
-      if (runId && event.runId !== runId) {
-        return;
-      }
-      if (socket.readyState === socket.OPEN) {
-        socket.send(JSON.stringify(event));
+    const types = parseEventTypes(url.searchParams.get("types") ?? undefined);
+    void (async () => {
+      if (await runtime.authEnforced()) {
+        const token = url.searchParams.get("apiKey") ?? undefined;
+        const info = await runtime.verifyApiKey(token, "read");
+        if (!info) {
+          socket.close(4401, "missing or insufficient API key");
+          return;
+        }
       }
-    });
-    socket.on("close", () => unsubscribe());
+      const unsubscribe = runtime.onEvent((event) => {
+        if (runId && event.runId !== runId) {
+          return;
+        }
+        if (types && !types.includes(event.type)) {
+          return;
+        }
+        if (socket.readyState === socket.OPEN) {
+          socket.send(JSON.stringify(event));
+        }
+      });
+      socket.on("close", () => unsubscribe());
+    })();
   });
 
   return server;
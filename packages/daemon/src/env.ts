@@ -0,0 +1,117 @@
+import { existsSync, readFileSync } from "fs";
+import path from "path";
+import type { Logger } from "@vuhlp/providers";
+
+let envFilePath: string | null = null;
+/** Keys whose current values came from the .env file (vs the real environment). */
+const fileKeys = new Set<string>();
+
+function parseEnvFile(filePath: string): Record<string, string> {
+  const values: Record<string, string> = {};
+  const content = readFileSync(filePath, "utf8");
+  for (const line of content.split(/\r?\n/)) {
+    const trimmed = line.trim();
+    if (!trimmed || trimmed.startsWith("#")) {
+      continue;
+    }
+    const equalsIndex = trimmed.indexOf("=");
+    if (equalsIndex <= 0) {
+      continue;
+    }
+    const key = trimmed.slice(0, equalsIndex).trim();
+    if (!key) {
+      continue;
+    }
+    let value = trimmed.slice(equalsIndex + 1).trim();
+    if (
+      (value.startsWith('"') && value.endsWith('"')) ||
+      (value.startsWith("'") && value.endsWith("'"))
+    ) {
+      value = value.slice(1, -1);
+    }
+    values[key] = value;
+  }
+  return values;
+}
+
+export function findEnvFile(startDir: string, maxDepth = 4): string | null {
+  let current = startDir;
+  for (let depth = 0; depth <= maxDepth; depth += 1) {
+    const candidate = path.resolve(current, ".env");
+    if (existsSync(candidate)) {
+      return candidate;
+    }
+    const parent = path.dirname(current);
+    if (parent === current) {
+      break;
+    }
+    current = parent;
+  }
+  return null;
+}
+
+/**
+ * Loads the nearest .env file into process.env. Real environment
+ * variables win; file-sourced keys are tracked so reloadEnv can update
+ * them later without clobbering the environment.
+ */
+export function loadEnv(startDir: string, logger?: Logger): string | null {
+  const filePath = findEnvFile(startDir);
+  if (!filePath) {
+    return null;
+  }
+  envFilePath = filePath;
+  try {
+    const values = parseEnvFile(filePath);
+    for (const [key, value] of Object.entries(values)) {
+      if (process.env[key] !== undefined) {
+        continue;
+      }
+      process.env[key] = value;
+      fileKeys.add(key);
+    }
+  } catch (error) {
+    const message = error instanceof Error ? error.message : String(error);
+    logger?.warn("failed to load env file", { filePath, message });
+  }
+  return filePath;
+}
+
+/**
+ * Re-reads the .env file found at startup and applies changes to keys
+ * that are file-sourced (or new). Keys removed from the file are unset.
+ * Returns the keys whose values changed; callers decide which settings
+ * are hot-applicable.
+ */
+export function reloadEnv(logger?: Logger): { envPath: string | null; changed: string[] } {
+  if (!envFilePath || !existsSync(envFilePath)) {
+    return { envPath: envFilePath, changed: [] };
+  }
+  const changed: string[] = [];
+  try {
+    const values = parseEnvFile(envFilePath);
+    for (const [key, value] of Object.entries(values)) {
+      const fromFile = fileKeys.has(key);
+      if (!fromFile && process.env[key] !== undefined) {
+        // Real environment variable; never override on reload.
+        continue;
+      }
+      if (process.env[key] !== value) {
+        process.env[key] = value;
+        changed.push(key);
+      }
+      fileKeys.add(key);
+    }
+    for (const key of [...fileKeys]) {
+      if (!(key in values)) {
+        delete process.env[key];
+        fileKeys.delete(key);
+        changed.push(key);
+      }
+    }
+  } catch (error) {
+    const message = error instanceof Error ? error.message : String(error);
+    logger?.warn("failed to reload env file", { filePath: envFilePath, message });
+  }
+  return { envPath: envFilePath, changed };
+}
@@ -1,66 +1,19 @@
-import { existsSync, readFileSync } from "fs";
+import { execFile as execFileCallback } from "child_process";
+import { existsSync } from "fs";
+import { promises as fsPromises } from "fs";
 import path from "path";
 import { fileURLToPath } from "url";
+import { promisify } from "util";
 import { ConsoleLogger } from "@vuhlp/providers";
+import { loadEnv } from "./env.js";
 import { Runtime } from "./runtime/runtime.js";
-import { createServer } from "./api/server.js";
+import { createServer, type ServerTlsOptions } from "./api/server.js";
 
-const logger = new ConsoleLogger({ scope: "daemon" });
-
-function loadEnvFile(filePath: string): void {
-  if (!existsSync(filePath)) {
-    return;
-  }
-  try {
-    const content = readFileSync(filePath, "utf8");
-    for (const line of content.split(/\r?\n/)) {
-      const trimmed = line.trim();
-      if (!trimmed || trimmed.startsWith("#")) {
-        continue;
-      }
-      const equalsIndex = trimmed.indexOf("=");
-      if (equalsIndex <= 0) {
-        continue;
-      }
-      const key = trimmed.slice(0, equalsIndex).trim();
-      if (!key || process.env[key] !== undefined) {
-        continue;
-      }
-      let value = trimmed.slice(equalsIndex + 1).trim();
-      if (
-        (value.startsWith('"') && value.endsWith('"')) ||
-        (value.startsWith("'") && value.endsWith("'"))
-      ) {
-        value = value.slice(1, -1);
-      }
-      process.env[key] = value;
-    }
-  } catch (error) {
-    const message = error instanceof Error ? error.message : String(error);
-    logger.warn("failed to load env file", { filePath, message });
-  }
-}
+const execFile = promisify(execFileCallback);
 
-function findEnvFile(startDir: string, maxDepth = 4): string | null {
-  let current = startDir;
-  for (let depth = 0; depth <= maxDepth; depth += 1) {
-    const candidate = path.resolve(current, ".env");
-    if (existsSync(candidate)) {
-      return candidate;
-    }
-    const parent = path.dirname(current);
-    if (parent === current) {
-      break;
-    }
-    current = parent;
-  }
-  return null;
-}
+const logger = new ConsoleLogger({ scope: "daemon" });
 
-const envPath = findEnvFile(process.cwd());
-if (envPath) {
-  loadEnvFile(envPath);
-}
+loadEnv(process.cwd(), logger);
 
 const port = Number(process.env.VUHLP_PORT ?? 4000);
 const bindHostEnv = process.env.VUHLP_BIND_HOST ?? process.env.VUHLP_HOST;
@@ -89,17 +42,92 @@ const appRoot = process.env.VUHLP_APP_ROOT
 logger.info("resolved runtime paths", { appRoot, repoRoot, dataDir });
 
 const runtime = new Runtime({ dataDir, repoRoot, appRoot, systemTemplatesDir, logger, stallThreshold });
-const server = createServer(runtime);
+
+/**
+ * TLS via VUHLP_TLS_CERT / VUHLP_TLS_KEY, or VUHLP_TLS=self-signed to
+ * generate a cert under dataDir/tls on first start (requires openssl).
+ */
+async function resolveTlsOptions(): Promise<ServerTlsOptions | null> {
+  const certPath = process.env.VUHLP_TLS_CERT;
+  const keyPath = process.env.VUHLP_TLS_KEY;
+  if (certPath && keyPath) {
+    const [cert, key] = await Promise.all([
+      fsPromises.readFile(path.resolve(certPath), "utf8"),
+      fsPromises.readFile(path.resolve(keyPath), "utf8")
+    ]);
+    logger.info("TLS enabled from configured cert/key", { certPath, keyPath });
+    return { cert, key };
+  }
+  if (process.env.VUHLP_TLS?.trim().toLowerCase() !== "self-signed") {
+    return null;
+  }
+  const tlsDir = path.join(dataDir, "tls");
+  const selfCertPath = path.join(tlsDir, "cert.pem");
+  const selfKeyPath = path.join(tlsDir, "key.pem");
+  if (!existsSync(selfCertPath) || !existsSync(selfKeyPath)) {
+    await fsPromises.mkdir(tlsDir, { recursive: true });
+    logger.info("generating self-signed TLS certificate", { tlsDir });
+    await execFile("openssl", [
+      "req",
+      "-x509",
+      "-newkey",
+      "rsa:2048",
+      "-keyout",
+      selfKeyPath,
+      "-out",
+      selfCertPath,
+      "-days",
+      "365",
+      "-nodes",
+      "-subj",
+      `/CN=${bindHost === "0.0.0.0" ? "localhost" : bindHost}`
+    ]);
+  }
+  const [cert, key] = await Promise.all([
+    fsPromises.readFile(selfCertPath, "utf8"),
+    fsPromises.readFile(selfKeyPath, "utf8")
+  ]);
+  logger.info("TLS enabled with self-signed certificate", { certPath: selfCertPath });
+  return { cert, key };
+}
+
+let serverClose: (() => Promise<void>) | null = null;
+let serverListening = false;
 
 const start = async (): Promise<void> => {
+  let tls: ServerTlsOptions | null = null;
+  try {
+    tls = await resolveTlsOptions();
+  } catch (error) {
+    const message = error instanceof Error ? error.message : String(error);
+    logger.error("failed to resolve TLS options", { message });
+    process.exit(1);
+  }
+  const server = createServer(runtime, tls ? { tls } : undefined);
+  serverClose = async () => {
+    if (!server.listening) {
+      return;
+    }
+    await new Promise<void>((resolve) => {
+      server.close((error) => {
+        if (error) {
+          const message = error instanceof Error ? error.message : String(error);
+          logger.error("failed to close server", { message });
+        }
+        resolve();
+      });
+    });
+  };
   await runtime.start();
   server.on("error", (error) => {
     const message = error instanceof Error ? error.message : String(error);
     logger.error("daemon server error", { message });
     process.exit(1);
   });
+  const scheme = tls ? "https" : "http";
   server.listen(port, bindHost, () => {
-    logger.info(`vuhlp daemon listening on http://${bindHost}:${port}`, { port, host: bindHost });
+    serverListening = true;
+    logger.info(`vuhlp daemon listening on ${scheme}://${bindHost}:${port}`, { port, host: bindHost, scheme });
   });
 };
 
@@ -112,18 +140,10 @@ void start().catch((error) => {
 let shuttingDown = false;
 
 const closeServer = async (): Promise<void> => {
-  if (!server.listening) {
+  if (!serverListening || !serverClose) {
     return;
   }
-  await new Promise<void>((resolve) => {
-    server.close((error) => {
-      if (error) {
-        const message = error instanceof Error ? error.message : String(error);
-        logger.error("failed to close server", { message });
-      }
-      resolve();
-    });
-  });
+  await serverClose();
 };
 
 const shutdown = async (signal: string): Promise<void> => {
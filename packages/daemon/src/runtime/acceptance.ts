@@ -0,0 +1,106 @@
+import { exec as execCallback } from "child_process";
+import { promisify } from "util";
+import type { AcceptanceResult, AcceptanceTest } from "@vuhlp/contracts";
+import type { Logger } from "@vuhlp/providers";
+
+const exec = promisify(execCallback);
+
+const DEFAULT_TEST_TIMEOUT_MS = 300_000;
+const MAX_OUTPUT_CHARS = 4_000;
+
+/** Per-test wall-clock limit, in seconds. */
+const testTimeoutMs = (): number => {
+  const raw = Number.parseInt(process.env.VUHLP_ACCEPTANCE_TIMEOUT ?? "", 10);
+  return Number.isFinite(raw) && raw > 0 ? raw * 1000 : DEFAULT_TEST_TIMEOUT_MS;
+};
+
+/**
+ * Extracts runnable acceptance tests from a spec's "# Acceptance tests"
+ * section. Each bullet holding a backtick-quoted command becomes a test:
+ * the command is what runs, the remaining prose is the criterion's name.
+ * Bullets without a command are treated as prose and skipped - they
+ * cannot be verified mechanically.
+ */
+export function parseAcceptanceTests(content: string): AcceptanceTest[] {
+  const lines = content.split("\n");
+  const tests: AcceptanceTest[] = [];
+  let inSection = false;
+  for (const line of lines) {
+    const heading = /^#{1,6}\s+(.*?)\s*#*\s*$/.exec(line);
+    if (heading) {
+      inSection = /^acceptance tests?$/i.test(heading[1].trim());
+      continue;
+    }
+    if (!inSection) {
+      continue;
+    }
+    const bullet = /^\s*[-*]\s+(.*)$/.exec(line);
+    if (!bullet) {
+      continue;
+    }
+    const text = bullet[1].trim();
+    const code = /`([^`]+)`/.exec(text);
+    if (!code || !code[1].trim()) {
+      continue;
+    }
+    const command = code[1].trim();
+    const name = text
+      .replace(code[0], "")
+      .replace(/^[\s:—–-]+|[\s:—–-]+$/g, "")
+      .trim();
+    tests.push({ name: name || command, command });
+  }
+  return tests;
+}
+
+/**
+ * Runs acceptance tests sequentially in the workspace, one shell command
+ * each. A non-zero exit, timeout, or spawn failure fails the test; the
+ * rest still run, so the report covers every criterion.
+ */
+export async function runAcceptanceTests(
+  tests: AcceptanceTest[],
+  cwd: string,
+  logger?: Logger
+): Promise<AcceptanceResult[]> {
+  const results: AcceptanceResult[] = [];
+  for (const test of tests) {
+    const startedAt = Date.now();
+    let ok = false;
+    let exitCode: number | null = null;
+    let output = "";
+    try {
+      const { stdout, stderr } = await exec(test.command, {
+        cwd,
+        timeout: testTimeoutMs(),
+        maxBuffer: 10 * 1024 * 1024
+      });
+      ok = true;
+      exitCode = 0;
+      output = `${stdout}${stderr}`;
+    } catch (error) {
+      const failure = error as Error & { code?: number; killed?: boolean; stdout?: string; stderr?: string };
+      exitCode = typeof failure.code === "number" ? failure.code : null;
+      output = `${failure.stdout ?? ""}${failure.stderr ?? ""}` || failure.message;
+      if (failure.killed) {
+        output = `${output}\n(timed out after ${testTimeoutMs() / 1000}s)`;
+      }
+    }
+    const durationMs = Date.now() - startedAt;
+    logger?.info("acceptance test finished", {
+      name: test.name,
+      command: test.command,
+      ok,
+      exitCode,
+      durationMs
+    });
+    results.push({
+      ...test,
+      ok,
+      exitCode,
+      durationMs,
+      output: output.length > MAX_OUTPUT_CHARS ? output.slice(output.length - MAX_OUTPUT_CHARS) : output
+    });
+  }
+  return results;
+}
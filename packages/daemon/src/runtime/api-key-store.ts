@@ -0,0 +1,165 @@
+import crypto from "crypto";
+import { promises as fs } from "fs";
+import path from "path";
+import type { ApiKeyInfo, ApiKeyScope, UUID } from "@vuhlp/contracts";
+import type { Logger } from "@vuhlp/providers";
+import { newId, nowIso } from "./utils.js";
+
+const KEY_PREFIX = "vuhlp_";
+const KEY_BYTES = 24;
+const LAST_USED_PERSIST_DELAY_MS = 5_000;
+
+const VALID_SCOPES: ReadonlyArray<ApiKeyScope> = ["read", "approve", "full"];
+
+interface StoredApiKey extends ApiKeyInfo {
+  /** sha256 hex of the plaintext key; the plaintext is never persisted. */
+  hash: string;
+}
+
+const hashKey = (key: string): string => crypto.createHash("sha256").update(key).digest("hex");
+
+/**
+ * Multi-user API keys with scopes, stored hashed in dataDir/api-keys.json.
+ *
+ * Auth is disabled while no keys exist (local-first default); creating the
+ * first key turns enforcement on for every request.
+ */
+export class ApiKeyStore {
+  private readonly manifestPath: string;
+  private readonly logger?: Logger;
+  private keys: StoredApiKey[] = [];
+  private loaded = false;
+  /** Debounce for last-used persistence so verify() is not a disk write per request. */
+  private persistTimer?: NodeJS.Timeout;
+
+  constructor(dataDir: string, logger?: Logger) {
+    this.manifestPath = path.join(dataDir, "api-keys.json");
+    this.logger = logger;
+  }
+
+  async list(): Promise<ApiKeyInfo[]> {
+    await this.ensureLoaded();
+    return this.keys.map(({ hash: _hash, ...info }) => info);
+  }
+
+  async create(name: string, scopes: ApiKeyScope[]): Promise<{ key: string; apiKey: ApiKeyInfo }> {
+    await this.ensureLoaded();
+    const trimmed = name?.trim();
+    if (!trimmed) {
+      throw new Error("api key name is required");
+    }
+    if (!Array.isArray(scopes) || scopes.length === 0) {
+      throw new Error("at least one scope is required");
+    }
+    for (const scope of scopes) {
+      if (!VALID_SCOPES.includes(scope)) {
+        throw new Error(`invalid scope "${scope}"; valid scopes: ${VALID_SCOPES.join(", ")}`);
+      }
+    }
+    const key = `${KEY_PREFIX}${crypto.randomBytes(KEY_BYTES).toString("hex")}`;
+    const stored: StoredApiKey = {
+      id: newId(),
+      name: trimmed,
+      scopes,
+      createdAt: nowIso(),
+      hash: hashKey(key)
+    };
+    this.keys.push(stored);
+    await this.persist();
+    this.logger?.info("api key created", { apiKeyId: stored.id, name: trimmed, scopes });
+    const { hash: _hash, ...info } = stored;
+    return { key, apiKey: info };
+  }
+
+  async revoke(apiKeyId: UUID): Promise<void> {
+    await this.ensureLoaded();
+    const index = this.keys.findIndex((key) => key.id === apiKeyId);
+    if (index === -1) {
+      throw new Error(`API key ${apiKeyId} not found`);
+    }
+    this.keys.splice(index, 1);
+    await this.persist();
+    this.logger?.info("api key revoked", { apiKeyId });
+  }
+
+  async enforced(): Promise<boolean> {
+    await this.ensureLoaded();
+    return this.keys.length > 0;
+  }
+
+  /**
+   * Returns the key info when the token is valid and covers the required
+   * scope, otherwise null. "full" covers everything; "approve" also covers
+   * "read" so approve-only keys can list what they are approving.
+   */
+  async verify(token: string | undefined, required: ApiKeyScope): Promise<ApiKeyInfo | null> {
+    await this.ensureLoaded();
+    if (!token) {
+      return null;
+    }
+    const hash = hashKey(token);
+    const stored = this.keys.find((key) => key.hash === hash);
+    if (!stored) {
+      return null;
+    }
+    if (!this.scopeCovers(stored.scopes, required)) {
+      this.logger?.warn("api key lacks required scope", {
+        apiKeyId: stored.id,
+        required,
+        scopes: stored.scopes
+      });
+      return null;
+    }
+    stored.lastUsedAt = nowIso();
+    this.schedulePersist();
+    const { hash: _hash, ...info } = stored;
+    return info;
+  }
+
+  /** Writes the manifest shortly after a burst of requests, so lastUsedAt survives restarts. */
+  private schedulePersist(): void {
+    if (this.persistTimer) {
+      return;
+    }
+    this.persistTimer = setTimeout(() => {
+      this.persistTimer = undefined;
+      void this.persist().catch((error: Error) => {
+        this.logger?.warn("failed to persist api keys manifest", { message: error.message });
+      });
+    }, LAST_USED_PERSIST_DELAY_MS);
+    this.persistTimer.unref();
+  }
+
+  private scopeCovers(scopes: ApiKeyScope[], required: ApiKeyScope): boolean {
+    if (scopes.includes("full")) {
+      return true;
+    }
+    if (required === "read") {
+      return scopes.includes("read") || scopes.includes("approve");
+    }
+    return scopes.includes(required);
+  }
+
+  private async persist(): Promise<void> {
+    await fs.mkdir(path.dirname(this.manifestPath), { recursive: true });
+    await fs.writeFile(this.manifestPath, JSON.stringify(this.keys, null, 2), "utf8");
+  }
+
+  private async ensureLoaded(): Promise<void> {
+    if (this.loaded) {
+      return;
+    }
+    this.loaded = true;
+    try {
+      const contents = await fs.readFile(this.manifestPath, "utf8");
+      this.keys = JSON.parse(contents) as StoredApiKey[];
+    } catch (error) {
+      const code = (error as NodeJS.ErrnoException).code;
+      if (code !== "ENOENT") {
+        const message = error instanceof Error ? error.message : String(error);
+        this.logger?.warn("failed to load api keys manifest", { message });
+      }
+      this.keys = [];
+    }
+  }
+}
@@ -0,0 +1,121 @@
+import crypto from "crypto";
+import type { ApprovalRequest, UUID } from "@vuhlp/contracts";
+
+const DEFAULT_LINK_TTL_MS = 15 * 60 * 1000;
+
+/**
+ * HMAC-signed, expiring approval links: a notification can carry a URL
+ * that approves or denies one specific pending approval from a phone
+ * browser, without pasting a bearer token. The token covers only that
+ * approvalId and expiry; resolving the approval invalidates the link,
+ * so it is one-time in effect.
+ *
+ * - VUHLP_APPROVAL_LINK_BASE: public base URL of the daemon; links are
+ *   only generated when this is set (opt-in, since the daemon must be
+ *   reachable from the phone)
+ * - VUHLP_APPROVAL_LINK_SECRET: signing secret; without it a random
+ *   per-process secret is used and links die with the process
+ * - VUHLP_APPROVAL_LINK_TTL: link lifetime in ms (default 15 minutes)
+ */
+
+let processSecret: string | null = null;
+
+const linkSecret = (): string => {
+  const env = process.env.VUHLP_APPROVAL_LINK_SECRET?.trim();
+  if (env) {
+    return env;
+  }
+  if (!processSecret) {
+    processSecret = crypto.randomBytes(32).toString("hex");
+  }
+  return processSecret;
+};
+
+const linkTtl = (): number => {
+  const raw = Number(process.env.VUHLP_APPROVAL_LINK_TTL);
+  return Number.isFinite(raw) && raw > 0 ? raw : DEFAULT_LINK_TTL_MS;
+};
+
+const linkBase = (): string | null => {
+  const raw = process.env.VUHLP_APPROVAL_LINK_BASE?.trim();
+  return raw ? raw.replace(/\/+$/, "") : null;
+};
+
+const sign = (approvalId: UUID, expiresAt: number): string =>
+  crypto.createHmac("sha256", linkSecret()).update(`${approvalId}.${expiresAt}`).digest("hex");
+
+/**
+ * Signed URL for resolving the approval, or null when no link base is
+ * configured. The page at the URL shows the call and posts the decision
+ * back with the same token; GET never mutates, so notification apps
+ * prefetching the link cannot approve anything.
+ */
+export function approvalLinkUrl(approvalId: UUID): string | null {
+  const base = linkBase();
+  if (!base) {
+    return null;
+  }
+  const expiresAt = Date.now() + linkTtl();
+  return `${base}/a/${approvalId}?token=${expiresAt}.${sign(approvalId, expiresAt)}`;
+}
+
+/** Whether the token matches the approval and has not expired. */
+export function verifyApprovalLinkToken(approvalId: UUID, token: string): boolean {
+  const dot = token.indexOf(".");
+  if (dot <= 0) {
+    return false;
+  }
+  const expiresAt = Number(token.slice(0, dot));
+  if (!Number.isFinite(expiresAt) || expiresAt <= Date.now()) {
+    return false;
+  }
+  const provided = Buffer.from(token.slice(dot + 1));
+  const expected = Buffer.from(sign(approvalId, expiresAt));
+  return provided.length === expected.length && crypto.timingSafeEqual(provided, expected);
+}
+
+function escapeHtml(value: string): string {
+  return value
+    .replace(/&/g, "&amp;")
+    .replace(/</g, "&lt;")
+    .replace(/>/g, "&gt;")
+    .replace(/"/g, "&quot;");
+}
+
+/** Minimal phone-friendly page showing the call with approve/deny forms. */
+export function renderApprovalLinkPage(approval: ApprovalRequest, token: string): string {
+  const summary = escapeHtml(approval.summary ?? approval.tool.name);
+  const risk = approval.risk ? ` <small>[${escapeHtml(approval.risk)}]</small>` : "";
+  const cwd = approval.cwd ? `<p><small>workspace: ${escapeHtml(approval.cwd)}</small></p>` : "";
+  const context = approval.context ? `<pre>${escapeHtml(approval.context)}</pre>` : "";
+  const action = `/a/${approval.approvalId}?token=${encodeURIComponent(token)}`;
+  return [
+    "<!doctype html>",
+    '<html><head><meta name="viewport" content="width=device-width, initial-scale=1">',
+    "<title>Approval</title></head>",
+    '<body style="font-family: sans-serif; max-width: 40em; margin: 2em auto; padding: 0 1em">',
+    `<h2>Approval needed${risk}</h2>`,
+    `<p><code>${summary}</code></p>`,
+    cwd,
+    context,
+    `<form method="post" action="${action}" style="display:inline">`,
+    '<button name="action" value="approve" style="font-size:1.2em; padding: 0.5em 2em">Approve</button>',
+    "</form> ",
+    `<form method="post" action="${action}" style="display:inline">`,
+    '<button name="action" value="deny" style="font-size:1.2em; padding: 0.5em 2em">Deny</button>',
+    "</form>",
+    "</body></html>"
+  ].join("\n");
+}
+
+/** Result page after a decision, or for dead links. */
+export function renderApprovalLinkResult(message: string): string {
+  return [
+    "<!doctype html>",
+    '<html><head><meta name="viewport" content="width=device-width, initial-scale=1">',
+    "<title>Approval</title></head>",
+    '<body style="font-family: sans-serif; max-width: 40em; margin: 2em auto; padding: 0 1em">',
+    `<p>${escapeHtml(message)}</p>`,
+    "</body></html>"
+  ].join("\n");
+}
@@ -0,0 +1,153 @@
+import { createHash } from "node:crypto";
+import { promises as fs } from "fs";
+import path from "path";
+import type { Logger } from "@vuhlp/providers";
+
+/**
+ * Append-only, tamper-evident audit trail, separate from run event logs.
+ * Every entry carries the hash of its predecessor; recomputing the chain
+ * during export reveals any edited or dropped line. Categories cover
+ * approval decisions, policy changes, tool executions, and API key use.
+ *
+ * Writes are best-effort and serialized: an audit failure is logged
+ * loudly but never blocks the operation being audited.
+ */
+
+export type AuditCategory = "approval" | "policy" | "tool" | "api-key";
+
+export interface AuditEntry {
+  seq: number;
+  ts: string;
+  category: AuditCategory;
+  action: string;
+  runId?: string;
+  nodeId?: string;
+  detail: Record<string, unknown>;
+  prevHash: string;
+  hash: string;
+}
+
+export interface AuditVerification {
+  entries: AuditEntry[];
+  valid: boolean;
+  /** Sequence number of the first entry that breaks the chain, if any. */
+  brokenAt?: number;
+}
+
+const GENESIS_HASH = "genesis";
+
+function entryHash(entry: Omit<AuditEntry, "hash">): string {
+  const material = [
+    entry.prevHash,
+    String(entry.seq),
+    entry.ts,
+    entry.category,
+    entry.action,
+    entry.runId ?? "",
+    entry.nodeId ?? "",
+    JSON.stringify(entry.detail)
+  ].join("|");
+  return createHash("sha256").update(material).digest("hex");
+}
+
+export class AuditLog {
+  private readonly filePath: string;
+  private readonly logger?: Logger;
+  private seq = 0;
+  private lastHash = GENESIS_HASH;
+  private loaded = false;
+  private queue: Promise<void> = Promise.resolve();
+
+  constructor(dataDir: string, logger?: Logger) {
+    this.filePath = path.join(dataDir, "audit.jsonl");
+    this.logger = logger;
+  }
+
+  /**
+   * Appends an entry to the chain. Serialized through an internal queue
+   * so concurrent records cannot interleave sequence numbers.
+   */
+  record(
+    category: AuditCategory,
+    action: string,
+    detail: Record<string, unknown>,
+    ids?: { runId?: string; nodeId?: string }
+  ): void {
+    this.queue = this.queue.then(async () => {
+      try {
+        await this.ensureLoaded();
+        const base: Omit<AuditEntry, "hash"> = {
+          seq: this.seq + 1,
+          ts: new Date().toISOString(),
+          category,
+          action,
+          runId: ids?.runId,
+          nodeId: ids?.nodeId,
+          detail,
+          prevHash: this.lastHash
+        };
+        const entry: AuditEntry = { ...base, hash: entryHash(base) };
+        await fs.mkdir(path.dirname(this.filePath), { recursive: true });
+        await fs.appendFile(this.filePath, `${JSON.stringify(entry)}\n`, "utf8");
+        this.seq = entry.seq;
+        this.lastHash = entry.hash;
+      } catch (error) {
+        const message = error instanceof Error ? error.message : String(error);
+        this.logger?.error("failed to append audit log", { category, action, message });
+      }
+    });
+  }
+
+  /** Reads the full log and re-verifies the hash chain. */
+  async export(): Promise<AuditVerification> {
+    await this.queue;
+    const entries = await this.readEntries();
+    let prevHash = GENESIS_HASH;
+    for (const entry of entries) {
+      const { hash, ...rest } = entry;
+      if (entry.prevHash !== prevHash || entryHash(rest) !== hash) {
+        this.logger?.error("audit chain verification failed", { seq: entry.seq });
+        return { entries, valid: false, brokenAt: entry.seq };
+      }
+      prevHash = hash;
+    }
+    return { entries, valid: true };
+  }
+
+  private async ensureLoaded(): Promise<void> {
+    if (this.loaded) {
+      return;
+    }
+    const entries = await this.readEntries();
+    if (entries.length > 0) {
+      const last = entries[entries.length - 1];
+      this.seq = last.seq;
+      this.lastHash = last.hash;
+    }
+    this.loaded = true;
+  }
+
+  private async readEntries(): Promise<AuditEntry[]> {
+    let content: string;
+    try {
+      content = await fs.readFile(this.filePath, "utf8");
+    } catch (error) {
+      if ((error as NodeJS.ErrnoException).code === "ENOENT") {
+        return [];
+      }
+      throw error;
+    }
+    const entries: AuditEntry[] = [];
+    for (const line of content.split("\n")) {
+      if (line.trim().length === 0) {
+        continue;
+      }
+      try {
+        entries.push(JSON.parse(line) as AuditEntry);
+      } catch {
+        this.logger?.warn("skipping unparseable audit line", { line: line.slice(0, 120) });
+      }
+    }
+    return entries;
+  }
+}
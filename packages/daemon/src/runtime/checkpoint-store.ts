@@ -0,0 +1,123 @@
+import { exec as execCallback, execFile as execFileCallback } from "child_process";
+import { promises as fs } from "fs";
+import path from "path";
+import { promisify } from "util";
+import type { UUID, WorkspaceCheckpoint } from "@vuhlp/contracts";
+import type { Logger } from "@vuhlp/providers";
+import { nowIso } from "./utils.js";
+
+const exec = promisify(execCallback);
+const execFile = promisify(execFileCallback);
+
+/**
+ * Captures per-turn snapshots of the run workspace as git tree objects.
+ *
+ * Uses a scratch index so checkpoints never touch the user's real index,
+ * and git's object store so unchanged turns cost almost nothing.
+ */
+export class CheckpointStore {
+  private readonly dir: string;
+  private readonly indexPath: string;
+  private readonly manifestPath: string;
+  private readonly runId: UUID;
+  private readonly logger?: Logger;
+  private checkpoints: WorkspaceCheckpoint[] = [];
+  private loaded = false;
+
+  constructor(baseDir: string, runId: UUID, logger?: Logger) {
+    this.dir = path.join(baseDir, "runs", runId);
+    this.indexPath = path.join(this.dir, "checkpoint.index");
+    this.manifestPath = path.join(this.dir, "checkpoints.json");
+    this.runId = runId;
+    this.logger = logger;
+  }
+
+  async list(): Promise<WorkspaceCheckpoint[]> {
+    await this.ensureLoaded();
+    return this.checkpoints;
+  }
+
+  async capture(cwd: string, nodeId: UUID): Promise<WorkspaceCheckpoint | null> {
+    await this.ensureLoaded();
+    try {
+      await fs.mkdir(this.dir, { recursive: true });
+      const env = { ...process.env, GIT_INDEX_FILE: this.indexPath };
+      await exec("git add -A", { cwd, env, maxBuffer: 10 * 1024 * 1024 });
+      const result = await exec("git write-tree", { cwd, env });
+      const tree = result.stdout.trim();
+      if (!tree) {
+        return null;
+      }
+      const previous = this.checkpoints[this.checkpoints.length - 1];
+      const checkpoint: WorkspaceCheckpoint = {
+        turn: (previous?.turn ?? 0) + 1,
+        nodeId,
+        ts: nowIso(),
+        tree
+      };
+      this.checkpoints.push(checkpoint);
+      await fs.writeFile(this.manifestPath, JSON.stringify(this.checkpoints, null, 2), "utf8");
+      this.logger?.debug("workspace checkpoint captured", {
+        runId: this.runId,
+        turn: checkpoint.turn,
+        tree
+      });
+      return checkpoint;
+    } catch (error) {
+      const message = error instanceof Error ? error.message : String(error);
+      this.logger?.warn("failed to capture workspace checkpoint", {
+        runId: this.runId,
+        cwd,
+        message
+      });
+      return null;
+    }
+  }
+
+  async readFileAtTurn(cwd: string, turn: number, filePath: string): Promise<string> {
+    await this.ensureLoaded();
+    const checkpoint = this.findCheckpoint(turn);
+    if (!checkpoint) {
+      throw new Error(`No checkpoint for turn ${turn} in run ${this.runId}`);
+    }
+    if (filePath.includes("..")) {
+      throw new Error(`Path escapes workspace: ${filePath}`);
+    }
+    const normalized = filePath.replace(/\\/g, "/").replace(/^\/+/, "");
+    try {
+      // execFile: the path comes from the HTTP API and must never reach a shell.
+      const result = await execFile(
+        "git",
+        ["cat-file", "blob", `${checkpoint.tree}:${normalized}`],
+        { cwd, maxBuffer: 10 * 1024 * 1024 }
+      );
+      return result.stdout;
+    } catch (error) {
+      const message = error instanceof Error ? error.message : String(error);
+      throw new Error(`File ${normalized} not found at turn ${turn}: ${message}`);
+    }
+  }
+
+  private findCheckpoint(turn: number): WorkspaceCheckpoint | undefined {
+    // The checkpoint for turn N reflects the workspace after turn N completed.
+    return this.checkpoints.find((checkpoint) => checkpoint.turn === turn);
+  }
+
+  private async ensureLoaded(): Promise<void> {
+    if (this.loaded) {
+      return;
+    }
+    this.loaded = true;
+    try {
+      const contents = await fs.readFile(this.manifestPath, "utf8");
+      this.checkpoints = JSON.parse(contents) as WorkspaceCheckpoint[];
+    } catch (error) {
+      const code = (error as NodeJS.ErrnoException).code;
+      if (code !== "ENOENT") {
+        const message = error instanceof Error ? error.message : String(error);
+        this.logger?.warn("failed to load checkpoint manifest", { runId: this.runId, message });
+      }
+      this.checkpoints = [];
+    }
+  }
+}
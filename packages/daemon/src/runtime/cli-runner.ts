@@ -1,7 +1,9 @@
 import type {
   ApprovalRequest,
   ApprovalResolution,
+  ApprovalRisk,
   EventEnvelope,
+  FileAccess,
   NodeConfig,
   NodeState,
   PromptArtifacts,
@@ -17,9 +19,19 @@ import {
   ConsoleLogger,
   createProviderAdapter,
   executeToolCall,
+  findPluginTool,
+  getNetToolNames,
+  getPluginTools,
   getProviderNativeToolNames,
   getVuhlpToolNames,
+  isProtectedPath,
+  isSqlMutation,
+  loadWorkspacePolicy,
+  parseUnifiedDiff,
+  previewPatch,
+  resolveEnvSet,
   resolvePermissionsMode,
+  stopProcessesForOwner,
   type ApiProviderConfig,
   type CliProviderConfig,
   type CreateEdgeRequest,
@@ -31,25 +43,40 @@ import {
   type SendHandoffResult,
   type SpawnNodeRequest,
   type SpawnNodeResult,
+  type SpawnSubagentRequest,
+  type SpawnSubagentResult,
   type ToolExecutionResult
 } from "@vuhlp/providers";
 import { AsyncQueue } from "./async-queue.js";
+import { classifyDestructive, commandMatchesPattern, evaluateCommandPolicy, policedCommand } from "./command-policy.js";
 import { PromptBuilder } from "./prompt-builder.js";
 import { ProviderResolver, type ProviderSpec } from "./provider-resolver.js";
-import type { NodeRunner, TurnInput, TurnResult } from "./runner.js";
+import type { NodeRunner, TurnArtifact, TurnInput, TurnResult } from "./runner.js";
 import { SessionStateManager } from "./session-state-manager.js";
 import { extractToolCalls, isRecord, mergeToolCalls } from "./tool-call-parser.js";
-import { CLI_TOOL_PROTOCOL_PROVIDER_NATIVE, CLI_TOOL_PROTOCOL_VUHLP } from "./tool-protocols.js";
+import {
+  CLI_TOOL_PROTOCOL_PROVIDER_NATIVE,
+  CLI_TOOL_PROTOCOL_VUHLP,
+  pluginToolProtocolLines
+} from "./tool-protocols.js";
 import { hashString, newId, nowIso } from "./utils.js";
 
-const VUHLP_TOOL_NAMES: ReadonlySet<string> = new Set([
-  ...getVuhlpToolNames()
-]);
+// Computed per use so tools registered by plugins (loaded at startup or
+// config reload) are accepted by the tool_call parser.
+function vuhlpToolNames(): ReadonlySet<string> {
+  return new Set([...getVuhlpToolNames(), ...getPluginTools().map((plugin) => plugin.name)]);
+}
 const PROVIDER_NATIVE_TOOL_NAMES: ReadonlySet<string> = new Set([
   ...getProviderNativeToolNames()
 ]);
+const NET_TOOL_NAMES: ReadonlySet<string> = new Set([
+  ...getNetToolNames()
+]);
+
+const APPROVAL_SUMMARY_MAX = 160;
 
 interface PendingTurn {
+  turnId: UUID;
   promptArtifacts: PromptArtifacts;
   partialOutput: string;
   promptLogged: boolean;
@@ -57,7 +84,20 @@ interface PendingTurn {
   toolQueue?: ToolCall[];
   toolMessage?: string;
   toolErrors?: string[];
+  toolDirectives?: string[];
   toolProposed?: boolean;
+  /** File paths touched by this turn's tools, in call order. */
+  filesTouched?: FileAccess[];
+  /** code_review findings captured this turn, stored as report artifacts. */
+  reviewArtifacts?: TurnArtifact[];
+  /** Tools approved with scope "turn"/"session"; later same-tool calls skip the gate. */
+  approvedTools?: Set<string>;
+}
+
+interface ProposedOption {
+  id: string;
+  label: string;
+  summary: string;
 }
 
 interface ProviderSession {
@@ -86,13 +126,20 @@ export interface CliRunnerOptions {
   spawnNode?: (runId: UUID, fromNodeId: UUID, request: SpawnNodeRequest) => Promise<SpawnNodeResult>;
   createEdge?: (runId: UUID, fromNodeId: UUID, request: CreateEdgeRequest) => Promise<CreateEdgeResult>;
   sendHandoff?: (runId: UUID, fromNodeId: UUID, request: SendHandoffRequest) => Promise<SendHandoffResult>;
+  spawnSubagent?: (runId: UUID, fromNodeId: UUID, request: SpawnSubagentRequest) => Promise<SpawnSubagentResult>;
   systemTemplatesDir?: string;
+  /** Global kill switch; while true every tool execution is refused. */
+  isHalted?: () => boolean;
 }
 
 export class CliRunner implements NodeRunner {
   private readonly sessions = new Map<UUID, ProviderSession>();
   private readonly pendingApprovals = new Map<UUID, UUID>();
   private readonly pendingToolResolutions = new Map<UUID, ApprovalResolution>();
+  /** Approvals persisted before a restart, by nodeId; seeded into the node's next turn. */
+  private readonly restoredApprovals = new Map<UUID, ApprovalRequest[]>();
+  /** Per-node env overlay for the plan step currently running there. */
+  private readonly stepEnv = new Map<UUID, Record<string, string>>();
   private readonly promptBuilder: PromptBuilder;
   private readonly providerResolver: ProviderResolver;
   private readonly emitEvent: (runId: UUID, event: EventEnvelope) => void;
@@ -102,6 +149,12 @@ export class CliRunner implements NodeRunner {
   private readonly spawnNode?: (runId: UUID, fromNodeId: UUID, request: SpawnNodeRequest) => Promise<SpawnNodeResult>;
   private readonly createEdge?: (runId: UUID, fromNodeId: UUID, request: CreateEdgeRequest) => Promise<CreateEdgeResult>;
   private readonly sendHandoff?: (runId: UUID, fromNodeId: UUID, request: SendHandoffRequest) => Promise<SendHandoffResult>;
+  private readonly spawnSubagent?: (
+    runId: UUID,
+    fromNodeId: UUID,
+    request: SpawnSubagentRequest
+  ) => Promise<SpawnSubagentResult>;
+  private readonly isHalted?: () => boolean;
 
   constructor(options: CliRunnerOptions) {
     this.repoRoot = options.repoRoot;
@@ -113,6 +166,8 @@ export class CliRunner implements NodeRunner {
     this.spawnNode = options.spawnNode;
     this.createEdge = options.createEdge;
     this.sendHandoff = options.sendHandoff;
+    this.spawnSubagent = options.spawnSubagent;
+    this.isHalted = options.isHalted;
   }
 
   supports(_provider: ProviderName): boolean {
@@ -129,24 +184,29 @@ export class CliRunner implements NodeRunner {
       };
     }
 
+    this.seedRestoredApprovals(session, input);
     if (session.pendingTurn) {
       return this.resumePendingTurn(session, input);
     }
 
     const { input: promptInput, replayed } = session.state.injectReplayMessages(input);
     const toolProtocol = this.buildToolProtocol(session.config);
-    const prompt = await this.promptBuilder.build(promptInput, { toolProtocol });
+    const prompt = await this.promptBuilder.build(promptInput, {
+      toolProtocol,
+      verifyCommands: session.config.policy?.verify
+    });
     const promptHeaderHash = this.buildPromptHeaderHash(prompt.artifacts);
     const promptKind = session.state.resolvePromptKind(session.config.resume, promptHeaderHash);
     const promptPayload = promptKind === "full" ? prompt.artifacts.full : prompt.delta;
 
+    const turnId = newId();
     try {
       session.interrupted = false;
       session.state.applyResumeArgs(session.config);
       await session.adapter.send({
         prompt: promptPayload,
         promptKind,
-        turnId: newId()
+        turnId
       });
       this.updateSessionId(input.node, session);
       const clearedReplay = session.state.clearReplayFlag();
@@ -170,6 +230,7 @@ export class CliRunner implements NodeRunner {
     }
 
     const turnState: PendingTurn = {
+      turnId,
       promptArtifacts: prompt.artifacts,
       partialOutput: "",
       promptLogged: false,
@@ -177,8 +238,10 @@ export class CliRunner implements NodeRunner {
     };
 
     session.activeTurn = turnState;
+    this.emitCurrentTurn(session, turnId);
     const outcome = await this.waitForOutcome(session, turnState);
     session.activeTurn = undefined;
+    this.emitCurrentTurn(session, undefined);
     if (outcome.kind === "blocked") {
       session.pendingTurn = turnState;
       this.pendingApprovals.set(outcome.approval.approvalId, input.node.id);
@@ -221,6 +284,71 @@ export class CliRunner implements NodeRunner {
     };
   }
 
+  setStepEnv(nodeId: UUID, env: Record<string, string> | undefined): void {
+    if (env && Object.keys(env).length > 0) {
+      this.stepEnv.set(nodeId, env);
+      this.logger.debug("step env set", { nodeId, keys: Object.keys(env) });
+    } else {
+      this.stepEnv.delete(nodeId);
+    }
+  }
+
+  /**
+   * Re-registers an approval that was persisted in a run snapshot before
+   * a restart. The node's next turn re-waits on the same tool_call_id:
+   * the tool queue is rebuilt from the stored calls, so a resolution
+   * answers the exact call the model proposed before the daemon went
+   * down.
+   */
+  restorePendingApproval(input: { runId: UUID; nodeId: UUID; approval: ApprovalRequest }): void {
+    const restored = this.restoredApprovals.get(input.nodeId) ?? [];
+    restored.push(input.approval);
+    this.restoredApprovals.set(input.nodeId, restored);
+    this.pendingApprovals.set(input.approval.approvalId, input.nodeId);
+    this.logger.info("pending approval restored from snapshot", {
+      runId: input.runId,
+      nodeId: input.nodeId,
+      approvalId: input.approval.approvalId,
+      tool: input.approval.tool.name
+    });
+  }
+
+  /**
+   * Rebuilds a pending turn from restored approvals so the resumed turn
+   * re-waits on the same tool calls. The original prompt artifacts are
+   * gone with the old process; a placeholder points at the event log.
+   */
+  private seedRestoredApprovals(session: ProviderSession, input: TurnInput): void {
+    const restored = this.restoredApprovals.get(input.node.id);
+    if (!restored || restored.length === 0) {
+      return;
+    }
+    this.restoredApprovals.delete(input.node.id);
+    if (session.pendingTurn) {
+      // A live turn already owns the queue; nothing to rebuild.
+      return;
+    }
+    const placeholder = "(turn restored after daemon restart; original prompt is in the event log)";
+    session.pendingTurn = {
+      turnId: newId(),
+      promptArtifacts: {
+        full: placeholder,
+        blocks: { system: "", role: "", mode: "", task: placeholder },
+        hash: hashString(placeholder)
+      },
+      partialOutput: "",
+      promptLogged: true,
+      inputMessages: input.messages,
+      toolQueue: restored.map((approval) => approval.tool),
+      toolProposed: true
+    };
+    this.logger.info("re-waiting on restored approvals", {
+      runId: input.run.id,
+      nodeId: input.node.id,
+      toolIds: restored.map((approval) => approval.approvalId)
+    });
+  }
+
   async resolveApproval(approvalId: UUID, resolution: ApprovalResolution): Promise<void> {
     if (this.resolveToolApproval(approvalId, resolution)) {
       return;
@@ -322,10 +450,29 @@ export class CliRunner implements NodeRunner {
       return;
     }
     await this.stopNode(nodeId);
+    const stopped = stopProcessesForOwner(nodeId, this.logger);
+    if (stopped > 0) {
+      this.logger.info("killed leftover background processes for node", { nodeId, stopped });
+    }
     session.state.resetForSessionReset();
     this.sessions.delete(nodeId);
   }
 
+  activeTurnId(nodeId: UUID): UUID | undefined {
+    return this.sessions.get(nodeId)?.activeTurn?.turnId;
+  }
+
+  private emitCurrentTurn(session: ProviderSession, turnId: UUID | undefined): void {
+    this.emitEvent(session.config.runId, {
+      id: newId(),
+      runId: session.config.runId,
+      ts: nowIso(),
+      type: "node.patch",
+      nodeId: session.config.nodeId,
+      patch: { currentTurnId: turnId }
+    });
+  }
+
   async interruptNode(nodeId: UUID): Promise<void> {
     const session = this.sessions.get(nodeId);
     if (!session || !session.activeTurn) {
@@ -356,10 +503,10 @@ export class CliRunner implements NodeRunner {
     if (config.transport !== "cli") {
       return undefined;
     }
-    if (config.nativeToolHandling === "provider") {
-      return CLI_TOOL_PROTOCOL_PROVIDER_NATIVE;
-    }
-    return CLI_TOOL_PROTOCOL_VUHLP;
+    const base =
+      config.nativeToolHandling === "provider" ? CLI_TOOL_PROTOCOL_PROVIDER_NATIVE : CLI_TOOL_PROTOCOL_VUHLP;
+    const pluginLines = pluginToolProtocolLines();
+    return pluginLines.length > 0 ? [base, ...pluginLines].join("\n") : base;
   }
 
   private async resumePendingTurn(session: ProviderSession, input: TurnInput): Promise<TurnResult> {
@@ -374,11 +521,13 @@ export class CliRunner implements NodeRunner {
 
     session.interrupted = false;
     session.activeTurn = pending;
+    this.emitCurrentTurn(session, pending.turnId);
     const outcome =
       pending.toolQueue && pending.toolQueue.length > 0
         ? await this.processToolQueue(session, pending)
         : await this.waitForOutcome(session, pending);
     session.activeTurn = undefined;
+    this.emitCurrentTurn(session, undefined);
     if (outcome.kind === "blocked") {
       this.pendingApprovals.set(outcome.approval.approvalId, input.node.id);
       const prompt = pending.promptLogged ? undefined : pending.promptArtifacts;
@@ -417,11 +566,32 @@ export class CliRunner implements NodeRunner {
     const prompt = pending.promptLogged ? undefined : pending.promptArtifacts;
     session.state.recordTranscript(pending.inputMessages, outcome.message);
     session.state.markTurnCompleted();
+    const filesTouched = pending.filesTouched ?? [];
+    if (filesTouched.length > 0) {
+      this.emitEvent(session.config.runId, {
+        id: newId(),
+        runId: session.config.runId,
+        ts: nowIso(),
+        type: "turn.files",
+        nodeId: session.config.nodeId,
+        turnId: pending.turnId,
+        files: filesTouched
+      });
+    }
+    const artifacts: TurnArtifact[] = [...(pending.reviewArtifacts ?? [])];
+    if (filesTouched.length > 0) {
+      artifacts.push({
+        kind: "log",
+        name: `files-touched-${pending.turnId}.json`,
+        content: JSON.stringify({ turnId: pending.turnId, files: filesTouched }, null, 2)
+      });
+    }
     return {
       kind: "completed",
       summary: outcome.summary,
       message: outcome.message,
-      prompt
+      prompt,
+      artifacts: artifacts.length > 0 ? artifacts : undefined
     };
   }
 
@@ -505,7 +675,7 @@ export class CliRunner implements NodeRunner {
   private resolveToolApproval(approvalId: UUID, resolution: ApprovalResolution): boolean {
     const session = this.findSessionWithToolApproval(approvalId);
     if (!session) {
-      return false;
+      return this.resolveRestoredApproval(approvalId, resolution);
     }
     if (this.pendingToolResolutions.has(approvalId)) {
       return true;
@@ -521,6 +691,30 @@ export class CliRunner implements NodeRunner {
     return true;
   }
 
+  /**
+   * Stores a resolution for an approval restored from a snapshot but not
+   * yet seeded into a turn; the resolution is picked up when the node's
+   * next turn replays the restored tool queue.
+   */
+  private resolveRestoredApproval(approvalId: UUID, resolution: ApprovalResolution): boolean {
+    for (const [nodeId, approvals] of this.restoredApprovals) {
+      if (!approvals.some((approval) => approval.approvalId === approvalId)) {
+        continue;
+      }
+      if (!this.pendingToolResolutions.has(approvalId)) {
+        this.pendingToolResolutions.set(approvalId, resolution);
+      }
+      this.pendingApprovals.delete(approvalId);
+      this.logger.info("resolution stored for restored approval", {
+        approvalId,
+        nodeId,
+        status: resolution.status
+      });
+      return true;
+    }
+    return false;
+  }
+
   private findSessionWithToolApproval(approvalId: UUID): ProviderSession | null {
     for (const session of this.sessions.values()) {
       const pending = session.pendingTurn;
@@ -548,7 +742,7 @@ export class CliRunner implements NodeRunner {
     }
     return {
       strictWrapper: true,
-      allowlist: VUHLP_TOOL_NAMES
+      allowlist: vuhlpToolNames()
     };
   }
 
@@ -564,6 +758,8 @@ export class CliRunner implements NodeRunner {
     const baseMessage = pending.toolMessage ?? pending.partialOutput;
     const toolErrors = pending.toolErrors ?? [];
     pending.toolErrors = toolErrors;
+    const toolDirectives = pending.toolDirectives ?? [];
+    pending.toolDirectives = toolDirectives;
 
     if (!pending.toolProposed) {
       for (const tool of toolQueue) {
@@ -573,7 +769,7 @@ export class CliRunner implements NodeRunner {
     }
 
     if (toolQueue.length === 0) {
-      const message = this.appendToolErrors(baseMessage, toolErrors);
+      const message = this.appendToolDirectives(this.appendToolErrors(baseMessage, toolErrors), toolDirectives);
       return { kind: "completed", message, summary: this.summarize(message) };
     }
 
@@ -581,6 +777,20 @@ export class CliRunner implements NodeRunner {
 
     while (toolQueue.length > 0) {
       let tool = toolQueue[0];
+      let approvedByUser = false;
+      if (this.isHalted?.()) {
+        const errorMessage = "daemon halted by kill switch; tool execution refused";
+        this.emitToolCompleted(session, tool.id, { ok: false, output: "" }, errorMessage);
+        this.logger.warn("tool execution refused while halted", {
+          runId: session.config.runId,
+          nodeId: session.config.nodeId,
+          tool: tool.name,
+          toolId: tool.id
+        });
+        toolErrors.push(`${tool.name}: ${errorMessage}`);
+        toolQueue.shift();
+        continue;
+      }
       const providerNativeGuard = this.guardProviderNativeToolCall(session, tool);
       if (providerNativeGuard) {
         const errorMessage = providerNativeGuard;
@@ -611,7 +821,22 @@ export class CliRunner implements NodeRunner {
         toolQueue.shift();
         continue;
       }
-      if (this.requiresToolApproval(session, tool)) {
+      const deniedCmd = policedCommand(tool);
+      if (deniedCmd !== null && evaluateCommandPolicy(deniedCmd, session.config.policy?.commands) === "deny") {
+        const errorMessage = `command blocked by deny policy (VUHLP_COMMAND_DENY or workspace policy): ${deniedCmd}`;
+        this.emitToolCompleted(session, tool.id, { ok: false, output: "" }, errorMessage);
+        this.logger.warn("command blocked by deny policy", {
+          runId: session.config.runId,
+          nodeId: session.config.nodeId,
+          tool: tool.name,
+          toolId: tool.id,
+          cmd: deniedCmd
+        });
+        toolErrors.push(`${tool.name}: ${errorMessage}`);
+        toolQueue.shift();
+        continue;
+      }
+      if (this.requiresToolApproval(session, pending, tool)) {
         const resolution = this.pendingToolResolutions.get(tool.id);
         if (!resolution) {
           this.logger.info("tool approval required", {
@@ -622,13 +847,13 @@ export class CliRunner implements NodeRunner {
           });
           return {
             kind: "blocked",
-            approval: this.buildApprovalRequest(session, tool),
+            approval: await this.buildApprovalRequest(session, tool),
             summary: `approval required: ${tool.name}`
           };
         }
         this.pendingToolResolutions.delete(tool.id);
         if (resolution.status === "denied") {
-          const errorMessage = "Tool denied by user";
+          const errorMessage = this.buildDenialFeedback(resolution);
           this.emitToolCompleted(session, tool.id, { ok: false }, errorMessage);
           toolErrors.push(`${tool.name}: ${errorMessage}`);
           break;
@@ -636,7 +861,111 @@ export class CliRunner implements NodeRunner {
         if (resolution.status === "modified" && resolution.modifiedArgs) {
           tool = { ...tool, args: resolution.modifiedArgs };
           toolQueue[0] = tool;
+          // The edited call is what executes; the deny policy still
+          // applies to it, and the model is told what really ran so it
+          // does not reason from the command it originally proposed.
+          const editedCmd = policedCommand(tool);
+          if (editedCmd !== null && evaluateCommandPolicy(editedCmd, session.config.policy?.commands) === "deny") {
+            const errorMessage = `edited command blocked by deny policy (VUHLP_COMMAND_DENY or workspace policy): ${editedCmd}`;
+            this.emitToolCompleted(session, tool.id, { ok: false, output: "" }, errorMessage);
+            this.logger.warn("edited command blocked by deny policy", {
+              runId: session.config.runId,
+              nodeId: session.config.nodeId,
+              tool: tool.name,
+              toolId: tool.id,
+              cmd: editedCmd
+            });
+            toolErrors.push(`${tool.name}: ${errorMessage}`);
+            toolQueue.shift();
+            continue;
+          }
+          if (tool.name !== "ask_user" && tool.name !== "propose_options") {
+            toolDirectives.push(
+              `User edited ${tool.name} before approving; what executed was: ${this.approvalSummary(tool)}`
+            );
+            this.logger.info("tool call edited before approval", {
+              runId: session.config.runId,
+              nodeId: session.config.nodeId,
+              tool: tool.name,
+              toolId: tool.id
+            });
+          }
+        }
+        approvedByUser = true;
+        if (resolution.scope === "turn" || resolution.scope === "session") {
+          // "Don't ask again for this tool": later same-tool calls this
+          // turn skip the gate. Session scope additionally records an
+          // auto-approve rule on the node (handled by the runtime), which
+          // refreshSessionConfig picks up on the next turn.
+          pending.approvedTools = (pending.approvedTools ?? new Set()).add(tool.name);
+          this.logger.info("approval extended beyond this call", {
+            runId: session.config.runId,
+            nodeId: session.config.nodeId,
+            tool: tool.name,
+            toolId: tool.id,
+            scope: resolution.scope
+          });
         }
+        // ask_user never executes against the workspace - the resolution
+        // carries the user's answer (modifiedArgs.answer or reason) and it
+        // becomes a directive for the rest of the turn.
+        if (tool.name === "ask_user") {
+          this.emitToolStarted(session, tool);
+          const answerArg = resolution.modifiedArgs?.answer;
+          const answer =
+            typeof answerArg === "string" && answerArg.trim().length > 0
+              ? answerArg.trim()
+              : resolution.reason?.trim() ?? "";
+          const question = typeof tool.args.question === "string" ? tool.args.question : "";
+          const directive =
+            answer.length > 0
+              ? `User answered "${question}": ${answer}`
+              : `User acknowledged "${question}" without an answer; proceed on your best judgment and say what you assumed.`;
+          this.emitToolCompleted(session, tool.id, { ok: true, output: directive }, undefined);
+          this.logger.info("ask_user answered", {
+            runId: session.config.runId,
+            nodeId: session.config.nodeId,
+            toolId: tool.id,
+            answered: answer.length > 0
+          });
+          toolDirectives.push(directive);
+          toolQueue.shift();
+          continue;
+        }
+      }
+
+      // propose_options never executes against the workspace - the approval
+      // resolution carries the user's pick (modifiedArgs.selected) and the
+      // chosen option becomes a directive for the rest of the turn.
+      if (tool.name === "propose_options") {
+        this.emitToolStarted(session, tool);
+        const selection = this.selectProposedOption(tool);
+        if (!selection.ok) {
+          this.emitToolCompleted(session, tool.id, { ok: false, output: "" }, selection.error);
+          this.logger.warn("propose_options selection failed", {
+            runId: session.config.runId,
+            nodeId: session.config.nodeId,
+            toolId: tool.id,
+            error: selection.error
+          });
+          toolErrors.push(`${tool.name}: ${selection.error}`);
+          toolQueue.shift();
+          continue;
+        }
+        const option = selection.option;
+        this.emitOptionSelected(session, tool.id, option);
+        this.logger.info("option selected", {
+          runId: session.config.runId,
+          nodeId: session.config.nodeId,
+          toolId: tool.id,
+          optionId: option.id,
+          label: option.label
+        });
+        const directive = `User selected option ${option.id} (${option.label}): ${option.summary}`;
+        this.emitToolCompleted(session, tool.id, { ok: true, output: directive }, undefined);
+        toolDirectives.push(directive);
+        toolQueue.shift();
+        continue;
       }
 
       // Intercept TodoWrite tool calls - extract todos and emit patch
@@ -689,7 +1018,7 @@ export class CliRunner implements NodeRunner {
       });
       let result: ToolExecutionResult;
       try {
-        result = await executeToolCall(tool, toolOptions);
+        result = await executeToolCall(tool, approvedByUser ? { ...toolOptions, elevated: true } : toolOptions);
       } catch (error) {
         const message = error instanceof Error ? error.message : String(error);
         this.logger.error("tool execution failed", {
@@ -701,6 +1030,28 @@ export class CliRunner implements NodeRunner {
         });
         return { kind: "failed", summary: "Tool execution failed", error: message };
       }
+      if (result.guardrail) {
+        this.emitEvent(session.config.runId, {
+          id: newId(),
+          runId: session.config.runId,
+          ts: nowIso(),
+          type: "guardrail.flagged",
+          nodeId: session.config.nodeId,
+          toolId: tool.id,
+          reasons: result.guardrail.reasons
+        });
+      }
+      if (tool.name === "code_review" && result.output.length > 0) {
+        // Keep the findings as an artifact so a review can gate run
+        // completion and be revisited after the turn is gone.
+        pending.reviewArtifacts = [
+          ...(pending.reviewArtifacts ?? []),
+          { kind: "report", name: `code-review-${tool.id}.json`, content: result.output }
+        ];
+      }
+      if (result.files && result.files.length > 0) {
+        pending.filesTouched = [...(pending.filesTouched ?? []), ...result.files];
+      }
       this.emitToolCompleted(session, tool.id, result, result.error);
       this.logger.info("tool execution completed", {
         runId: session.config.runId,
@@ -720,21 +1071,40 @@ export class CliRunner implements NodeRunner {
     pending.toolQueue = undefined;
     pending.toolMessage = undefined;
     pending.toolErrors = undefined;
+    pending.toolDirectives = undefined;
     pending.toolProposed = undefined;
 
-    const message = this.appendToolErrors(baseMessage, toolErrors);
+    const message = this.appendToolDirectives(this.appendToolErrors(baseMessage, toolErrors), toolDirectives);
     return { kind: "completed", message, summary: this.summarize(message) };
   }
 
   private buildToolExecutionOptions(session: ProviderSession) {
+    const envSet = session.config.envSet ? resolveEnvSet(session.config.envSet, this.logger) : null;
+    if (session.config.envSet && !envSet) {
+      this.logger.warn("requested env set is not configured", {
+        runId: session.config.runId,
+        nodeId: session.config.nodeId,
+        envSet: session.config.envSet
+      });
+    }
+    const stepEnv = this.stepEnv.get(session.config.nodeId);
+    const env = envSet?.env || stepEnv ? { ...envSet?.env, ...stepEnv } : undefined;
     return {
       cwd: session.config.cwd ?? this.repoRoot,
+      ownerId: session.config.nodeId,
+      env,
+      secrets: envSet?.secrets,
+      sandboxImage: session.config.sandboxImage,
+      readOnly: session.config.readOnly,
+      policy: session.config.policy,
       capabilities: session.config.capabilities,
       globalMode: session.config.globalMode,
       defaultProvider: session.config.provider,
       spawnNode: session.config.spawnNode,
       createEdge: session.config.createEdge,
       sendHandoff: session.config.sendHandoff,
+      spawnSubagent: session.config.spawnSubagent,
+      updateTodos: session.config.updateTodos,
       logger: this.logger
     };
   }
@@ -748,8 +1118,8 @@ export class CliRunner implements NodeRunner {
       return null;
     }
     const scope = capabilities.edgeManagement;
-    if (tool.name === "spawn_node") {
-      return scope === "all" ? null : "edgeManagement=all required to spawn nodes";
+    if (tool.name === "spawn_node" || tool.name === "spawn_subagent") {
+      return scope === "all" ? null : `edgeManagement=all required to ${tool.name === "spawn_node" ? "spawn nodes" : "spawn subagents"}`;
     }
     if (tool.name === "create_edge") {
       return scope === "none" ? "edgeManagement capability is disabled" : null;
@@ -768,7 +1138,7 @@ export class CliRunner implements NodeRunner {
   }
 
   private isAgentManagementTool(tool: ToolCall): boolean {
-    return tool.name === "spawn_node" || tool.name === "create_edge";
+    return tool.name === "spawn_node" || tool.name === "spawn_subagent" || tool.name === "create_edge";
   }
 
   private isProviderHandledTool(config: ProviderConfig, tool: ToolCall): boolean {
@@ -782,10 +1152,59 @@ export class CliRunner implements NodeRunner {
     return typeof marker === "boolean" ? marker : false;
   }
 
-  private requiresToolApproval(session: ProviderSession, tool: ToolCall): boolean {
+  private requiresToolApproval(session: ProviderSession, pending: PendingTurn, tool: ToolCall): boolean {
     if (this.isProviderHandledTool(session.config, tool)) {
       return false;
     }
+    if (tool.name === "propose_options" || tool.name === "ask_user") {
+      // Always pause for the user's pick/answer, even with permissions skipped.
+      return true;
+    }
+    if (findPluginTool(tool.name)?.requiresApproval) {
+      // The plugin's manifest asked for gating regardless of mode.
+      return true;
+    }
+    if (tool.name === "docker") {
+      // Builds and container runs reach the host daemon; always gate them.
+      return true;
+    }
+    if (tool.name === "sql_query" && isSqlMutation(typeof tool.args.query === "string" ? tool.args.query : "")) {
+      // Data mutations always pause for a human, whatever the mode.
+      return true;
+    }
+    if (tool.name === "update_todos") {
+      // Pure bookkeeping - never worth an approval round-trip.
+      return false;
+    }
+    if (this.destructiveLabel(tool) !== null) {
+      // Destructive commands escalate even past an allowlist match.
+      return true;
+    }
+    const cmd = policedCommand(tool);
+    const policy = cmd !== null ? evaluateCommandPolicy(cmd, session.config.policy?.commands) : null;
+    if (policy === "approve") {
+      return true;
+    }
+    if (policy === "allow") {
+      return false;
+    }
+    if (this.writeTargets(tool).some((target) => isProtectedPath(target, session.config.policy?.protectedPaths))) {
+      // Protected paths always need an explicit human approval; the
+      // approved call then runs with elevation past the write guard.
+      return true;
+    }
+    if (pending.approvedTools?.has(tool.name)) {
+      // An earlier approval this turn carried scope "turn"/"session";
+      // same-tool calls are covered until the turn ends, but the risky
+      // classes above still escalate per call.
+      return false;
+    }
+    if (this.matchesAutoApprove(session, tool)) {
+      // A session auto-approve rule covers the call; the risky classes
+      // (destructive, deny/approve patterns, protected paths) were
+      // already escalated above and never reach this point.
+      return false;
+    }
     if (session.config.permissionsMode === "gated") {
       return true;
     }
@@ -795,17 +1214,214 @@ export class CliRunner implements NodeRunner {
     return this.isAgentManagementTool(tool);
   }
 
-  private buildApprovalRequest(session: ProviderSession, tool: ToolCall): ApprovalRequest {
+  /**
+   * True when a session auto-approve rule covers the call: the rule has
+   * not expired, the tool matches (exact name, the "net" class, or "*"),
+   * and any command pattern matches the command the tool would run.
+   */
+  private matchesAutoApprove(session: ProviderSession, tool: ToolCall): boolean {
+    const rules = session.config.autoApprove ?? [];
+    if (rules.length === 0) {
+      return false;
+    }
+    const now = Date.now();
+    const cmd = policedCommand(tool);
+    for (const rule of rules) {
+      if (rule.expiresAt && Date.parse(rule.expiresAt) <= now) {
+        continue;
+      }
+      if (rule.tool !== "*" && rule.tool !== tool.name && !(rule.tool === "net" && NET_TOOL_NAMES.has(tool.name))) {
+        continue;
+      }
+      if (rule.command && (cmd === null || !commandMatchesPattern(cmd, rule.command))) {
+        continue;
+      }
+      this.logger.info("tool call auto-approved by session rule", {
+        runId: session.config.runId,
+        nodeId: session.config.nodeId,
+        tool: tool.name,
+        toolId: tool.id,
+        ruleId: rule.id
+      });
+      return true;
+    }
+    return false;
+  }
+
+  /** Paths a write/patch tool would touch, for protected-path gating. */
+  private writeTargets(tool: ToolCall): string[] {
+    switch (tool.name) {
+      case "write_file":
+      case "edit_file":
+      case "delete_file":
+      case "make_dir":
+        return typeof tool.args.path === "string" ? [tool.args.path] : [];
+      case "move_file": {
+        const targets: string[] = [];
+        if (typeof tool.args.from === "string") {
+          targets.push(tool.args.from);
+        }
+        if (typeof tool.args.to === "string") {
+          targets.push(tool.args.to);
+        }
+        return targets;
+      }
+      case "apply_patch": {
+        if (typeof tool.args.patch !== "string") {
+          return [];
+        }
+        return parseUnifiedDiff(tool.args.patch)
+          .flatMap((file) => [file.oldPath, file.newPath])
+          .filter((target): target is string => target !== null);
+      }
+      default:
+        return [];
+    }
+  }
+
+  private buildDenialFeedback(resolution: ApprovalResolution): string {
+    const lines = ["Tool denied by user"];
+    if (resolution.reason && resolution.reason.trim().length > 0) {
+      lines.push(`Reason: ${resolution.reason.trim()}`);
+    }
+    const comments = resolution.comments ?? [];
+    if (comments.length > 0) {
+      lines.push("Review feedback (address each comment precisely in your next attempt):");
+      for (const comment of comments) {
+        const location = [comment.file, comment.line !== undefined ? `line ${comment.line}` : undefined, comment.hunk]
+          .filter((part): part is string => Boolean(part))
+          .join(" ");
+        lines.push(location ? `- [${location}] ${comment.body}` : `- ${comment.body}`);
+      }
+    }
+    return lines.join("\n");
+  }
+
+  private async buildApprovalRequest(session: ProviderSession, tool: ToolCall): Promise<ApprovalRequest> {
+    const requiredApprovals = this.requiredApprovalsFor(session, tool);
+    const destructive = this.destructiveLabel(tool);
+    const context = await this.buildApprovalContext(session, tool);
     return {
       approvalId: tool.id,
       nodeId: session.config.nodeId,
       tool,
-      context: this.buildApprovalContext(tool)
+      context: destructive
+        ? [`WARNING - destructive command: ${destructive}`, context].filter(Boolean).join("\n")
+        : context,
+      summary: this.approvalSummary(tool),
+      risk: this.approvalRisk(tool),
+      cwd: session.config.cwd ?? this.repoRoot,
+      severity: destructive ? "destructive" : undefined,
+      requiredApprovals: requiredApprovals > 1 ? requiredApprovals : undefined
     };
   }
 
-  private buildApprovalContext(tool: ToolCall): string | undefined {
+  /** One-line rendering of the call for approval lists. */
+  private approvalSummary(tool: ToolCall): string {
+    const cmd = policedCommand(tool);
+    if (cmd !== null) {
+      return cmd.split("\n")[0].slice(0, APPROVAL_SUMMARY_MAX);
+    }
+    if (tool.name === "apply_patch" && typeof tool.args.patch === "string") {
+      return `patch touching ${parseUnifiedDiff(tool.args.patch).length} file(s)`;
+    }
+    if (tool.name === "sql_query" && typeof tool.args.query === "string") {
+      return tool.args.query.split("\n")[0].slice(0, APPROVAL_SUMMARY_MAX);
+    }
+    const target = tool.args.path ?? tool.args.from ?? tool.args.url ?? tool.args.question;
+    return typeof target === "string"
+      ? `${tool.name}: ${target}`.slice(0, APPROVAL_SUMMARY_MAX)
+      : tool.name;
+  }
+
+  /** Coarse risk classification, so reviewers can triage without reading args. */
+  private approvalRisk(tool: ToolCall): ApprovalRisk {
+    if (this.destructiveLabel(tool) !== null) {
+      return "destructive";
+    }
+    if (this.isAgentManagementTool(tool)) {
+      return "agent";
+    }
+    if (policedCommand(tool) !== null || tool.name === "docker") {
+      return "exec";
+    }
+    if (NET_TOOL_NAMES.has(tool.name)) {
+      return "net";
+    }
+    if (
+      this.writeTargets(tool).length > 0 ||
+      (tool.name === "sql_query" && isSqlMutation(typeof tool.args.query === "string" ? tool.args.query : ""))
+    ) {
+      return "write";
+    }
+    return "read";
+  }
+
+  /**
+   * The destructive classification for the command a tool would run
+   * (command-style tools and sql_query), or null when it is not
+   * destructive.
+   */
+  private destructiveLabel(tool: ToolCall): string | null {
+    const cmd =
+      policedCommand(tool) ??
+      (tool.name === "sql_query" && typeof tool.args.query === "string" ? tool.args.query : null);
+    return cmd !== null ? classifyDestructive(cmd) : null;
+  }
+
+  /**
+   * Reads the multi-approver policy from VUHLP_APPROVAL_POLICY, a comma
+   * list of tool=count entries (e.g. "run_command=2,spawn_node=2") for
+   * destructive tools that should be gated like a terraform apply. The
+   * pseudo-name "net" matches every network-reaching tool. A workspace
+   * policy file can raise the count for a tool; the stricter side wins.
+   */
+  private requiredApprovalsFor(session: ProviderSession, tool: ToolCall): number {
+    const workspaceCounts = session.config.policy?.approvals ?? {};
+    const workspace = Math.max(workspaceCounts[tool.name] ?? 1, NET_TOOL_NAMES.has(tool.name) ? workspaceCounts.net ?? 1 : 1);
+    const raw = process.env.VUHLP_APPROVAL_POLICY;
+    if (!raw) {
+      return workspace;
+    }
+    for (const entry of raw.split(",")) {
+      const [name, countRaw] = entry.split("=").map((part) => part.trim());
+      if (name !== tool.name && !(name === "net" && NET_TOOL_NAMES.has(tool.name))) {
+        continue;
+      }
+      const count = Number(countRaw);
+      if (!Number.isFinite(count) || count < 1) {
+        this.logger.warn("ignoring invalid VUHLP_APPROVAL_POLICY entry", { entry });
+        return workspace;
+      }
+      return Math.max(Math.floor(count), workspace);
+    }
+    return workspace;
+  }
+
+  private async buildApprovalContext(session: ProviderSession, tool: ToolCall): Promise<string | undefined> {
     const args = tool.args ?? {};
+    if (tool.name === "apply_patch" && typeof args.patch === "string") {
+      // Dry-run the patch so the reviewer sees files, +/- counts, and
+      // conflicts - not just a tool name.
+      try {
+        const entries = await previewPatch(session.config.cwd ?? this.repoRoot, args.patch);
+        const additions = entries.reduce((sum, entry) => sum + entry.additions, 0);
+        const deletions = entries.reduce((sum, entry) => sum + entry.deletions, 0);
+        const conflicts = entries.filter((entry) => entry.status === "conflict" || entry.status === "missing");
+        const lines = [
+          `Apply patch: ${entries.length} file(s), +${additions} -${deletions}`,
+          ...entries.map((entry) => `- ${entry.file} +${entry.additions} -${entry.deletions} (${entry.status})`)
+        ];
+        if (conflicts.length > 0) {
+          lines.push(`WARNING: ${conflicts.length} file(s) will not apply cleanly`);
+        }
+        return lines.join("\n");
+      } catch (error) {
+        const message = error instanceof Error ? error.message : String(error);
+        this.logger.warn("patch preview failed", { toolId: tool.id, message });
+        return `Apply patch: preview failed (${message})`;
+      }
+    }
     if (tool.name === "spawn_node") {
       const label = typeof args.label === "string" ? args.label : "unnamed";
       const role =
@@ -826,6 +1442,11 @@ export class CliRunner implements NodeRunner {
       const to = typeof args.to === "string" ? args.to : "unknown";
       return `Send handoff to: ${to}`;
     }
+    if (tool.name === "ask_user") {
+      const question = typeof args.question === "string" ? args.question : "unspecified";
+      const context = typeof args.context === "string" && args.context.trim().length > 0 ? args.context.trim() : null;
+      return context ? `Question: ${question}\n\n${context}` : `Question: ${question}`;
+    }
     return undefined;
   }
 
@@ -877,6 +1498,67 @@ export class CliRunner implements NodeRunner {
     return `${prefix}Tool errors:\n${toolErrors.map((error) => `- ${error}`).join("\n")}`;
   }
 
+  private appendToolDirectives(message: string, toolDirectives: string[]): string {
+    if (toolDirectives.length === 0) {
+      return message;
+    }
+    const prefix = message.trim().length > 0 ? `${message}\n\n` : "";
+    return `${prefix}Directives:\n${toolDirectives.map((directive) => `- ${directive}`).join("\n")}`;
+  }
+
+  private selectProposedOption(tool: ToolCall): { ok: true; option: ProposedOption } | { ok: false; error: string } {
+    const rawOptions = tool.args.options;
+    if (!Array.isArray(rawOptions) || rawOptions.length === 0) {
+      return { ok: false, error: "propose_options requires a non-empty options array" };
+    }
+    const options: ProposedOption[] = [];
+    for (let index = 0; index < rawOptions.length; index += 1) {
+      const raw = rawOptions[index];
+      if (!isRecord(raw) || typeof raw.label !== "string" || typeof raw.summary !== "string") {
+        return { ok: false, error: `option ${index} must have string label and summary` };
+      }
+      const id = typeof raw.id === "string" && raw.id.trim().length > 0
+        ? raw.id.trim()
+        : String.fromCharCode(65 + index);
+      options.push({ id, label: raw.label, summary: raw.summary });
+    }
+    const selected = tool.args.selected;
+    if (selected === undefined || selected === null) {
+      // Approved without an explicit pick - take the first option.
+      return { ok: true, option: options[0] };
+    }
+    if (typeof selected === "number") {
+      const option = options[selected];
+      return option
+        ? { ok: true, option }
+        : { ok: false, error: `selected index ${selected} out of range (${options.length} options)` };
+    }
+    if (typeof selected === "string") {
+      const needle = selected.trim().toLowerCase();
+      const option = options.find(
+        (candidate) => candidate.id.toLowerCase() === needle || candidate.label.toLowerCase() === needle
+      );
+      return option
+        ? { ok: true, option }
+        : { ok: false, error: `selected option "${selected}" does not match any option id or label` };
+    }
+    return { ok: false, error: "selected must be an option id, label, or index" };
+  }
+
+  private emitOptionSelected(session: ProviderSession, toolId: UUID, option: ProposedOption): void {
+    this.emitEvent(session.config.runId, {
+      id: newId(),
+      runId: session.config.runId,
+      ts: nowIso(),
+      type: "option.selected",
+      nodeId: session.config.nodeId,
+      toolId,
+      optionId: option.id,
+      label: option.label,
+      summary: option.summary
+    });
+  }
+
   private applyCliPermissionFlags(config: CliProviderConfig): CliProviderConfig {
     if (config.permissionsMode !== "skip") {
       return config;
@@ -898,8 +1580,12 @@ export class CliRunner implements NodeRunner {
   }
 
   private async ensureSession(input: TurnInput): Promise<ProviderSession | null> {
+    // Reloaded per turn (mtime-cached) so policy edits land without a
+    // session restart and policies follow the run's workspace.
+    const policy = await loadWorkspacePolicy(input.run.cwd ?? this.repoRoot, this.logger);
     const existing = this.sessions.get(input.node.id);
     if (existing) {
+      existing.config.policy = policy;
       this.refreshSessionConfig(existing, input);
       return existing;
     }
@@ -934,6 +1620,11 @@ export class CliRunner implements NodeRunner {
       nodeId: input.node.id,
       provider: input.config.provider,
       cwd: input.run.cwd ?? this.repoRoot,
+      envSet: input.run.envSet,
+      sandboxImage: input.run.sandboxImage,
+      readOnly: input.run.readOnly,
+      policy,
+      autoApprove: input.node.autoApprove,
       permissionsMode: resolvePermissionsMode(input.config.permissions.cliPermissionsMode),
       agentManagementRequiresApproval: input.node.permissions.agentManagementRequiresApproval,
       spawnNode: this.spawnNode
@@ -945,6 +1636,10 @@ export class CliRunner implements NodeRunner {
       sendHandoff: this.sendHandoff
         ? (request: SendHandoffRequest) => this.sendHandoff?.(input.run.id, input.node.id, request)
         : undefined,
+      spawnSubagent: this.spawnSubagent
+        ? (request: SpawnSubagentRequest) => this.spawnSubagent?.(input.run.id, input.node.id, request)
+        : undefined,
+      updateTodos: (todos: TodoItem[]) => this.emitNodeTodos(input.run.id, input.node.id, todos),
       resume,
       resetCommands: input.config.session.resetCommands,
       capabilities: input.node.capabilities,
@@ -1032,6 +1727,10 @@ export class CliRunner implements NodeRunner {
     const prevPermissionsMode = config.permissionsMode;
 
     config.cwd = input.run.cwd ?? this.repoRoot;
+    config.envSet = input.run.envSet;
+    config.sandboxImage = input.run.sandboxImage;
+    config.readOnly = input.run.readOnly;
+    config.autoApprove = input.node.autoApprove;
     config.globalMode = input.run.globalMode;
     config.capabilities = input.node.capabilities;
     config.permissionsMode = nextPermissionsMode;
@@ -1154,7 +1853,10 @@ export class CliRunner implements NodeRunner {
           approvalId: event.approvalId,
           nodeId: event.nodeId,
           tool: event.tool,
-          context: event.context
+          context: event.context,
+          summary: event.summary,
+          risk: event.risk,
+          cwd: event.cwd
         }
       });
       return;
@@ -1200,12 +1902,16 @@ export class CliRunner implements NodeRunner {
   }
 
   private emitTodoPatch(session: ProviderSession, todos: TodoItem[]): void {
-    this.emitEvent(session.config.runId, {
+    this.emitNodeTodos(session.config.runId, session.config.nodeId, todos);
+  }
+
+  private emitNodeTodos(runId: UUID, nodeId: UUID, todos: TodoItem[]): void {
+    this.emitEvent(runId, {
       id: newId(),
-      runId: session.config.runId,
+      runId,
       ts: nowIso(),
       type: "node.patch",
-      nodeId: session.config.nodeId,
+      nodeId,
       patch: { todos }
     });
   }
@@ -0,0 +1,101 @@
+import type { ToolCall } from "@vuhlp/contracts";
+import type { WorkspaceCommandRules } from "@vuhlp/providers";
+
+/**
+ * Pattern-based approval policy over shell commands, so approvals are
+ * about risk rather than tool kind. Three comma-separated pattern lists:
+ *
+ * - VUHLP_COMMAND_DENY: matching commands never run
+ * - VUHLP_COMMAND_APPROVE: matching commands always pause for approval,
+ *   even with permissions skipped
+ * - VUHLP_COMMAND_ALLOW: matching commands skip gated-mode approval
+ *
+ * A pattern matches against the start of the trimmed command; `*`
+ * matches any run of characters (`go test*`, `rm -rf /*`). Deny wins
+ * over approve, approve wins over allow. A workspace policy file
+ * (.vuhlp/policy.json) may contribute the same three lists; they are
+ * evaluated alongside the environment ones at each level.
+ */
+
+export type CommandPolicyDecision = "deny" | "approve" | "allow";
+
+/** Tools whose `cmd` argument is a shell command the policy covers. */
+const COMMAND_TOOLS = new Set(["command", "run_tests", "start_process"]);
+
+function compilePattern(entry: string): RegExp {
+  return new RegExp(`^${entry.replace(/[.+?^${}()|[\]\\]/g, "\\$&").replace(/\*/g, ".*")}`);
+}
+
+function patternsFrom(name: string, extra?: string[]): RegExp[] {
+  const raw = process.env[name];
+  const entries = [...(raw ? raw.split(",") : []), ...(extra ?? [])];
+  return entries
+    .map((entry) => entry.trim())
+    .filter((entry) => entry.length > 0)
+    .map(compilePattern);
+}
+
+function matchesAny(cmd: string, patterns: RegExp[]): boolean {
+  return patterns.some((pattern) => pattern.test(cmd));
+}
+
+/** Whether one policy-style pattern matches the command, for ad-hoc rules. */
+export function commandMatchesPattern(cmd: string, pattern: string): boolean {
+  const trimmed = pattern.trim();
+  return trimmed.length > 0 && compilePattern(trimmed).test(cmd);
+}
+
+/** The shell command a tool call would run, or null for non-command tools. */
+export function policedCommand(tool: ToolCall): string | null {
+  if (!COMMAND_TOOLS.has(tool.name)) {
+    return null;
+  }
+  return typeof tool.args.cmd === "string" ? tool.args.cmd.trim() : null;
+}
+
+/**
+ * Built-in signatures of destructive operations. Matching commands
+ * escalate to a destructive approval regardless of allowlists - an
+ * allow rule for `git *` should not silently cover a force-push.
+ */
+const DESTRUCTIVE_SIGNATURES: { pattern: RegExp; label: string }[] = [
+  { pattern: /\brm\s+(-[a-zA-Z]*[rf][a-zA-Z]*\s+)+/, label: "recursive or forced file deletion" },
+  { pattern: /\bdd\b[^|;&]*\bof=/, label: "raw dd write" },
+  { pattern: /\bmkfs[.\s]/, label: "filesystem format" },
+  { pattern: /\bdrop\s+(table|database|schema|index)\b/i, label: "SQL DROP" },
+  { pattern: /\btruncate\s+(table\s+)?\w/i, label: "SQL TRUNCATE" },
+  { pattern: /\bterraform\s+destroy\b/, label: "terraform destroy" },
+  { pattern: /\bgit\s+push\b[^|;&]*(\s--force\b|\s-f\b)/, label: "force push" },
+  { pattern: /\bgit\s+(reset\s+--hard|clean\s+-[a-zA-Z]*f)/, label: "git worktree/history wipe" },
+  { pattern: /\b(shutdown|reboot|halt|poweroff)\b/, label: "host shutdown" }
+];
+
+/**
+ * Returns a human-readable label when the command matches a destructive
+ * signature, or null for everything else.
+ */
+export function classifyDestructive(cmd: string): string | null {
+  for (const { pattern, label } of DESTRUCTIVE_SIGNATURES) {
+    if (pattern.test(cmd)) {
+      return label;
+    }
+  }
+  return null;
+}
+
+/**
+ * Evaluates the configured lists against the command. Returns null when
+ * no pattern matches and the usual mode-based gating should apply.
+ */
+export function evaluateCommandPolicy(cmd: string, workspace?: WorkspaceCommandRules): CommandPolicyDecision | null {
+  if (matchesAny(cmd, patternsFrom("VUHLP_COMMAND_DENY", workspace?.deny))) {
+    return "deny";
+  }
+  if (matchesAny(cmd, patternsFrom("VUHLP_COMMAND_APPROVE", workspace?.approve))) {
+    return "approve";
+  }
+  if (matchesAny(cmd, patternsFrom("VUHLP_COMMAND_ALLOW", workspace?.allow))) {
+    return "allow";
+  }
+  return null;
+}
@@ -0,0 +1,148 @@
+import type { Logger } from "@vuhlp/providers";
+
+const PLAN_TIMEOUT_MS = 120_000;
+
+const CANDIDATE_SYSTEM_PROMPT = [
+  "You are a senior engineer drafting an implementation plan.",
+  "Produce a concrete, numbered plan in markdown: steps, files touched, risks,",
+  "and verification. Do not write code; plan only."
+].join("\n");
+
+const RECONCILE_SYSTEM_PROMPT = [
+  "You reconcile two independently drafted implementation plans for the same goal.",
+  "Merge their strengths, flag any point where they disagree, and produce one",
+  "final numbered plan in markdown. Where the candidates conflict, pick the safer",
+  "option and note the disagreement explicitly."
+].join("\n");
+
+export interface ConsensusPlanInput {
+  goal: string;
+  context?: string;
+}
+
+export interface ConsensusPlanCandidate {
+  model: string;
+  content: string;
+}
+
+export interface ConsensusPlanResult {
+  plan: string;
+  reconciledBy: string;
+  candidates: ConsensusPlanCandidate[];
+}
+
+/**
+ * Drafts plans with two models in parallel and reconciles the candidates
+ * with a third call, for specs where a single model's plan is too risky.
+ *
+ * Configured via VUHLP_CONSENSUS_API_KEY / VUHLP_CONSENSUS_MODELS /
+ * VUHLP_CONSENSUS_RECONCILE_MODEL / VUHLP_CONSENSUS_API_URL
+ * (OpenAI-compatible chat completions). Disabled when no key is set.
+ */
+export class ConsensusPlanner {
+  private readonly logger?: Logger;
+
+  constructor(logger?: Logger) {
+    this.logger = logger;
+  }
+
+  get enabled(): boolean {
+    return Boolean(process.env.VUHLP_CONSENSUS_API_KEY) && this.candidateModels().length >= 2;
+  }
+
+  private candidateModels(): string[] {
+    const raw = process.env.VUHLP_CONSENSUS_MODELS ?? "";
+    return raw
+      .split(",")
+      .map((value) => value.trim())
+      .filter((value) => value.length > 0)
+      .slice(0, 2);
+  }
+
+  async plan(input: ConsensusPlanInput): Promise<ConsensusPlanResult> {
+    const apiKey = process.env.VUHLP_CONSENSUS_API_KEY;
+    if (!apiKey) {
+      throw new Error("consensus planning disabled; set VUHLP_CONSENSUS_API_KEY to enable");
+    }
+    const models = this.candidateModels();
+    if (models.length < 2) {
+      throw new Error("consensus planning needs two models in VUHLP_CONSENSUS_MODELS");
+    }
+    const reconcileModel = process.env.VUHLP_CONSENSUS_RECONCILE_MODEL ?? models[0];
+    const baseUrl = (process.env.VUHLP_CONSENSUS_API_URL ?? "https://api.openai.com/v1").replace(
+      /\/+$/,
+      ""
+    );
+    const userPrompt = [
+      `Goal: ${input.goal}`,
+      input.context ? `Context:\n${input.context}` : undefined
+    ]
+      .filter((part): part is string => Boolean(part))
+      .join("\n\n");
+
+    this.logger?.info("drafting consensus plan", { models, reconcileModel });
+    const candidates = await Promise.all(
+      models.map(async (model): Promise<ConsensusPlanCandidate> => {
+        const content = await this.complete(baseUrl, apiKey, model, CANDIDATE_SYSTEM_PROMPT, userPrompt);
+        return { model, content };
+      })
+    );
+
+    const reconcilePrompt = [
+      userPrompt,
+      `Candidate plan A (${candidates[0].model}):\n${candidates[0].content}`,
+      `Candidate plan B (${candidates[1].model}):\n${candidates[1].content}`
+    ].join("\n\n---\n\n");
+    const plan = await this.complete(
+      baseUrl,
+      apiKey,
+      reconcileModel,
+      RECONCILE_SYSTEM_PROMPT,
+      reconcilePrompt
+    );
+    return { plan, reconciledBy: reconcileModel, candidates };
+  }
+
+  private async complete(
+    baseUrl: string,
+    apiKey: string,
+    model: string,
+    systemPrompt: string,
+    userPrompt: string
+  ): Promise<string> {
+    const controller = new AbortController();
+    const timer = setTimeout(() => controller.abort(), PLAN_TIMEOUT_MS);
+    try {
+      const response = await fetch(`${baseUrl}/chat/completions`, {
+        method: "POST",
+        headers: {
+          "Content-Type": "application/json",
+          Authorization: `Bearer ${apiKey}`
+        },
+        body: JSON.stringify({
+          model,
+          messages: [
+            { role: "system", content: systemPrompt },
+            { role: "user", content: userPrompt }
+          ],
+          temperature: 0.2
+        }),
+        signal: controller.signal
+      });
+      if (!response.ok) {
+        const detail = await response.text();
+        throw new Error(`consensus plan call rejected (${model}): ${response.status} ${detail}`);
+      }
+      const payload = (await response.json()) as {
+        choices?: Array<{ message?: { content?: string } }>;
+      };
+      const content = payload.choices?.[0]?.message?.content;
+      if (!content) {
+        throw new Error(`consensus plan response missing content (${model})`);
+      }
+      return content;
+    } finally {
+      clearTimeout(timer);
+    }
+  }
+}
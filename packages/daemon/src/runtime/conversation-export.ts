@@ -0,0 +1,71 @@
+import type { ConversationExportFormat } from "@vuhlp/contracts";
+import type { ImportedMessage } from "./conversation-import.js";
+
+/**
+ * Renderers for handing a run's conversation to other agent tools — the
+ * inverse of conversation-import. Only the message text is exported.
+ */
+
+export interface ConversationExport {
+  content: string;
+  contentType: string;
+  filename: string;
+}
+
+export function renderConversation(
+  format: ConversationExportFormat,
+  runId: string,
+  messages: ImportedMessage[]
+): ConversationExport {
+  switch (format) {
+    case "aider":
+      return {
+        content: renderAider(messages),
+        contentType: "text/markdown",
+        filename: `${runId}.aider.chat.history.md`
+      };
+    case "continue":
+      return {
+        content: renderContinue(messages),
+        contentType: "application/json",
+        filename: `${runId}.continue-session.json`
+      };
+    case "generic":
+      return {
+        content: JSON.stringify(messages, null, 2),
+        contentType: "application/json",
+        filename: `${runId}.transcript.json`
+      };
+    default: {
+      const exhaustive: never = format;
+      throw new Error(`unsupported export format: ${String(exhaustive)}`);
+    }
+  }
+}
+
+/** aider chat history: "#### " prefixes user lines, assistant text is plain. */
+function renderAider(messages: ImportedMessage[]): string {
+  const lines: string[] = ["# aider chat started", ""];
+  for (const message of messages) {
+    if (message.role === "user") {
+      for (const line of message.content.split(/\r?\n/)) {
+        lines.push(`#### ${line}`);
+      }
+    } else {
+      lines.push(message.content);
+    }
+    lines.push("");
+  }
+  return lines.join("\n");
+}
+
+/** Continue session JSON: a history of { message: { role, content } } steps. */
+function renderContinue(messages: ImportedMessage[]): string {
+  const session = {
+    history: messages.map((message) => ({
+      message: { role: message.role, content: message.content },
+      contextItems: []
+    }))
+  };
+  return JSON.stringify(session, null, 2);
+}
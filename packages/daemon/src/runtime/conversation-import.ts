@@ -0,0 +1,173 @@
+import type { ConversationImportFormat } from "@vuhlp/contracts";
+
+/**
+ * Parsers for transcripts exported from other agent tools, so work
+ * started elsewhere can continue in a vuhlp run. Only the message text
+ * survives the trip; attachments and tool results are stubbed as
+ * bracketed placeholders.
+ */
+
+export interface ImportedMessage {
+  role: "user" | "assistant";
+  content: string;
+}
+
+export function parseConversation(format: ConversationImportFormat, content: string): ImportedMessage[] {
+  switch (format) {
+    case "claude-code":
+      return parseClaudeCode(content);
+    case "cursor":
+      return parseMarkdownSections(content, /^#{1,4}\s*(user|cursor|assistant)\s*:?\s*$/i);
+    case "aider":
+      return parseAider(content);
+    case "generic":
+      return parseGenericJson(content);
+    default: {
+      const exhaustive: never = format;
+      throw new Error(`unsupported import format: ${String(exhaustive)}`);
+    }
+  }
+}
+
+/** Claude Code session exports are JSONL: one {type, message} per line. */
+function parseClaudeCode(content: string): ImportedMessage[] {
+  const messages: ImportedMessage[] = [];
+  for (const line of content.split(/\r?\n/)) {
+    const trimmed = line.trim();
+    if (!trimmed.startsWith("{")) {
+      continue;
+    }
+    let parsed: unknown;
+    try {
+      parsed = JSON.parse(trimmed);
+    } catch {
+      continue;
+    }
+    if (typeof parsed !== "object" || parsed === null) {
+      continue;
+    }
+    const entry = parsed as { type?: string; message?: { role?: string; content?: unknown } };
+    if (entry.type !== "user" && entry.type !== "assistant") {
+      continue;
+    }
+    const role = entry.type;
+    const text = extractMessageText(entry.message?.content);
+    if (text.trim().length > 0) {
+      messages.push({ role, content: text });
+    }
+  }
+  return messages;
+}
+
+/** Content blocks can be a string or an array of {type, text} blocks. */
+function extractMessageText(content: unknown): string {
+  if (typeof content === "string") {
+    return content;
+  }
+  if (!Array.isArray(content)) {
+    return "";
+  }
+  const parts: string[] = [];
+  for (const block of content) {
+    if (typeof block !== "object" || block === null) {
+      continue;
+    }
+    const typed = block as { type?: string; text?: string; name?: string };
+    if (typed.type === "text" && typeof typed.text === "string") {
+      parts.push(typed.text);
+    } else if (typed.type === "tool_use") {
+      parts.push(`[tool call: ${typed.name ?? "unknown"}]`);
+    } else if (typed.type === "tool_result") {
+      parts.push("[tool result omitted]");
+    } else if (typed.type === "image") {
+      parts.push("[image attachment omitted]");
+    }
+  }
+  return parts.join("\n");
+}
+
+/** Markdown with per-speaker headings (Cursor-style chat exports). */
+function parseMarkdownSections(content: string, heading: RegExp): ImportedMessage[] {
+  const messages: ImportedMessage[] = [];
+  let role: "user" | "assistant" | null = null;
+  let buffer: string[] = [];
+  const flush = (): void => {
+    if (role && buffer.length > 0) {
+      const text = buffer.join("\n").trim();
+      if (text.length > 0) {
+        messages.push({ role, content: text });
+      }
+    }
+    buffer = [];
+  };
+  for (const line of content.split(/\r?\n/)) {
+    const match = line.match(heading);
+    if (match) {
+      flush();
+      role = match[1].toLowerCase() === "user" ? "user" : "assistant";
+      continue;
+    }
+    buffer.push(line);
+  }
+  flush();
+  return messages;
+}
+
+/** aider chat history: "#### " lines are the user, everything else the assistant. */
+function parseAider(content: string): ImportedMessage[] {
+  const messages: ImportedMessage[] = [];
+  let role: "user" | "assistant" | null = null;
+  let buffer: string[] = [];
+  const flush = (): void => {
+    if (role && buffer.length > 0) {
+      const text = buffer.join("\n").trim();
+      if (text.length > 0) {
+        messages.push({ role, content: text });
+      }
+    }
+    buffer = [];
+  };
+  for (const line of content.split(/\r?\n/)) {
+    if (line.startsWith("# aider chat started")) {
+      continue;
+    }
+    const isUser = line.startsWith("#### ");
+    const lineRole: "user" | "assistant" = isUser ? "user" : "assistant";
+    if (role !== lineRole) {
+      flush();
+      role = lineRole;
+    }
+    buffer.push(isUser ? line.slice(5) : line);
+  }
+  flush();
+  return messages;
+}
+
+/** Plain JSON array of { role, content } objects. */
+function parseGenericJson(content: string): ImportedMessage[] {
+  let parsed: unknown;
+  try {
+    parsed = JSON.parse(content);
+  } catch (error) {
+    const message = error instanceof Error ? error.message : String(error);
+    throw new Error(`invalid JSON transcript: ${message}`);
+  }
+  if (!Array.isArray(parsed)) {
+    throw new Error("generic transcript must be a JSON array of {role, content}");
+  }
+  const messages: ImportedMessage[] = [];
+  for (const entry of parsed) {
+    if (typeof entry !== "object" || entry === null) {
+      continue;
+    }
+    const typed = entry as { role?: string; content?: unknown };
+    if (typed.role !== "user" && typed.role !== "assistant") {
+      continue;
+    }
+    const text = extractMessageText(typed.content);
+    if (text.trim().length > 0) {
+      messages.push({ role: typed.role, content: text });
+    }
+  }
+  return messages;
+}
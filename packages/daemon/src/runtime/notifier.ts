@@ -0,0 +1,178 @@
+import type { EventEnvelope } from "@vuhlp/contracts";
+import type { Logger } from "@vuhlp/providers";
+import { inQuietHours } from "./quiet-hours.js";
+import { approvalLinkUrl } from "./approval-links.js";
+
+const DELIVERY_TIMEOUT_MS = 10_000;
+
+const slackWebhook = (): string | null => process.env.VUHLP_NOTIFY_SLACK_WEBHOOK?.trim() || null;
+
+const discordWebhook = (): string | null => process.env.VUHLP_NOTIFY_DISCORD_WEBHOOK?.trim() || null;
+
+/** Generic webhook receiving the structured notification as JSON. */
+const genericWebhook = (): string | null => process.env.VUHLP_NOTIFY_WEBHOOK_URL?.trim() || null;
+
+/** ntfy topic URL for mobile push, built from topic + optional server. */
+const ntfyUrl = (): string | null => {
+  const topic = process.env.VUHLP_NOTIFY_NTFY_TOPIC?.trim();
+  if (!topic) {
+    return null;
+  }
+  const server = (process.env.VUHLP_NOTIFY_NTFY_SERVER?.trim() || "https://ntfy.sh").replace(/\/+$/, "");
+  return `${server}/${topic}`;
+};
+
+/** Dashboard base for deep links; the UI honors `?run=<id>` on load. */
+const dashboardUrl = (): string =>
+  (process.env.VUHLP_NOTIFY_DASHBOARD_URL?.trim() || "http://localhost:5173").replace(/\/+$/, "");
+
+const DEFAULT_KINDS = "approval.requested,run.failed,run.completed,turn.completed";
+
+const notifyKinds = (): Set<string> =>
+  new Set(
+    (process.env.VUHLP_NOTIFY_EVENTS ?? DEFAULT_KINDS)
+      .split(",")
+      .map((entry) => entry.trim())
+      .filter((entry) => entry.length > 0)
+  );
+
+/**
+ * Posts human-readable notifications to a Slack and/or Discord webhook
+ * when attention-worthy events fire - a pending approval, a failed run,
+ * a completed turn - each with a deep link back to the dashboard, so
+ * nobody has to keep a terminal attached to notice.
+ *
+ * Configured entirely by environment: VUHLP_NOTIFY_SLACK_WEBHOOK,
+ * VUHLP_NOTIFY_DISCORD_WEBHOOK, VUHLP_NOTIFY_NTFY_TOPIC (mobile push
+ * via ntfy.sh or a self-hosted server), and VUHLP_NOTIFY_WEBHOOK_URL
+ * (structured JSON for anything else) each enable a channel;
+ * VUHLP_NOTIFY_EVENTS narrows the notification kinds,
+ * VUHLP_NOTIFY_DASHBOARD_URL sets the link base. Distinct from webhook
+ * subscriptions, which deliver raw event JSON for machines; these are
+ * one-line messages for a channel or a phone.
+ */
+export class Notifier {
+  private readonly logger?: Logger;
+
+  constructor(logger?: Logger) {
+    this.logger = logger;
+  }
+
+  notify(event: EventEnvelope): void {
+    const slack = slackWebhook();
+    const discord = discordWebhook();
+    const ntfy = ntfyUrl();
+    const generic = genericWebhook();
+    if (!slack && !discord && !ntfy && !generic) {
+      return;
+    }
+    const rendered = this.render(event);
+    if (!rendered || !notifyKinds().has(rendered.kind)) {
+      return;
+    }
+    if (inQuietHours()) {
+      this.logger?.debug("notification suppressed during quiet hours", { kind: rendered.kind });
+      return;
+    }
+    const { kind, title, detail, link } = rendered;
+    const text = `${title}: ${detail}\n${link}`;
+    if (slack) {
+      void this.post("slack", slack, JSON.stringify({ text }), { "Content-Type": "application/json" }, kind);
+    }
+    if (discord) {
+      void this.post("discord", discord, JSON.stringify({ content: text }), { "Content-Type": "application/json" }, kind);
+    }
+    if (ntfy) {
+      // Tapping the push opens the dashboard on the run; approvals push
+      // at high priority so they break through the phone's quiet modes.
+      void this.post(
+        "ntfy",
+        ntfy,
+        detail,
+        {
+          Title: title,
+          Click: link,
+          Priority: kind === "approval.requested" ? "high" : "default"
+        },
+        kind
+      );
+    }
+    if (generic) {
+      void this.post(
+        "webhook",
+        generic,
+        JSON.stringify({ kind, title, detail, link, runId: event.runId, ts: event.ts }),
+        { "Content-Type": "application/json" },
+        kind
+      );
+    }
+  }
+
+  /**
+   * Maps an event to a notification kind, short title, and detail line,
+   * or null for everything not worth a ping. "run.failed",
+   * "run.completed", and "turn.completed" are notification kinds derived
+   * from run.patch / turn.status events, not event types of their own.
+   */
+  private render(event: EventEnvelope): { kind: string; title: string; detail: string; link: string } | null {
+    const link = `${dashboardUrl()}/?run=${event.runId}`;
+    if (event.type === "approval.requested") {
+      const risk = event.risk ? ` [${event.risk}]` : "";
+      // A signed link (when VUHLP_APPROVAL_LINK_BASE is set) resolves
+      // this one approval from a browser without the bearer token.
+      const signed = approvalLinkUrl(event.approvalId);
+      return {
+        kind: "approval.requested",
+        title: `Approval needed${risk}`,
+        detail: signed ? `${event.summary ?? event.tool.name}\nApprove/deny: ${signed}` : event.summary ?? event.tool.name,
+        link
+      };
+    }
+    if (event.type === "run.patch" && event.patch.status === "failed") {
+      return { kind: "run.failed", title: "Run failed", detail: event.runId, link };
+    }
+    if (event.type === "run.patch" && event.patch.status === "completed") {
+      return { kind: "run.completed", title: "Run completed", detail: event.runId, link };
+    }
+    if (event.type === "turn.status" && event.status === "turn.completed") {
+      return {
+        kind: "turn.completed",
+        title: "Turn completed",
+        detail: `node ${event.nodeId}${event.detail ? `: ${event.detail}` : ""}`,
+        link
+      };
+    }
+    return null;
+  }
+
+  private async post(
+    target: "slack" | "discord" | "ntfy" | "webhook",
+    url: string,
+    body: string,
+    headers: Record<string, string>,
+    kind: string
+  ): Promise<void> {
+    try {
+      const controller = new AbortController();
+      const timer = setTimeout(() => controller.abort(), DELIVERY_TIMEOUT_MS);
+      try {
+        const response = await fetch(url, {
+          method: "POST",
+          headers,
+          body,
+          signal: controller.signal
+        });
+        if (response.ok) {
+          this.logger?.debug("notification delivered", { target, kind, status: response.status });
+          return;
+        }
+        this.logger?.warn("notification rejected", { target, kind, status: response.status });
+      } finally {
+        clearTimeout(timer);
+      }
+    } catch (error) {
+      const message = error instanceof Error ? error.message : String(error);
+      this.logger?.warn("notification delivery failed", { target, kind, message });
+    }
+  }
+}
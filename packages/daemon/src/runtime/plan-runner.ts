@@ -0,0 +1,691 @@
+import type {
+  CreatePlanRequest,
+  CreatePlanStepInput,
+  EventEnvelope,
+  PlanState,
+  PlanStep,
+  PlanStepStatus,
+  TurnStatus,
+  UUID
+} from "@vuhlp/contracts";
+import { ConsoleLogger, type Logger } from "@vuhlp/providers";
+import { Replanner } from "./replanner.js";
+import type { RunRecord, RunStore } from "./store.js";
+import { newId, nowIso } from "./utils.js";
+
+const DEFAULT_CONCURRENCY = 2;
+const RETRY_BASE_DELAY_MS = 5_000;
+const RETRY_MAX_DELAY_MS = 60_000;
+
+/** Policy default: require plan approval before start unless the request opts out. */
+const planReviewDefault = (): boolean => {
+  const value = process.env.VUHLP_PLAN_REVIEW?.trim().toLowerCase();
+  return value === "1" || value === "true";
+};
+
+/** Max re-planning iterations per plan; 0 disables the loop outright. */
+const replanLimit = (): number => {
+  const raw = Number.parseInt(process.env.VUHLP_PLAN_REPLAN_LIMIT ?? "", 10);
+  return Number.isFinite(raw) && raw >= 0 ? raw : 2;
+};
+
+export interface PlanRunnerOptions {
+  store: RunStore;
+  emitEvent: (runId: UUID, event: EventEnvelope) => void;
+  /** Delivers a step's task to its node; the scheduler runs it as the node's next turn. */
+  dispatchStep: (runId: UUID, nodeId: UUID, content: string) => void;
+  /** Interrupts the node's active turn; used when a step exceeds its timeout. */
+  interruptNode: (runId: UUID, nodeId: UUID) => Promise<void>;
+  /** Applies a step's env overlay to the node's command execution; undefined clears it. */
+  setStepEnv: (runId: UUID, nodeId: UUID, env: Record<string, string> | undefined) => void;
+  logger?: Logger;
+}
+
+/**
+ * Executes a dependency-ordered plan over the run's nodes. Steps whose
+ * dependencies are all completed start concurrently - bounded by the
+ * plan's `concurrency` and by one step per node, since a node runs one
+ * turn at a time. Progress is driven by turn.status events: a settled
+ * turn settles the step running on that node. A failed step fails the
+ * plan and skips everything that depends on it.
+ */
+export class PlanRunner {
+  private readonly store: RunStore;
+  private readonly emitEvent: (runId: UUID, event: EventEnvelope) => void;
+  private readonly dispatchStep: (runId: UUID, nodeId: UUID, content: string) => void;
+  private readonly interruptNode: (runId: UUID, nodeId: UUID) => Promise<void>;
+  private readonly setStepEnv: (runId: UUID, nodeId: UUID, env: Record<string, string> | undefined) => void;
+  private readonly logger: Logger;
+  /** Steps waiting out a retry backoff, keyed by step id. */
+  private readonly retryTimers = new Map<UUID, NodeJS.Timeout>();
+  /** Per-attempt timeout timers, keyed by step id. */
+  private readonly stepTimers = new Map<UUID, NodeJS.Timeout>();
+  /** Steps whose timeout fired; their next interrupt is a failure, not a pause. */
+  private readonly timedOutSteps = new Set<UUID>();
+  /** Plans with a revision request outstanding; they must not complete meanwhile. */
+  private readonly replansInFlight = new Set<UUID>();
+  private readonly replanner: Replanner;
+
+  constructor(options: PlanRunnerOptions) {
+    this.store = options.store;
+    this.emitEvent = options.emitEvent;
+    this.dispatchStep = options.dispatchStep;
+    this.interruptNode = options.interruptNode;
+    this.setStepEnv = options.setStepEnv;
+    this.logger = options.logger ?? new ConsoleLogger({ scope: "plan-runner" });
+    this.replanner = new Replanner(this.logger);
+  }
+
+  createPlan(runId: UUID, input: CreatePlanRequest): PlanState {
+    const record = this.requireRun(runId);
+    if (record.state.plan && (record.state.plan.status === "pending" || record.state.plan.status === "running")) {
+      throw new Error("run already has an active plan; cancel it first");
+    }
+    const steps = this.buildSteps(record, input.steps);
+    const now = nowIso();
+    const concurrency =
+      input.concurrency !== undefined && Number.isFinite(input.concurrency) && input.concurrency > 0
+        ? Math.floor(input.concurrency)
+        : DEFAULT_CONCURRENCY;
+    const plan: PlanState = {
+      id: newId(),
+      runId,
+      goal: input.goal?.trim() || undefined,
+      status: "pending",
+      steps,
+      concurrency,
+      reviewRequired: input.review ?? planReviewDefault(),
+      replanCount: 0,
+      createdAt: now,
+      updatedAt: now
+    };
+    record.state.plan = plan;
+    this.logger.info("plan created", {
+      runId,
+      planId: plan.id,
+      steps: steps.length,
+      concurrency,
+      reviewRequired: plan.reviewRequired
+    });
+    this.emitPlanUpdated(record);
+    return plan;
+  }
+
+  /**
+   * Replaces a pending plan's steps and settings in place, keeping the
+   * plan id. Editing invalidates any prior review approval - the edited
+   * plan is not the one that was approved.
+   */
+  updatePlan(runId: UUID, input: CreatePlanRequest): PlanState {
+    const record = this.requireRun(runId);
+    const plan = record.state.plan;
+    if (!plan) {
+      throw new Error("run has no plan");
+    }
+    if (plan.status !== "pending") {
+      throw new Error(`plan is ${plan.status}; only a pending plan can be edited`);
+    }
+    plan.steps = this.buildSteps(record, input.steps);
+    plan.goal = input.goal?.trim() || undefined;
+    if (input.concurrency !== undefined && Number.isFinite(input.concurrency) && input.concurrency > 0) {
+      plan.concurrency = Math.floor(input.concurrency);
+    }
+    if (input.review !== undefined) {
+      plan.reviewRequired = input.review;
+    }
+    plan.reviewedBy = undefined;
+    plan.reviewedAt = undefined;
+    plan.updatedAt = nowIso();
+    this.logger.info("plan updated", { runId, planId: plan.id, steps: plan.steps.length });
+    this.emitPlanUpdated(record);
+    return plan;
+  }
+
+  approvePlan(runId: UUID, approver?: string): PlanState {
+    const record = this.requireRun(runId);
+    const plan = record.state.plan;
+    if (!plan) {
+      throw new Error("run has no plan");
+    }
+    if (plan.status !== "pending") {
+      throw new Error(`plan is ${plan.status}; only a pending plan can be approved`);
+    }
+    plan.reviewedBy = approver?.trim() || "api";
+    plan.reviewedAt = nowIso();
+    plan.updatedAt = plan.reviewedAt;
+    this.logger.info("plan approved", { runId, planId: plan.id, approver: plan.reviewedBy });
+    this.emitPlanUpdated(record);
+    return plan;
+  }
+
+  /**
+   * Validates step inputs and resolves dependsOn names to ids. `existing`
+   * maps names of steps already on the plan (used when re-planning), so
+   * revised steps can depend on completed work.
+   */
+  private buildSteps(record: RunRecord, inputs: CreatePlanStepInput[], existing?: Map<string, UUID>): PlanStep[] {
+    if (!Array.isArray(inputs) || inputs.length === 0) {
+      throw new Error("plan requires at least one step");
+    }
+    const nodeIds = Object.keys(record.state.nodes);
+    const names = new Map<string, UUID>();
+    const steps: PlanStep[] = inputs.map((step) => {
+      const name = step.name?.trim();
+      if (!name) {
+        throw new Error("every plan step needs a name");
+      }
+      if (names.has(name) || existing?.has(name)) {
+        throw new Error(`duplicate plan step name: ${name}`);
+      }
+      if (!step.task?.trim()) {
+        throw new Error(`plan step ${name} needs a task`);
+      }
+      const nodeId = step.nodeId ?? (nodeIds.length === 1 ? nodeIds[0] : undefined);
+      if (!nodeId) {
+        throw new Error(`plan step ${name} needs a nodeId (run has ${nodeIds.length} nodes)`);
+      }
+      if (!record.state.nodes[nodeId]) {
+        throw new Error(`plan step ${name} targets unknown node ${nodeId}`);
+      }
+      if (step.timeoutSeconds !== undefined && (!Number.isFinite(step.timeoutSeconds) || step.timeoutSeconds <= 0)) {
+        throw new Error(`plan step ${name} has an invalid timeoutSeconds`);
+      }
+      if (step.env !== undefined) {
+        for (const [key, value] of Object.entries(step.env)) {
+          if (!key.trim() || typeof value !== "string") {
+            throw new Error(`plan step ${name} has an invalid env entry: ${key}`);
+          }
+        }
+      }
+      const id = newId();
+      names.set(name, id);
+      return {
+        id,
+        name,
+        task: step.task.trim(),
+        nodeId,
+        dependsOn: [],
+        status: "pending" as PlanStepStatus,
+        attempt: 0,
+        maxAttempts:
+          step.maxAttempts !== undefined && Number.isFinite(step.maxAttempts) && step.maxAttempts >= 1
+            ? Math.floor(step.maxAttempts)
+            : 1,
+        timeoutSeconds: step.timeoutSeconds !== undefined ? Math.ceil(step.timeoutSeconds) : undefined,
+        env: step.env && Object.keys(step.env).length > 0 ? step.env : undefined
+      };
+    });
+    inputs.forEach((step, index) => {
+      for (const dep of step.dependsOn ?? []) {
+        const depId = names.get(dep) ?? existing?.get(dep);
+        if (!depId) {
+          throw new Error(`plan step ${steps[index].name} depends on unknown step: ${dep}`);
+        }
+        if (depId === steps[index].id) {
+          throw new Error(`plan step ${steps[index].name} depends on itself`);
+        }
+        steps[index].dependsOn.push(depId);
+      }
+    });
+    this.assertAcyclic(steps);
+    return steps;
+  }
+
+  getPlan(runId: UUID): PlanState {
+    const plan = this.requireRun(runId).state.plan;
+    if (!plan) {
+      throw new Error("run has no plan");
+    }
+    return plan;
+  }
+
+  startPlan(runId: UUID): PlanState {
+    const record = this.requireRun(runId);
+    const plan = record.state.plan;
+    if (!plan) {
+      throw new Error("run has no plan");
+    }
+    if (plan.status !== "pending") {
+      throw new Error(`plan is ${plan.status}; only a pending plan can start`);
+    }
+    if (plan.reviewRequired && !plan.reviewedAt) {
+      throw new Error("plan requires review; approve it via plan/approve before starting");
+    }
+    plan.status = "running";
+    plan.updatedAt = nowIso();
+    this.logger.info("plan started", { runId, planId: plan.id });
+    this.dispatchReady(plan);
+    this.emitPlanUpdated(record);
+    return plan;
+  }
+
+  cancelPlan(runId: UUID): PlanState {
+    const record = this.requireRun(runId);
+    const plan = record.state.plan;
+    if (!plan) {
+      throw new Error("run has no plan");
+    }
+    if (plan.status === "completed" || plan.status === "failed" || plan.status === "cancelled") {
+      return plan;
+    }
+    for (const step of plan.steps) {
+      const timer = this.retryTimers.get(step.id);
+      if (timer) {
+        clearTimeout(timer);
+        this.retryTimers.delete(step.id);
+      }
+      this.disarmStepTimeout(step);
+      this.timedOutSteps.delete(step.id);
+      if (step.status === "running") {
+        this.setStepEnv(plan.runId, step.nodeId, undefined);
+      }
+      if (step.status === "pending") {
+        this.patchStep(plan, step, "skipped", "plan cancelled");
+      }
+    }
+    this.replansInFlight.delete(plan.id);
+    plan.status = "cancelled";
+    plan.updatedAt = nowIso();
+    this.logger.info("plan cancelled", { runId, planId: plan.id });
+    this.emitPlanUpdated(record);
+    return plan;
+  }
+
+  /**
+   * Advances the plan when a turn on one of the run's nodes settles. A
+   * node runs at most one plan step at a time, so the settled turn maps
+   * to the single running step on that node.
+   */
+  onTurnSettled(runId: UUID, nodeId: UUID, status: TurnStatus, detail?: string): void {
+    if (status !== "turn.completed" && status !== "turn.failed" && status !== "turn.interrupted") {
+      return;
+    }
+    const record = this.store.getRun(runId);
+    const plan = record?.state.plan;
+    if (!record || !plan || plan.status !== "running") {
+      return;
+    }
+    const step = plan.steps.find((candidate) => candidate.status === "running" && candidate.nodeId === nodeId);
+    if (!step) {
+      return;
+    }
+    this.disarmStepTimeout(step);
+    this.setStepEnv(runId, nodeId, undefined);
+    if (this.timedOutSteps.delete(step.id)) {
+      // The interrupt was ours: the attempt exceeded its timeout. Treat it
+      // as a failure so retries and re-planning apply.
+      status = "turn.failed";
+      detail = `step timed out after ${step.timeoutSeconds}s`;
+    }
+    if (status === "turn.interrupted" && record.state.status !== "running") {
+      // Run pause interrupts in-flight turns; that is not a step failure.
+      // Hand the attempt back and let resume re-dispatch from here.
+      step.attempt = Math.max(0, step.attempt - 1);
+      this.patchStep(plan, step, "pending");
+      this.logger.info("plan step suspended by run pause", {
+        runId,
+        planId: plan.id,
+        stepId: step.id,
+        name: step.name
+      });
+      this.finishOrContinue(record, plan);
+      return;
+    }
+    if (status === "turn.completed") {
+      this.patchStep(plan, step, "completed");
+      this.logger.info("plan step completed", { runId, planId: plan.id, stepId: step.id, name: step.name });
+    } else {
+      const error = detail ?? (status === "turn.interrupted" ? "turn interrupted" : "turn failed");
+      if (status === "turn.failed" && step.attempt < step.maxAttempts) {
+        this.scheduleRetry(plan, step, error);
+      } else {
+        this.patchStep(plan, step, "failed", error);
+        this.logger.warn("plan step failed", {
+          runId,
+          planId: plan.id,
+          stepId: step.id,
+          name: step.name,
+          error
+        });
+        if (status === "turn.failed" && this.replanner.enabled && plan.replanCount < replanLimit()) {
+          this.beginReplan(plan, step, error);
+        } else {
+          this.skipDependents(plan, step.id);
+          plan.status = "failed";
+        }
+      }
+    }
+    this.finishOrContinue(record, plan);
+  }
+
+  /** Starts every runnable step, then settles the plan if nothing is left. */
+  private finishOrContinue(record: RunRecord, plan: PlanState): void {
+    if (plan.status === "running") {
+      this.dispatchReady(plan);
+      // A failed step the plan was revised around stays failed; the plan
+      // still completes once everything left has settled.
+      const settled = plan.steps.every((step) => step.status !== "pending" && step.status !== "running");
+      if (settled && !this.replansInFlight.has(plan.id)) {
+        plan.status = "completed";
+        this.logger.info("plan completed", { runId: plan.runId, planId: plan.id });
+      }
+    } else if (plan.status === "failed" && !plan.steps.some((step) => step.status === "running")) {
+      this.logger.warn("plan failed", { runId: plan.runId, planId: plan.id });
+    }
+    plan.updatedAt = nowIso();
+    this.emitPlanUpdated(record);
+  }
+
+  /** Re-dispatches ready steps after a paused run resumes. */
+  resumeDispatch(runId: UUID): void {
+    const record = this.store.getRun(runId);
+    const plan = record?.state.plan;
+    if (!record || !plan || plan.status !== "running") {
+      return;
+    }
+    this.logger.info("resuming plan dispatch", { runId, planId: plan.id });
+    this.dispatchReady(plan);
+    this.emitPlanUpdated(record);
+  }
+
+  private dispatchReady(plan: PlanState): void {
+    const record = this.store.getRun(plan.runId);
+    if (record && record.state.status !== "running") {
+      // A paused or stopped run dispatches nothing; resume picks the
+      // pending steps back up.
+      return;
+    }
+    const completed = new Set(plan.steps.filter((step) => step.status === "completed").map((step) => step.id));
+    const busyNodes = new Set(plan.steps.filter((step) => step.status === "running").map((step) => step.nodeId));
+    for (const step of plan.steps) {
+      if (busyNodes.size >= plan.concurrency) {
+        break;
+      }
+      if (step.status !== "pending" || busyNodes.has(step.nodeId) || this.retryTimers.has(step.id)) {
+        continue;
+      }
+      if (!step.dependsOn.every((dep) => completed.has(dep))) {
+        continue;
+      }
+      step.attempt += 1;
+      step.startedAt = nowIso();
+      this.patchStep(plan, step, "running");
+      busyNodes.add(step.nodeId);
+      this.logger.info("plan step started", {
+        runId: plan.runId,
+        planId: plan.id,
+        stepId: step.id,
+        name: step.name,
+        nodeId: step.nodeId,
+        attempt: step.attempt
+      });
+      this.setStepEnv(plan.runId, step.nodeId, step.env);
+      if (step.timeoutSeconds !== undefined) {
+        this.armStepTimeout(plan, step);
+      }
+      this.dispatchStep(plan.runId, step.nodeId, `[plan step: ${step.name}]\n${step.task}`);
+    }
+  }
+
+  /**
+   * Feeds a step failure back to the re-planning model and swaps the
+   * plan's unstarted remainder for the revision. The plan stays running
+   * meanwhile - steps that do not depend on the failed one keep going;
+   * nothing that depends on it can start, so the swap is race-free.
+   */
+  private beginReplan(plan: PlanState, failedStep: PlanStep, error: string): void {
+    plan.replanCount += 1;
+    const iteration = plan.replanCount;
+    this.logger.info("replanning after step failure", {
+      runId: plan.runId,
+      planId: plan.id,
+      stepId: failedStep.id,
+      iteration,
+      limit: replanLimit()
+    });
+    this.emitEvent(plan.runId, {
+      id: newId(),
+      runId: plan.runId,
+      ts: nowIso(),
+      type: "plan.replanned",
+      planId: plan.id,
+      stepId: failedStep.id,
+      iteration,
+      reason: error
+    });
+    const record = this.store.getRun(plan.runId);
+    const brief = (step: PlanStep): { name: string; task: string } => ({ name: step.name, task: step.task });
+    this.replansInFlight.add(plan.id);
+    void this.replanner
+      .replan({
+        goal: plan.goal,
+        completed: plan.steps.filter((step) => step.status === "completed").map(brief),
+        failed: { name: failedStep.name, task: failedStep.task, error },
+        remaining: plan.steps.filter((step) => step.status === "pending").map(brief),
+        nodeIds: record ? Object.keys(record.state.nodes) : []
+      })
+      .then((drafts) => {
+        this.replansInFlight.delete(plan.id);
+        this.applyReplan(plan, drafts);
+      })
+      .catch((cause: Error) => {
+        this.replansInFlight.delete(plan.id);
+        this.logger.error("re-planning failed; failing plan", {
+          runId: plan.runId,
+          planId: plan.id,
+          iteration,
+          message: cause.message
+        });
+        this.failPlanAfterReplan(plan, failedStep);
+      });
+  }
+
+  /** Replaces the plan's unstarted steps with the model's revision. */
+  private applyReplan(plan: PlanState, drafts: CreatePlanStepInput[]): void {
+    const record = this.store.getRun(plan.runId);
+    if (!record || record.state.plan?.id !== plan.id || plan.status !== "running") {
+      this.logger.warn("discarding plan revision; plan no longer running", {
+        runId: plan.runId,
+        planId: plan.id
+      });
+      return;
+    }
+    const kept = plan.steps.filter((step) => step.status !== "pending");
+    const existing = new Map(kept.map((step) => [step.name, step.id]));
+    let revised: PlanStep[];
+    try {
+      revised = this.buildSteps(record, drafts, existing);
+    } catch (error) {
+      const message = error instanceof Error ? error.message : String(error);
+      this.logger.error("plan revision invalid; failing plan", {
+        runId: plan.runId,
+        planId: plan.id,
+        message
+      });
+      const failedStep = plan.steps.find((step) => step.status === "failed");
+      this.failPlanAfterReplan(plan, failedStep);
+      return;
+    }
+    plan.steps = [...kept, ...revised];
+    this.logger.info("plan revised", {
+      runId: plan.runId,
+      planId: plan.id,
+      kept: kept.length,
+      revised: revised.length
+    });
+    this.finishOrContinue(record, plan);
+  }
+
+  /** Terminal path when re-planning itself fails: fail the plan as if the loop were disabled. */
+  private failPlanAfterReplan(plan: PlanState, failedStep?: PlanStep): void {
+    const record = this.store.getRun(plan.runId);
+    if (!record || record.state.plan?.id !== plan.id || plan.status !== "running") {
+      return;
+    }
+    if (failedStep) {
+      this.skipDependents(plan, failedStep.id);
+    }
+    plan.status = "failed";
+    this.finishOrContinue(record, plan);
+  }
+
+  /**
+   * Interrupts the step's turn when the attempt outlives its
+   * `timeoutSeconds`; the settling interrupt is then handled as a
+   * failure, so retries and re-planning apply.
+   */
+  private armStepTimeout(plan: PlanState, step: PlanStep): void {
+    if (step.timeoutSeconds === undefined) {
+      return;
+    }
+    const timer = setTimeout(() => {
+      this.stepTimers.delete(step.id);
+      if (step.status !== "running") {
+        return;
+      }
+      this.timedOutSteps.add(step.id);
+      this.logger.warn("plan step timed out; interrupting", {
+        runId: plan.runId,
+        planId: plan.id,
+        stepId: step.id,
+        name: step.name,
+        timeoutSeconds: step.timeoutSeconds
+      });
+      this.interruptNode(plan.runId, step.nodeId).catch((error: Error) => {
+        this.logger.error("failed to interrupt timed-out step", {
+          runId: plan.runId,
+          stepId: step.id,
+          message: error.message
+        });
+      });
+    }, step.timeoutSeconds * 1000);
+    timer.unref();
+    this.stepTimers.set(step.id, timer);
+  }
+
+  private disarmStepTimeout(step: PlanStep): void {
+    const timer = this.stepTimers.get(step.id);
+    if (timer) {
+      clearTimeout(timer);
+      this.stepTimers.delete(step.id);
+    }
+  }
+
+  /**
+   * Puts a failed step back to pending and re-dispatches it after an
+   * exponential backoff, so flaky failures (network, rate limits) get
+   * their remaining attempts without tearing the plan down.
+   */
+  private scheduleRetry(plan: PlanState, step: PlanStep, error: string): void {
+    const delay = Math.min(RETRY_BASE_DELAY_MS * 2 ** (step.attempt - 1), RETRY_MAX_DELAY_MS);
+    this.patchStep(plan, step, "pending", `attempt ${step.attempt}/${step.maxAttempts} failed: ${error}`);
+    this.logger.warn("plan step retrying", {
+      runId: plan.runId,
+      planId: plan.id,
+      stepId: step.id,
+      name: step.name,
+      attempt: step.attempt,
+      maxAttempts: step.maxAttempts,
+      delayMs: delay,
+      error
+    });
+    const timer = setTimeout(() => {
+      this.retryTimers.delete(step.id);
+      const record = this.store.getRun(plan.runId);
+      if (!record || record.state.plan?.id !== plan.id || plan.status !== "running") {
+        return;
+      }
+      this.dispatchReady(plan);
+      this.emitPlanUpdated(record);
+    }, delay);
+    timer.unref();
+    this.retryTimers.set(step.id, timer);
+  }
+
+  /** Marks every pending step that transitively depends on `failedId` as skipped. */
+  private skipDependents(plan: PlanState, failedId: UUID): void {
+    const dead = new Set<UUID>([failedId]);
+    let grew = true;
+    while (grew) {
+      grew = false;
+      for (const step of plan.steps) {
+        if (dead.has(step.id) || !step.dependsOn.some((dep) => dead.has(dep))) {
+          continue;
+        }
+        dead.add(step.id);
+        grew = true;
+        if (step.status === "pending") {
+          this.patchStep(plan, step, "skipped", "dependency failed");
+        }
+      }
+    }
+  }
+
+  private patchStep(plan: PlanState, step: PlanStep, status: PlanStepStatus, error?: string): void {
+    step.status = status;
+    step.error = error;
+    if (status === "completed" || status === "failed" || status === "skipped") {
+      step.completedAt = nowIso();
+    }
+    this.emitEvent(plan.runId, {
+      id: newId(),
+      runId: plan.runId,
+      ts: nowIso(),
+      type: "plan.step",
+      planId: plan.id,
+      stepId: step.id,
+      nodeId: step.nodeId,
+      status,
+      error
+    });
+  }
+
+  private emitPlanUpdated(record: RunRecord): void {
+    const plan = record.state.plan;
+    if (!plan) {
+      return;
+    }
+    this.emitEvent(plan.runId, {
+      id: newId(),
+      runId: plan.runId,
+      ts: nowIso(),
+      type: "plan.updated",
+      plan
+    });
+  }
+
+  /** Throws when the dependency graph has a cycle (Kahn's algorithm). */
+  private assertAcyclic(steps: PlanStep[]): void {
+    // Deps on steps outside this batch (completed work, when re-planning)
+    // cannot form a cycle and are ignored.
+    const known = new Set(steps.map((step) => step.id));
+    const remaining = new Map(
+      steps.map((step) => [step.id, new Set(step.dependsOn.filter((dep) => known.has(dep)))])
+    );
+    let removed = true;
+    while (removed) {
+      removed = false;
+      for (const [id, deps] of remaining) {
+        if (deps.size === 0) {
+          remaining.delete(id);
+          for (const other of remaining.values()) {
+            other.delete(id);
+          }
+          removed = true;
+        }
+      }
+    }
+    if (remaining.size > 0) {
+      const stuck = steps.filter((step) => remaining.has(step.id)).map((step) => step.name);
+      throw new Error(`plan has a dependency cycle involving: ${stuck.join(", ")}`);
+    }
+  }
+
+  private requireRun(runId: UUID): RunRecord {
+    const record = this.store.getRun(runId);
+    if (!record) {
+      throw new Error(`Run ${runId} not found`);
+    }
+    return record;
+  }
+}
@@ -44,6 +44,8 @@ export interface PromptBuildResult {
 
 export interface PromptBuildOptions {
   toolProtocol?: string;
+  /** Workspace policy verify commands, surfaced in the mode block. */
+  verifyCommands?: string[];
 }
 
 export class PromptBuilder {
@@ -61,7 +63,15 @@ export class PromptBuilder {
   async build(input: TurnInput, options: PromptBuildOptions = {}): Promise<PromptBuildResult> {
     const system = [SYSTEM_CONTEXT, options.toolProtocol].filter(Boolean).join("\n\n");
     const role = await this.loadRoleTemplate(input);
-    const mode = MODE_PREAMBLE[input.run.globalMode];
+    const verify = options.verifyCommands ?? [];
+    const mode =
+      verify.length > 0
+        ? [
+          MODE_PREAMBLE[input.run.globalMode],
+          "Workspace verification commands (run before reporting completion):",
+          ...verify.map((cmd) => `- ${cmd}`)
+        ].join("\n")
+        : MODE_PREAMBLE[input.run.globalMode];
     const task = this.buildTaskPayload(input);
     const blocks: PromptBlocks = {
       system,
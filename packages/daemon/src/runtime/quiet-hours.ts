@@ -0,0 +1,44 @@
+/**
+ * Quiet-hours window parsed from VUHLP_QUIET_HOURS ("HH:MM-HH:MM", local
+ * time, overnight wrap supported). While the window is active the
+ * scheduler queues work instead of starting turns and webhook
+ * notifications are suppressed, so scheduled agents don't page anyone at
+ * 3am waiting for approvals.
+ */
+export function inQuietHours(now: Date = new Date()): boolean {
+  const window = parseQuietHours(process.env.VUHLP_QUIET_HOURS);
+  if (!window) {
+    return false;
+  }
+  const minutes = now.getHours() * 60 + now.getMinutes();
+  if (window.start === window.end) {
+    return false;
+  }
+  if (window.start < window.end) {
+    return minutes >= window.start && minutes < window.end;
+  }
+  // Overnight window, e.g. 22:00-07:00.
+  return minutes >= window.start || minutes < window.end;
+}
+
+export function quietHoursConfigured(): boolean {
+  return parseQuietHours(process.env.VUHLP_QUIET_HOURS) !== null;
+}
+
+function parseQuietHours(raw: string | undefined): { start: number; end: number } | null {
+  if (!raw || raw.trim().length === 0) {
+    return null;
+  }
+  const match = raw.trim().match(/^(\d{1,2}):(\d{2})-(\d{1,2}):(\d{2})$/);
+  if (!match) {
+    return null;
+  }
+  const startHours = Number(match[1]);
+  const startMinutes = Number(match[2]);
+  const endHours = Number(match[3]);
+  const endMinutes = Number(match[4]);
+  if (startHours > 23 || endHours > 23 || startMinutes > 59 || endMinutes > 59) {
+    return null;
+  }
+  return { start: startHours * 60 + startMinutes, end: endHours * 60 + endMinutes };
+}
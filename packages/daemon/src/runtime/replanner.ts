@@ -0,0 +1,161 @@
+import type { Logger } from "@vuhlp/providers";
+
+const REPLAN_TIMEOUT_MS = 120_000;
+
+const REPLAN_SYSTEM_PROMPT = [
+  "You revise the remainder of an execution plan after one of its steps failed.",
+  "You are given the goal, the steps already completed, the failed step with its",
+  "error output, and the steps that have not run yet. Produce a revised remainder",
+  "that works around the failure: adjust, split, reorder, or drop steps as needed.",
+  "Do not repeat completed steps. Respond with a JSON array only - no prose, no",
+  'code fences. Each element: {"name": string, "task": string, "nodeId"?: string,',
+  '"dependsOn"?: string[], "maxAttempts"?: number}. dependsOn refers to steps by',
+  "name and may name completed steps."
+].join("\n");
+
+export interface ReplanInput {
+  goal?: string;
+  completed: Array<{ name: string; task: string }>;
+  failed: { name: string; task: string; error: string };
+  remaining: Array<{ name: string; task: string }>;
+  /** Node ids the revised steps may be assigned to. */
+  nodeIds: string[];
+}
+
+export interface ReplanStepDraft {
+  name: string;
+  task: string;
+  nodeId?: string;
+  dependsOn?: string[];
+  maxAttempts?: number;
+}
+
+/**
+ * Generates a revised plan remainder after a step failure by feeding the
+ * failure output back to a model (OpenAI-compatible chat completions).
+ *
+ * Configured via VUHLP_REPLAN_API_KEY / VUHLP_REPLAN_MODEL /
+ * VUHLP_REPLAN_API_URL, each falling back to the consensus-planning
+ * equivalent so one provider setup serves both. Disabled when no key or
+ * model resolves.
+ */
+export class Replanner {
+  private readonly logger?: Logger;
+
+  constructor(logger?: Logger) {
+    this.logger = logger;
+  }
+
+  get enabled(): boolean {
+    return Boolean(this.apiKey() && this.model());
+  }
+
+  private apiKey(): string | undefined {
+    return process.env.VUHLP_REPLAN_API_KEY ?? process.env.VUHLP_CONSENSUS_API_KEY;
+  }
+
+  private model(): string | undefined {
+    const explicit = process.env.VUHLP_REPLAN_MODEL?.trim();
+    if (explicit) {
+      return explicit;
+    }
+    const consensus = (process.env.VUHLP_CONSENSUS_MODELS ?? "")
+      .split(",")
+      .map((value) => value.trim())
+      .filter((value) => value.length > 0);
+    return consensus[0];
+  }
+
+  private baseUrl(): string {
+    return (
+      process.env.VUHLP_REPLAN_API_URL ??
+      process.env.VUHLP_CONSENSUS_API_URL ??
+      "https://api.openai.com/v1"
+    ).replace(/\/+$/, "");
+  }
+
+  async replan(input: ReplanInput): Promise<ReplanStepDraft[]> {
+    const apiKey = this.apiKey();
+    const model = this.model();
+    if (!apiKey || !model) {
+      throw new Error("re-planning disabled; set VUHLP_REPLAN_API_KEY and VUHLP_REPLAN_MODEL");
+    }
+    const describe = (steps: Array<{ name: string; task: string }>): string =>
+      steps.length > 0 ? steps.map((step) => `- ${step.name}: ${step.task}`).join("\n") : "(none)";
+    const userPrompt = [
+      input.goal ? `Goal: ${input.goal}` : undefined,
+      `Available node ids: ${input.nodeIds.join(", ")}`,
+      `Completed steps:\n${describe(input.completed)}`,
+      `Failed step:\n- ${input.failed.name}: ${input.failed.task}\nFailure output:\n${input.failed.error}`,
+      `Steps not yet run:\n${describe(input.remaining)}`
+    ]
+      .filter((part): part is string => Boolean(part))
+      .join("\n\n");
+
+    this.logger?.info("requesting plan revision", { model, remaining: input.remaining.length });
+    const content = await this.complete(apiKey, model, userPrompt);
+    return this.parseSteps(content, model);
+  }
+
+  private parseSteps(content: string, model: string): ReplanStepDraft[] {
+    // Models wrap JSON in fences often enough that stripping them is cheaper
+    // than another round trip.
+    const stripped = content
+      .trim()
+      .replace(/^```(?:json)?\s*/i, "")
+      .replace(/\s*```$/, "");
+    let parsed: ReplanStepDraft[];
+    try {
+      parsed = JSON.parse(stripped) as ReplanStepDraft[];
+    } catch (error) {
+      const message = error instanceof Error ? error.message : String(error);
+      throw new Error(`re-plan response is not valid JSON (${model}): ${message}`);
+    }
+    if (!Array.isArray(parsed) || parsed.length === 0) {
+      throw new Error(`re-plan response must be a non-empty JSON array (${model})`);
+    }
+    for (const step of parsed) {
+      if (typeof step?.name !== "string" || typeof step?.task !== "string") {
+        throw new Error(`re-plan step missing name or task (${model})`);
+      }
+    }
+    return parsed;
+  }
+
+  private async complete(apiKey: string, model: string, userPrompt: string): Promise<string> {
+    const controller = new AbortController();
+    const timer = setTimeout(() => controller.abort(), REPLAN_TIMEOUT_MS);
+    try {
+      const response = await fetch(`${this.baseUrl()}/chat/completions`, {
+        method: "POST",
+        headers: {
+          "Content-Type": "application/json",
+          Authorization: `Bearer ${apiKey}`
+        },
+        body: JSON.stringify({
+          model,
+          messages: [
+            { role: "system", content: REPLAN_SYSTEM_PROMPT },
+            { role: "user", content: userPrompt }
+          ],
+          temperature: 0.2
+        }),
+        signal: controller.signal
+      });
+      if (!response.ok) {
+        const detail = await response.text();
+        throw new Error(`re-plan call rejected (${model}): ${response.status} ${detail}`);
+      }
+      const payload = (await response.json()) as {
+        choices?: Array<{ message?: { content?: string } }>;
+      };
+      const content = payload.choices?.[0]?.message?.content;
+      if (!content) {
+        throw new Error(`re-plan response missing content (${model})`);
+      }
+      return content;
+    } finally {
+      clearTimeout(timer);
+    }
+  }
+}
@@ -0,0 +1,168 @@
+import { promises as fs } from "fs";
+import path from "path";
+
+/**
+ * Lightweight repo map: per-file top-level symbols with language-specific
+ * kinds, feeding the context pack so agents orient quickly on polyglot
+ * repos. Parsers are line-anchored patterns per language rather than
+ * tree-sitter grammars — the daemon stays free of native build steps —
+ * but they cover the declaration forms that matter for navigation.
+ */
+
+export type SymbolKind =
+  | "function"
+  | "method"
+  | "type"
+  | "struct"
+  | "interface"
+  | "class"
+  | "enum"
+  | "trait"
+  | "module"
+  | "const";
+
+export interface RepoSymbol {
+  name: string;
+  kind: SymbolKind;
+  line: number;
+}
+
+export interface RepoMapEntry {
+  path: string;
+  language: string;
+  symbols: RepoSymbol[];
+}
+
+interface LanguagePattern {
+  kind: SymbolKind;
+  regex: RegExp;
+}
+
+const LANGUAGE_BY_EXT: Record<string, string> = {
+  ".go": "go",
+  ".py": "python",
+  ".js": "javascript",
+  ".jsx": "javascript",
+  ".ts": "typescript",
+  ".tsx": "typescript",
+  ".rs": "rust",
+  ".java": "java",
+  ".cs": "csharp",
+  ".rb": "ruby",
+  ".kt": "kotlin",
+  ".kts": "kotlin"
+};
+
+const PATTERNS: Record<string, LanguagePattern[]> = {
+  go: [
+    { kind: "method", regex: /^func\s+\([^)]+\)\s+([A-Za-z_][A-Za-z0-9_]*)/ },
+    { kind: "function", regex: /^func\s+([A-Za-z_][A-Za-z0-9_]*)/ },
+    { kind: "struct", regex: /^type\s+([A-Za-z_][A-Za-z0-9_]*)\s+struct\b/ },
+    { kind: "interface", regex: /^type\s+([A-Za-z_][A-Za-z0-9_]*)\s+interface\b/ },
+    { kind: "type", regex: /^type\s+([A-Za-z_][A-Za-z0-9_]*)\b/ }
+  ],
+  python: [
+    { kind: "class", regex: /^class\s+([A-Za-z_][A-Za-z0-9_]*)/ },
+    { kind: "function", regex: /^(?:async\s+)?def\s+([A-Za-z_][A-Za-z0-9_]*)/ },
+    { kind: "method", regex: /^\s+(?:async\s+)?def\s+([A-Za-z_][A-Za-z0-9_]*)/ }
+  ],
+  javascript: [
+    { kind: "class", regex: /^(?:export\s+)?(?:default\s+)?class\s+([A-Za-z_$][A-Za-z0-9_$]*)/ },
+    { kind: "function", regex: /^(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*([A-Za-z_$][A-Za-z0-9_$]*)/ },
+    { kind: "const", regex: /^(?:export\s+)?const\s+([A-Za-z_$][A-Za-z0-9_$]*)\s*=/ }
+  ],
+  typescript: [
+    { kind: "class", regex: /^(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+([A-Za-z_$][A-Za-z0-9_$]*)/ },
+    { kind: "interface", regex: /^(?:export\s+)?interface\s+([A-Za-z_$][A-Za-z0-9_$]*)/ },
+    { kind: "enum", regex: /^(?:export\s+)?(?:const\s+)?enum\s+([A-Za-z_$][A-Za-z0-9_$]*)/ },
+    { kind: "type", regex: /^(?:export\s+)?type\s+([A-Za-z_$][A-Za-z0-9_$]*)\s*=/ },
+    { kind: "function", regex: /^(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*([A-Za-z_$][A-Za-z0-9_$]*)/ },
+    { kind: "const", regex: /^(?:export\s+)?const\s+([A-Za-z_$][A-Za-z0-9_$]*)\s*=/ }
+  ],
+  rust: [
+    { kind: "function", regex: /^\s*(?:pub(?:\([^)]*\))?\s+)?(?:async\s+)?(?:unsafe\s+)?fn\s+([A-Za-z_][A-Za-z0-9_]*)/ },
+    { kind: "struct", regex: /^\s*(?:pub(?:\([^)]*\))?\s+)?struct\s+([A-Za-z_][A-Za-z0-9_]*)/ },
+    { kind: "enum", regex: /^\s*(?:pub(?:\([^)]*\))?\s+)?enum\s+([A-Za-z_][A-Za-z0-9_]*)/ },
+    { kind: "trait", regex: /^\s*(?:pub(?:\([^)]*\))?\s+)?trait\s+([A-Za-z_][A-Za-z0-9_]*)/ },
+    { kind: "module", regex: /^\s*(?:pub(?:\([^)]*\))?\s+)?mod\s+([A-Za-z_][A-Za-z0-9_]*)/ },
+    { kind: "type", regex: /^\s*(?:pub(?:\([^)]*\))?\s+)?type\s+([A-Za-z_][A-Za-z0-9_]*)/ }
+  ],
+  java: [
+    { kind: "class", regex: /^\s*(?:public\s+|protected\s+|private\s+)?(?:static\s+)?(?:final\s+|abstract\s+)?class\s+([A-Za-z_$][A-Za-z0-9_$]*)/ },
+    { kind: "interface", regex: /^\s*(?:public\s+)?interface\s+([A-Za-z_$][A-Za-z0-9_$]*)/ },
+    { kind: "enum", regex: /^\s*(?:public\s+)?enum\s+([A-Za-z_$][A-Za-z0-9_$]*)/ },
+    { kind: "method", regex: /^\s{2,}(?:public|protected|private)\s+(?:static\s+)?(?:final\s+)?[\w<>\[\],\s]+\s+([a-z][A-Za-z0-9_$]*)\s*\(/ }
+  ],
+  csharp: [
+    { kind: "class", regex: /^\s*(?:public\s+|internal\s+|private\s+|protected\s+)?(?:static\s+|sealed\s+|abstract\s+|partial\s+)*class\s+([A-Za-z_][A-Za-z0-9_]*)/ },
+    { kind: "interface", regex: /^\s*(?:public\s+|internal\s+)?interface\s+([A-Za-z_][A-Za-z0-9_]*)/ },
+    { kind: "enum", regex: /^\s*(?:public\s+|internal\s+)?enum\s+([A-Za-z_][A-Za-z0-9_]*)/ },
+    { kind: "struct", regex: /^\s*(?:public\s+|internal\s+)?(?:readonly\s+)?struct\s+([A-Za-z_][A-Za-z0-9_]*)/ },
+    { kind: "method", regex: /^\s{2,}(?:public|internal|protected|private)\s+(?:static\s+|async\s+|virtual\s+|override\s+)*[\w<>\[\],\s?]+\s+([A-Z][A-Za-z0-9_]*)\s*\(/ }
+  ],
+  ruby: [
+    { kind: "class", regex: /^\s*class\s+([A-Z][A-Za-z0-9_:]*)/ },
+    { kind: "module", regex: /^\s*module\s+([A-Z][A-Za-z0-9_:]*)/ },
+    { kind: "method", regex: /^\s*def\s+(?:self\.)?([a-z_][A-Za-z0-9_?!=]*)/ }
+  ],
+  kotlin: [
+    { kind: "class", regex: /^\s*(?:public\s+|internal\s+|private\s+)?(?:data\s+|sealed\s+|abstract\s+|open\s+)*class\s+([A-Za-z_][A-Za-z0-9_]*)/ },
+    { kind: "interface", regex: /^\s*(?:public\s+|internal\s+)?interface\s+([A-Za-z_][A-Za-z0-9_]*)/ },
+    { kind: "enum", regex: /^\s*(?:public\s+|internal\s+)?enum\s+class\s+([A-Za-z_][A-Za-z0-9_]*)/ },
+    { kind: "function", regex: /^\s*(?:public\s+|internal\s+|private\s+)?(?:suspend\s+)?fun\s+(?:<[^>]+>\s+)?([A-Za-z_][A-Za-z0-9_]*)/ },
+    { kind: "module", regex: /^\s*object\s+([A-Za-z_][A-Za-z0-9_]*)/ }
+  ]
+};
+
+const MAX_SYMBOLS_PER_FILE = 50;
+
+export function languageForPath(filePath: string): string | null {
+  return LANGUAGE_BY_EXT[path.extname(filePath).toLowerCase()] ?? null;
+}
+
+/**
+ * Extracts top-level symbols from file content. Each line is matched
+ * against the language's patterns in order; the first match wins so
+ * "type Foo struct" lands as struct, not type.
+ */
+export function extractSymbols(language: string, content: string): RepoSymbol[] {
+  const patterns = PATTERNS[language];
+  if (!patterns) {
+    return [];
+  }
+  const symbols: RepoSymbol[] = [];
+  const lines = content.split(/\r?\n/);
+  for (let index = 0; index < lines.length && symbols.length < MAX_SYMBOLS_PER_FILE; index += 1) {
+    const line = lines[index];
+    for (const pattern of patterns) {
+      const match = line.match(pattern.regex);
+      if (match) {
+        symbols.push({ name: match[1], kind: pattern.kind, line: index + 1 });
+        break;
+      }
+    }
+  }
+  return symbols;
+}
+
+/**
+ * Builds map entries for the given workspace-relative files, skipping
+ * files in unknown languages or that fail to read.
+ */
+export async function buildRepoMap(root: string, files: string[]): Promise<RepoMapEntry[]> {
+  const entries: RepoMapEntry[] = [];
+  for (const file of files) {
+    const language = languageForPath(file);
+    if (!language) {
+      continue;
+    }
+    let content: string;
+    try {
+      content = await fs.readFile(path.resolve(root, file), "utf8");
+    } catch {
+      continue;
+    }
+    entries.push({ path: file, language, symbols: extractSymbols(language, content) });
+  }
+  return entries;
+}
@@ -0,0 +1,109 @@
+import type { RunStatus, RunSummary } from "@vuhlp/contracts";
+import type { Logger } from "@vuhlp/providers";
+import { nowIso } from "./utils.js";
+
+const SUMMARY_TIMEOUT_MS = 60_000;
+const MAX_REPORT_CHARS = 12_000;
+const MAX_SUMMARY_CHARS = 1_500;
+
+const SYSTEM_PROMPT = [
+  "You write the wrap-up for a finished multi-agent coding run.",
+  "From the report, produce a concise summary covering three things:",
+  "what changed, what was verified, and what is left or went wrong.",
+  "A few short sentences or bullets; no preamble, no headings.",
+  "Respond with the summary text only."
+].join("\n");
+
+export interface RunSummaryInput {
+  status: RunStatus;
+  /** Plain-text run report: node outcomes, plan/loop state, commits, cancellation. */
+  report: string;
+}
+
+/**
+ * Turns a finished run's raw state into a human-readable wrap-up with a
+ * cheap model call, so run lists and the dashboard can show more than a
+ * status string.
+ *
+ * Configured via VUHLP_SUMMARY_API_KEY / VUHLP_SUMMARY_MODEL /
+ * VUHLP_SUMMARY_API_URL, each falling back to its VUHLP_EVAL_*
+ * equivalent — a turn-evaluation setup also enables summaries.
+ */
+export class RunSummarizer {
+  private readonly logger?: Logger;
+  private warnedDisabled = false;
+
+  constructor(logger?: Logger) {
+    this.logger = logger;
+  }
+
+  get enabled(): boolean {
+    return Boolean(process.env.VUHLP_SUMMARY_API_KEY ?? process.env.VUHLP_EVAL_API_KEY);
+  }
+
+  async summarize(input: RunSummaryInput): Promise<RunSummary | null> {
+    const apiKey = process.env.VUHLP_SUMMARY_API_KEY ?? process.env.VUHLP_EVAL_API_KEY;
+    if (!apiKey) {
+      if (!this.warnedDisabled) {
+        this.warnedDisabled = true;
+        this.logger?.debug("run summaries disabled; set VUHLP_SUMMARY_API_KEY to enable");
+      }
+      return null;
+    }
+    const model = process.env.VUHLP_SUMMARY_MODEL ?? process.env.VUHLP_EVAL_MODEL ?? "gpt-4o-mini";
+    const baseUrl = (
+      process.env.VUHLP_SUMMARY_API_URL ??
+      process.env.VUHLP_EVAL_API_URL ??
+      "https://api.openai.com/v1"
+    ).replace(/\/+$/, "");
+    const report =
+      input.report.length > MAX_REPORT_CHARS
+        ? `${input.report.slice(0, MAX_REPORT_CHARS)}\n[truncated]`
+        : input.report;
+    const userPrompt = `Run finished with status: ${input.status}\n\n${report}`;
+
+    try {
+      const controller = new AbortController();
+      const timer = setTimeout(() => controller.abort(), SUMMARY_TIMEOUT_MS);
+      try {
+        const response = await fetch(`${baseUrl}/chat/completions`, {
+          method: "POST",
+          headers: {
+            "Content-Type": "application/json",
+            Authorization: `Bearer ${apiKey}`
+          },
+          body: JSON.stringify({
+            model,
+            messages: [
+              { role: "system", content: SYSTEM_PROMPT },
+              { role: "user", content: userPrompt }
+            ],
+            temperature: 0.2
+          }),
+          signal: controller.signal
+        });
+        if (!response.ok) {
+          const detail = await response.text();
+          this.logger?.warn("run summary request rejected", { status: response.status, detail });
+          return null;
+        }
+        const payload = (await response.json()) as {
+          choices?: Array<{ message?: { content?: string } }>;
+        };
+        const content = payload.choices?.[0]?.message?.content?.trim();
+        if (!content) {
+          this.logger?.warn("run summary response missing content");
+          return null;
+        }
+        const text = content.length > MAX_SUMMARY_CHARS ? `${content.slice(0, MAX_SUMMARY_CHARS)}…` : content;
+        return { text, model, generatedAt: nowIso() };
+      } finally {
+        clearTimeout(timer);
+      }
+    } catch (error) {
+      const message = error instanceof Error ? error.message : String(error);
+      this.logger?.warn("run summary generation failed", { model, message });
+      return null;
+    }
+  }
+}
@@ -75,8 +75,14 @@ export interface NodeRunner {
   stopNode?(nodeId: UUID): Promise<void>;
   disposeNode?(nodeId: UUID): Promise<void>;
   resolveApproval?(approvalId: UUID, resolution: ApprovalResolution): Promise<void>;
+  /** Hands back an approval persisted before a restart, so the node's next turn re-waits on the same tool call. */
+  restorePendingApproval?(input: { runId: UUID; nodeId: UUID; approval: ApprovalRequest }): void;
   resetNode?(nodeId: UUID): Promise<void>;
+  /** Extra env for commands the node's tools run, merged over the run's env set; undefined clears it. */
+  setStepEnv?(nodeId: UUID, env: Record<string, string> | undefined): void;
   interruptNode?(nodeId: UUID): Promise<void>;
+  /** Id of the turn currently running on the node, when the runner tracks turns. */
+  activeTurnId?(nodeId: UUID): UUID | undefined;
 }
 
 export class NoopRunner implements NodeRunner {
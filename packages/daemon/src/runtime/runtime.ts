@@ -1,11 +1,26 @@
+import { exec as execCallback } from "child_process";
 import { promises as fs } from "fs";
 import path from "path";
+import { promisify } from "util";
 import type {
+  AdminHaltState,
+  ApiKeyInfo,
+  ApiKeyScope,
+  ApprovalComment,
+  ApprovalDecision,
   ApprovalRequest,
   ApprovalResolution,
   Artifact,
   ArtifactKind,
   ArtifactMetadata,
+  AutoApproveRule,
+  CancellationInfo,
+  CancellationSource,
+  CompareBatchResponse,
+  ContextPack,
+  ConversationExportFormat,
+  ConversationImportFormat,
+  CreatePlanRequest,
   CreateTemplateResponse,
   DeleteTemplateResponse,
   EdgeManagementScope,
@@ -14,7 +29,15 @@ import type {
   EventEnvelope,
   FileEntry,
   GlobalMode,
+  GetFileAtTurnResponse,
+  GetReadinessResponse,
   GetRoleTemplateResponse,
+  GetToolStatsResponse,
+  GetUsageResponse,
+  ListCheckpointsResponse,
+  GetRunPatchSeriesResponse,
+  ReadinessCheck,
+  RunPatchSeriesEntry,
   ListDirectoryResponse,
   ListTemplatesResponse,
   NodeConnection,
@@ -22,32 +45,134 @@ import type {
   NodeConfigInput,
   NodeState,
   OrchestrationMode,
+  PendingApprovalSummary,
+  PlanState,
+  ProviderName,
+  ProviderUsageBreakdown,
+  RunAcceptanceResponse,
+  RunBudget,
+  RunListItem,
   RunState,
+  RunStatus,
+  RunTemplate,
+  SpecInfo,
+  SpecLoopState,
+  StartSpecLoopRequest,
   TemplateInfo,
+  ToolCall,
+  ToolUsageStats,
   UpdateTemplateResponse,
   UsageTotals,
   UserMessageRecord,
-  UUID
+  UUID,
+  VariantComparisonEntry,
+  WebhookSubscription,
+  WorkspaceSnapshot
 } from "@vuhlp/contracts";
+import { reloadEnv } from "../env.js";
+import { renderConversation, type ConversationExport } from "./conversation-export.js";
+import { parseConversation, type ImportedMessage } from "./conversation-import.js";
 import { EventBus } from "./event-bus.js";
 import { ArtifactStore } from "./artifact-store.js";
 import { EventLog } from "./event-log.js";
 import { RunStore, type NodeRecord, type RunRecord } from "./store.js";
+import { CheckpointStore } from "./checkpoint-store.js";
+import { WebhookDispatcher } from "./webhooks.js";
+import { parseAcceptanceTests, runAcceptanceTests } from "./acceptance.js";
+import { Notifier } from "./notifier.js";
+import { PlanRunner } from "./plan-runner.js";
+import { RunSummarizer } from "./run-summarizer.js";
+import { SpecLoopRunner } from "./spec-loop.js";
+import { verifyApprovalLinkToken } from "./approval-links.js";
+import { ApiKeyStore } from "./api-key-store.js";
+import { AuditLog, type AuditVerification } from "./audit-log.js";
+import { ConsensusPlanner, type ConsensusPlanResult } from "./consensus-planner.js";
+import { ProviderResolver, type ProviderSpec } from "./provider-resolver.js";
+import { buildRepoMap } from "./repo-map.js";
 import { Scheduler } from "./scheduler.js";
 import { type NodeRunner } from "./runner.js";
 import { CliRunner } from "./cli-runner.js";
-import { newId, nowIso } from "./utils.js";
+import { hashString, newId, nowIso } from "./utils.js";
 import {
   ConsoleLogger,
   CreateEdgeRequest,
   CreateEdgeResult,
+  loadPlugins,
   type Logger,
   SendHandoffRequest,
   SendHandoffResult,
   SpawnNodeRequest,
-  SpawnNodeResult
+  SpawnNodeResult,
+  SpawnSubagentRequest,
+  SpawnSubagentResult,
+  stopAllProcesses
 } from "@vuhlp/providers";
 
+const exec = promisify(execCallback);
+
+const DEFAULT_SUBAGENT_TIMEOUT = 600_000;
+
+const subagentTimeout = (): number => {
+  const raw = Number(process.env.VUHLP_SUBAGENT_TIMEOUT);
+  return Number.isFinite(raw) && raw > 0 ? raw : DEFAULT_SUBAGENT_TIMEOUT;
+};
+
+/** Workspace-wide sandbox default; a per-run sandboxImage overrides it. */
+const defaultSandboxImage = (): string | undefined => {
+  const raw = process.env.VUHLP_SANDBOX_IMAGE;
+  return raw && raw.trim().length > 0 ? raw.trim() : undefined;
+};
+
+const APPROVAL_SWEEP_INTERVAL = 30_000;
+const APPROVAL_DECISION_HISTORY = 100;
+
+/** Cap on each /readyz provider probe so orchestrator health checks stay fast. */
+const READINESS_PROBE_TIMEOUT_MS = 5_000;
+
+/** Global cap on concurrently active runs; 0 means unlimited. */
+const maxActiveRuns = (): number => {
+  const raw = Number.parseInt(process.env.VUHLP_MAX_ACTIVE_RUNS ?? "", 10);
+  return Number.isFinite(raw) && raw > 0 ? raw : 0;
+};
+
+/** Active runs allowed per workspace; default 1 so two runs never stomp the same tree. */
+const maxRunsPerWorkspace = (): number => {
+  const raw = Number.parseInt(process.env.VUHLP_MAX_RUNS_PER_WORKSPACE ?? "", 10);
+  return Number.isFinite(raw) && raw > 0 ? raw : 1;
+};
+
+/** Default token budget for runs created without one; 0 means unlimited. */
+const budgetTokensDefault = (): number => {
+  const raw = Number.parseInt(process.env.VUHLP_BUDGET_TOKENS ?? "", 10);
+  return Number.isFinite(raw) && raw > 0 ? raw : 0;
+};
+
+/** Default USD budget for runs created without one; 0 means unlimited. */
+const budgetUsdDefault = (): number => {
+  const raw = Number.parseFloat(process.env.VUHLP_BUDGET_USD ?? "");
+  return Number.isFinite(raw) && raw > 0 ? raw : 0;
+};
+
+/** Pending approvals older than this many ms are auto-resolved; unset disables the sweep. */
+const approvalTimeout = (): number | null => {
+  const raw = Number(process.env.VUHLP_APPROVAL_TIMEOUT);
+  return Number.isFinite(raw) && raw > 0 ? raw : null;
+};
+
+/** Tool names whose timed-out approvals resolve as approved instead of denied. */
+const approvalTimeoutAutoApprove = (): Set<string> => {
+  const raw = process.env.VUHLP_APPROVAL_TIMEOUT_AUTO_APPROVE;
+  if (!raw) {
+    return new Set();
+  }
+  return new Set(
+    raw
+      .split(",")
+      .map((name) => name.trim())
+      .filter((name) => name.length > 0)
+  );
+};
+
 const addUsage = (current: UsageTotals | undefined, delta: UsageTotals): UsageTotals => ({
   promptTokens: (current?.promptTokens ?? 0) + delta.promptTokens,
   completionTokens: (current?.completionTokens ?? 0) + delta.completionTokens,
@@ -56,6 +181,16 @@ const addUsage = (current: UsageTotals | undefined, delta: UsageTotals): UsageTo
 
 const getErrorCode = (error: { code?: string } | null | undefined): string | undefined => error?.code;
 
+const TOOL_PREVIEW_MAX = 120;
+
+/** Single-line preview of a tool call for list rendering. */
+const toolPreview = (tool: ToolCall): string => {
+  const command = tool.args.command ?? tool.args.path ?? tool.args.file;
+  const raw = typeof command === "string" ? command : JSON.stringify(tool.args);
+  const firstLine = raw.split("\n")[0].trim();
+  return firstLine.length > TOOL_PREVIEW_MAX ? `${firstLine.slice(0, TOOL_PREVIEW_MAX - 1)}…` : firstLine;
+};
+
 export interface RuntimeOptions {
   dataDir: string;
   runner?: NodeRunner;
@@ -78,6 +213,17 @@ export class Runtime {
   private readonly logger: Logger;
   private readonly artifactStores = new Map<UUID, ArtifactStore>();
   private readonly snapshotTimers = new Map<UUID, NodeJS.Timeout>();
+  private readonly webhooks: WebhookDispatcher;
+  private readonly notifier: Notifier;
+  private readonly planRunner: PlanRunner;
+  private readonly specLoop: SpecLoopRunner;
+  private readonly runSummarizer: RunSummarizer;
+  private readonly apiKeys: ApiKeyStore;
+  private readonly audit: AuditLog;
+  private approvalSweepTimer?: NodeJS.Timeout;
+  private haltedAt: string | null = null;
+  private haltReason?: string;
+  private consensusPlanner: ConsensusPlanner;
 
   constructor(options: RuntimeOptions) {
     this.dataDir = options.dataDir;
@@ -87,6 +233,34 @@ export class Runtime {
     this.logger = options.logger ?? new ConsoleLogger({ scope: "runtime" });
     this.store = new RunStore(this.dataDir, this.logger);
     this.eventBus = new EventBus();
+    this.webhooks = new WebhookDispatcher(this.dataDir, this.logger);
+    this.notifier = new Notifier(this.logger);
+    this.apiKeys = new ApiKeyStore(this.dataDir, this.logger);
+    this.audit = new AuditLog(this.dataDir, this.logger);
+    this.consensusPlanner = new ConsensusPlanner(this.logger);
+    this.runSummarizer = new RunSummarizer(this.logger);
+    this.planRunner = new PlanRunner({
+      store: this.store,
+      emitEvent: this.emitEvent.bind(this),
+      dispatchStep: (runId, nodeId, content) => {
+        this.postMessage(runId, nodeId, content);
+      },
+      interruptNode: (runId, nodeId) => this.interruptNodeProcess(runId, nodeId),
+      setStepEnv: (_runId, nodeId, env) => {
+        this.runner.setStepEnv?.(nodeId, env);
+      },
+      logger: this.logger
+    });
+    this.specLoop = new SpecLoopRunner({
+      store: this.store,
+      emitEvent: this.emitEvent.bind(this),
+      dispatch: (runId, nodeId, content) => {
+        this.postMessage(runId, nodeId, content);
+      },
+      readSpec: (runId, name) => this.getSpec(runId, name),
+      runAcceptance: (runId, name) => this.runAcceptance(runId, name),
+      logger: this.logger
+    });
     this.runner =
       options.runner ??
       new CliRunner({
@@ -96,7 +270,9 @@ export class Runtime {
         spawnNode: this.spawnNodeFromTool.bind(this),
         createEdge: this.createEdgeFromTool.bind(this),
         sendHandoff: this.sendHandoffFromTool.bind(this),
+        spawnSubagent: this.spawnSubagentFromTool.bind(this),
         systemTemplatesDir: this.systemTemplatesDir,
+        isHalted: () => this.haltedAt !== null,
         logger: this.logger
       });
     this.scheduler = new Scheduler({
@@ -105,22 +281,31 @@ export class Runtime {
       runner: this.runner,
       dataDir: this.dataDir,
       stallThreshold: options.stallThreshold,
+      isHalted: () => this.haltedAt !== null,
       logger: this.logger
     });
   }
 
   async start(): Promise<void> {
+    await loadPlugins(this.logger);
     await this.loadPersistedRuns();
+    this.processRunQueue();
     this.scheduler.start();
+    this.approvalSweepTimer = setInterval(() => this.sweepExpiredApprovals(), APPROVAL_SWEEP_INTERVAL);
+    this.approvalSweepTimer.unref();
   }
 
   stop(): void {
     this.scheduler.stop();
+    if (this.approvalSweepTimer) {
+      clearInterval(this.approvalSweepTimer);
+      this.approvalSweepTimer = undefined;
+    }
   }
 
   async shutdown(reason = "shutdown"): Promise<void> {
     this.logger.info("runtime shutdown started", { reason });
-    this.scheduler.stop();
+    this.stop();
     const now = nowIso();
     for (const record of this.store.listRunRecords()) {
       for (const nodeRecord of record.nodes.values()) {
@@ -166,9 +351,57 @@ export class Runtime {
       await this.flushRunSnapshot(runId);
     }
 
+    const orphaned = stopAllProcesses(this.logger);
+    if (orphaned > 0) {
+      this.logger.warn("killed leftover background processes at shutdown", { orphaned });
+    }
+
     this.logger.info("runtime shutdown complete", { runs: this.store.listRuns().length });
   }
 
+  /**
+   * Global kill switch: pauses every active run (interrupting in-flight
+   * turns) and blocks new turns and tool executions until the halt is
+   * lifted. Runs stay paused after resume so an operator can inspect
+   * them before restarting anything.
+   */
+  halt(reason?: string): AdminHaltState {
+    if (this.haltedAt !== null) {
+      return this.haltState();
+    }
+    this.haltedAt = nowIso();
+    this.haltReason = reason?.trim() || undefined;
+    this.logger.warn("daemon halted by kill switch", { reason: this.haltReason });
+    this.audit.record("policy", "halt", { reason: this.haltReason ?? null });
+    let pausedRuns = 0;
+    for (const runState of this.store.listRuns()) {
+      if (runState.status !== "running" && runState.status !== "queued") {
+        continue;
+      }
+      this.updateRun(runState.id, { status: "paused" });
+      pausedRuns += 1;
+    }
+    return { ...this.haltState(), pausedRuns };
+  }
+
+  /** Lifts the kill switch. Paused runs are not restarted automatically. */
+  resumeFromHalt(): AdminHaltState {
+    if (this.haltedAt === null) {
+      return this.haltState();
+    }
+    this.haltedAt = null;
+    this.haltReason = undefined;
+    this.logger.warn("daemon halt lifted; runs remain paused until resumed individually");
+    this.audit.record("policy", "halt-lifted", {});
+    return this.haltState();
+  }
+
+  haltState(): AdminHaltState {
+    return this.haltedAt !== null
+      ? { halted: true, haltedAt: this.haltedAt, reason: this.haltReason }
+      : { halted: false };
+  }
+
   onEvent(listener: (event: EventEnvelope) => void): () => void {
     return this.eventBus.on(listener);
   }
@@ -177,6 +410,32 @@ export class Runtime {
     return this.store.listRuns();
   }
 
+  /**
+   * Runs enriched with a summary of the approvals blocking them, so list
+   * clients can render an actionable queue without fetching each run.
+   */
+  listRunSummaries(): RunListItem[] {
+    const now = Date.now();
+    return this.listRuns().map((run) => {
+      const pendingApprovals: PendingApprovalSummary[] = [];
+      const record = this.store.getRun(run.id);
+      if (record) {
+        for (const approval of record.approvals.values()) {
+          pendingApprovals.push({
+            approvalId: approval.approvalId,
+            nodeId: approval.nodeId,
+            tool: approval.tool.name,
+            preview: approval.summary ?? toolPreview(approval.tool),
+            risk: approval.risk,
+            requestedAt: approval.requestedAt,
+            ageMs: approval.requestedAt ? Math.max(0, now - Date.parse(approval.requestedAt)) : undefined
+          });
+        }
+      }
+      return { ...run, pendingApprovals };
+    });
+  }
+
   getRun(runId: UUID): RunState {
     const record = this.requireRun(runId);
     return record.state;
@@ -374,12 +633,17 @@ export class Runtime {
 
   async getEvents(
     runId: UUID,
-    options?: { limit?: number; before?: number }
+    options?: { limit?: number; before?: number; types?: string[] }
   ): Promise<{ events: EventEnvelope[]; page: { nextCursor: string | null; hasMore: boolean } }> {
     const record = this.requireRun(runId);
     const limit = options?.limit ?? 200;
     const page = await record.eventLog.readPage({ limit, before: options?.before });
-    return { events: page.events, page: { nextCursor: page.nextCursor, hasMore: page.hasMore } };
+    const types = options?.types;
+    const events =
+      types && types.length > 0
+        ? page.events.filter((event) => types.includes(event.type))
+        : page.events;
+    return { events, page: { nextCursor: page.nextCursor, hasMore: page.hasMore } };
   }
 
   private runSnapshotPath(runId: UUID): string {
@@ -610,10 +874,43 @@ export class Runtime {
       status,
       updatedAt: status !== runState.status ? now : runState.updatedAt,
       layout,
-      nodeConfigs
+      nodeConfigs,
+      plan: this.normalizePersistedPlan(runState.plan, now)
     };
   }
 
+  /**
+   * Steps the daemon died under were checkpointed as `running`; their
+   * turns are gone, so hand the attempt back and let resume re-dispatch
+   * from the next pending step.
+   */
+  private normalizePersistedPlan(plan: PlanState | undefined, now: string): PlanState | undefined {
+    if (!plan) {
+      return undefined;
+    }
+    let interrupted = 0;
+    const steps = plan.steps.map((step) => {
+      if (step.status !== "running") {
+        return step;
+      }
+      interrupted += 1;
+      return {
+        ...step,
+        status: "pending" as const,
+        attempt: Math.max(0, step.attempt - 1),
+        startedAt: undefined
+      };
+    });
+    if (interrupted > 0) {
+      this.logger.info("reset in-flight plan steps from snapshot", {
+        runId: plan.runId,
+        planId: plan.id,
+        interrupted
+      });
+    }
+    return interrupted > 0 ? { ...plan, steps, updatedAt: now } : plan;
+  }
+
   private buildNodeConfigFromState(node: NodeState): NodeConfig {
     return {
       id: node.id,
@@ -661,11 +958,42 @@ export class Runtime {
     for (const artifact of Object.values(runState.artifacts)) {
       this.store.addArtifact(runState.id, artifact);
     }
+    const keptApprovals: Record<UUID, ApprovalRequest> = {};
+    for (const approval of Object.values(runState.approvals ?? {})) {
+      if (!record.nodes.get(approval.nodeId)) {
+        this.logger.warn("dropping persisted approval for missing node", {
+          runId: runState.id,
+          nodeId: approval.nodeId,
+          approvalId: approval.approvalId
+        });
+        continue;
+      }
+      record.approvals.set(approval.approvalId, approval);
+      keptApprovals[approval.approvalId] = approval;
+      // Hand the call back to the runner so the node's next turn
+      // re-waits on the same tool_call_id instead of stranding it.
+      this.runner.restorePendingApproval?.({ runId: runState.id, nodeId: approval.nodeId, approval });
+    }
+    record.state.approvals = Object.keys(keptApprovals).length > 0 ? keptApprovals : undefined;
+    if (Object.keys(keptApprovals).length > 0) {
+      this.logger.info("restored pending approvals", {
+        runId: runState.id,
+        count: Object.keys(keptApprovals).length
+      });
+    }
+    if (runState.plan && runState.plan.status === "running") {
+      this.logger.info("restored mid-flight plan; resume the run to continue", {
+        runId: runState.id,
+        planId: runState.plan.id,
+        pending: runState.plan.steps.filter((step) => step.status === "pending").length
+      });
+    }
   }
 
   updateRun(
     runId: UUID,
-    patch: Partial<Pick<RunState, "status" | "mode" | "globalMode" | "layout">>
+    patch: Partial<Pick<RunState, "status" | "mode" | "globalMode" | "layout">>,
+    cancellation?: { reason?: string; actor?: string; source?: CancellationSource }
   ): RunState {
     const record = this.requireRun(runId);
     const now = nowIso();
@@ -675,12 +1003,39 @@ export class Runtime {
     if (patch.status !== undefined) {
       record.state.status = patch.status;
       updates.status = patch.status;
+      if (patch.status === "stopped" && previousStatus !== "stopped") {
+        const info: CancellationInfo = {
+          source: cancellation?.source ?? "user",
+          reason: cancellation?.reason,
+          actor: cancellation?.actor,
+          at: now
+        };
+        record.state.cancellation = info;
+        updates.cancellation = info;
+        this.logger.info("run cancelled", {
+          runId,
+          source: info.source,
+          reason: info.reason,
+          actor: info.actor
+        });
+      }
     }
     if (patch.mode !== undefined) {
+      if (patch.mode !== record.state.mode) {
+        this.audit.record("policy", "mode-changed", { from: record.state.mode, to: patch.mode }, { runId });
+      }
       record.state.mode = patch.mode;
       updates.mode = patch.mode;
     }
     if (patch.globalMode !== undefined) {
+      if (patch.globalMode !== record.state.globalMode) {
+        this.audit.record(
+          "policy",
+          "global-mode-changed",
+          { from: record.state.globalMode, to: patch.globalMode },
+          { runId }
+        );
+      }
       record.state.globalMode = patch.globalMode;
       updates.globalMode = patch.globalMode;
     }
@@ -720,12 +1075,31 @@ export class Runtime {
       this.interruptRun(record, now);
     }
 
+    if (updates.status === "budget_exceeded" && previousStatus !== "budget_exceeded") {
+      // Same interruption as a pause: in-flight turns stop cleanly, and
+      // the run can continue once someone raises the budget and resumes.
+      this.interruptRun(record, now);
+    }
+
     if (updates.status === "stopped" && previousStatus !== "stopped") {
       this.stopRun(record, now);
     }
 
-    if (updates.status === "running" && previousStatus === "paused") {
+    if (updates.status === "running" && (previousStatus === "paused" || previousStatus === "budget_exceeded")) {
       this.resumeInterruptedNodes(record);
+      this.planRunner.resumeDispatch(runId);
+      this.specLoop.resumeDispatch(runId);
+    }
+
+    const terminalStatuses: RunStatus[] = ["stopped", "completed", "failed"];
+    if (
+      updates.status &&
+      terminalStatuses.includes(updates.status) &&
+      !terminalStatuses.includes(previousStatus)
+    ) {
+      void this.captureWorkspaceSnapshot(runId, "end");
+      this.processRunQueue();
+      void this.generateRunSummary(runId);
     }
 
     void this.saveRunSnapshot(runId);
@@ -756,8 +1130,24 @@ export class Runtime {
       }
     }
 
+    if (record.state.worktree) {
+      // Best-effort: the branch stays behind so finished work can still
+      // be merged back after the run is deleted.
+      const { path: worktreePath, base } = record.state.worktree;
+      try {
+        await exec(`git -C "${base}" worktree remove --force "${worktreePath}"`, { timeout: 60_000 });
+      } catch (error) {
+        this.logger.error("failed to remove isolation worktree", {
+          runId,
+          worktreePath,
+          message: error instanceof Error ? error.message : String(error)
+        });
+      }
+    }
+
     this.store.deleteRun(runId);
     this.artifactStores.delete(runId);
+    this.processRunQueue();
 
     try {
       await fs.rm(path.join(this.dataDir, "runs", runId), { recursive: true, force: true });
@@ -1009,6 +1399,45 @@ export class Runtime {
     }
   }
 
+  /**
+   * Cancels one specific turn, leaving the session alive for further
+   * messages. Rejects when the turn is no longer active, so a late cancel
+   * cannot interrupt whatever started next.
+   */
+  async cancelTurn(
+    runId: UUID,
+    nodeId: UUID,
+    turnId: UUID,
+    cancellation?: { reason?: string; actor?: string }
+  ): Promise<void> {
+    const record = this.requireRun(runId);
+    this.requireNode(record, nodeId);
+
+    if (!this.runner.interruptNode || !this.runner.activeTurnId) {
+      throw new Error("Runner does not support cancelling turns");
+    }
+
+    const activeTurnId = this.runner.activeTurnId(nodeId);
+    if (activeTurnId !== turnId) {
+      throw new Error(`Turn ${turnId} is not active on node ${nodeId}`);
+    }
+
+    this.logger.info("cancelling turn", {
+      runId,
+      nodeId,
+      turnId,
+      reason: cancellation?.reason,
+      actor: cancellation?.actor
+    });
+    try {
+      await this.runner.interruptNode(nodeId);
+    } catch (error) {
+      const message = error instanceof Error ? error.message : String(error);
+      this.logger.error("failed to cancel turn", { runId, nodeId, turnId, message });
+      throw error;
+    }
+  }
+
   async deleteNode(runId: UUID, nodeId: UUID): Promise<void> {
     const record = this.requireRun(runId);
     const now = nowIso();
@@ -1083,6 +1512,223 @@ export class Runtime {
     return { artifact, content };
   }
 
+  async checkReadiness(): Promise<GetReadinessResponse> {
+    const checks: ReadinessCheck[] = [];
+
+    checks.push(await this.checkDataDirWritable());
+    checks.push(await this.checkGitAvailable());
+
+    const resolver = new ProviderResolver({ appRoot: this.appRoot, logger: this.logger });
+    const providers: Array<"codex" | "claude" | "gemini"> = ["codex", "claude", "gemini"];
+    for (const provider of providers) {
+      const spec = resolver.resolve(provider);
+      if (!spec) {
+        // Not configuring a provider is a deployment choice, not a fault;
+        // it must not hold /readyz at 503 and pull the daemon from service.
+        checks.push({ name: `provider:${provider}`, ok: true, detail: "not configured (skipped)" });
+        continue;
+      }
+      if (spec.transport === "api") {
+        checks.push(await this.probeApiProvider(provider, spec));
+        continue;
+      }
+      const command = spec.command ?? provider;
+      const available = await this.isCommandAvailable(command);
+      if (available) {
+        checks.push({ name: `provider:${provider}`, ok: true, detail: `cli (${command})` });
+        continue;
+      }
+      // Only fail on a missing binary the operator explicitly asked for;
+      // the implicit PATH fallback for an unconfigured provider is skipped.
+      const explicit = Boolean(process.env[`VUHLP_${provider.toUpperCase()}_COMMAND`]?.trim());
+      checks.push({
+        name: `provider:${provider}`,
+        ok: !explicit,
+        detail: explicit
+          ? `cli command not found: ${command}`
+          : `cli command not found: ${command} (not configured; skipped)`
+      });
+    }
+
+    const ready = checks.every((check) => check.ok);
+    if (!ready) {
+      this.logger.warn("readiness check failed", {
+        failing: checks.filter((check) => !check.ok).map((check) => check.name)
+      });
+    }
+    return { ready, checks };
+  }
+
+  private async checkDataDirWritable(): Promise<ReadinessCheck> {
+    const probePath = path.join(this.dataDir, `.readyz-${newId()}`);
+    try {
+      await fs.mkdir(this.dataDir, { recursive: true });
+      await fs.writeFile(probePath, "ok", "utf8");
+      await fs.rm(probePath, { force: true });
+      return { name: "dataDir", ok: true, detail: this.dataDir };
+    } catch (error) {
+      const message = error instanceof Error ? error.message : String(error);
+      return { name: "dataDir", ok: false, detail: message };
+    }
+  }
+
+  private async checkGitAvailable(): Promise<ReadinessCheck> {
+    try {
+      const result = await exec("git --version", { timeout: 5000 });
+      return { name: "git", ok: true, detail: result.stdout.trim() };
+    } catch (error) {
+      const message = error instanceof Error ? error.message : String(error);
+      return { name: "git", ok: false, detail: message };
+    }
+  }
+
+  /**
+   * Confirms an API-transport provider actually answers a model list call
+   * with the configured credentials, bounded by READINESS_PROBE_TIMEOUT_MS.
+   */
+  private async probeApiProvider(
+    provider: "codex" | "claude" | "gemini",
+    spec: ProviderSpec
+  ): Promise<ReadinessCheck> {
+    const name = `provider:${provider}`;
+    const probe = this.modelListProbe(provider, spec);
+    const controller = new AbortController();
+    const timer = setTimeout(() => controller.abort(), READINESS_PROBE_TIMEOUT_MS);
+    try {
+      const response = await fetch(probe.url, { headers: probe.headers, signal: controller.signal });
+      if (!response.ok) {
+        return { name, ok: false, detail: `api model list returned ${response.status}` };
+      }
+      return { name, ok: true, detail: `api (${spec.model})` };
+    } catch (error) {
+      const detail =
+        error instanceof Error && error.name === "AbortError"
+          ? `api model list timed out after ${READINESS_PROBE_TIMEOUT_MS}ms`
+          : error instanceof Error
+            ? error.message
+            : String(error);
+      return { name, ok: false, detail };
+    } finally {
+      clearTimeout(timer);
+    }
+  }
+
+  /** Model-list endpoint and auth headers per provider API; keys stay out of URLs. */
+  private modelListProbe(
+    provider: "codex" | "claude" | "gemini",
+    spec: ProviderSpec
+  ): { url: string; headers: Record<string, string> } {
+    const base = (fallback: string): string => (spec.apiBaseUrl ?? fallback).replace(/\/+$/, "");
+    const apiKey = spec.apiKey ?? "";
+    switch (provider) {
+      case "claude":
+        return {
+          url: `${base("https://api.anthropic.com")}/v1/models`,
+          headers: { "x-api-key": apiKey, "anthropic-version": "2023-06-01" }
+        };
+      case "gemini":
+        return {
+          url: `${base("https://generativelanguage.googleapis.com/v1beta")}/models`,
+          headers: { "x-goog-api-key": apiKey }
+        };
+      default:
+        return {
+          url: `${base("https://api.openai.com/v1")}/models`,
+          headers: { Authorization: `Bearer ${apiKey}` }
+        };
+    }
+  }
+
+  private async isCommandAvailable(command: string): Promise<boolean> {
+    if (path.isAbsolute(command)) {
+      try {
+        const stat = await fs.stat(command);
+        return stat.isFile();
+      } catch {
+        return false;
+      }
+    }
+    try {
+      const probe = process.platform === "win32" ? `where ${command}` : `command -v ${command}`;
+      await exec(probe, { timeout: 5000 });
+      return true;
+    } catch {
+      return false;
+    }
+  }
+
+  async listCheckpoints(runId: UUID): Promise<ListCheckpointsResponse> {
+    this.requireRun(runId);
+    const store = new CheckpointStore(this.dataDir, runId, this.logger);
+    const checkpoints = await store.list();
+    return { runId, checkpoints };
+  }
+
+  async getFileAtTurn(runId: UUID, filePath: string, turn: number): Promise<GetFileAtTurnResponse> {
+    const record = this.requireRun(runId);
+    const cwd = record.state.cwd;
+    if (!cwd) {
+      throw new Error(`Run ${runId} has no workspace cwd`);
+    }
+    if (!Number.isInteger(turn) || turn <= 0) {
+      throw new Error("turn must be a positive integer");
+    }
+    const store = new CheckpointStore(this.dataDir, runId, this.logger);
+    const content = await store.readFileAtTurn(cwd, turn, filePath);
+    return { runId, path: filePath, turn, content };
+  }
+
+  async getRunPatch(runId: UUID): Promise<{ filename: string; content: string }> {
+    const entries = await this.collectDiffArtifacts(runId);
+    const content = entries.map((entry) => entry.content.trimEnd()).join("\n\n");
+    return {
+      filename: `run-${runId}.patch`,
+      content: content.length > 0 ? `${content}\n` : ""
+    };
+  }
+
+  async getRunPatchSeries(runId: UUID): Promise<GetRunPatchSeriesResponse> {
+    const entries = await this.collectDiffArtifacts(runId);
+    const total = entries.length;
+    const patches: RunPatchSeriesEntry[] = entries.map((entry, index) => {
+      const sequence = index + 1;
+      const subject = entry.artifact.metadata?.summary ?? entry.artifact.name;
+      const nodeLabel = this.store.getRun(runId)?.state.nodes[entry.artifact.nodeId]?.label ?? "unknown";
+      const header = [
+        `From ${entry.artifact.id.replace(/-/g, "")} Mon Sep 17 00:00:00 2001`,
+        `From: vuhlp <${nodeLabel}@vuhlp.local>`,
+        `Date: ${new Date(entry.artifact.createdAt).toUTCString()}`,
+        `Subject: [PATCH ${sequence}/${total}] ${subject}`,
+        "",
+        "---"
+      ].join("\n");
+      return {
+        sequence,
+        name: `${String(sequence).padStart(4, "0")}-${entry.artifact.id}.patch`,
+        content: `${header}\n${entry.content.trimEnd()}\n-- \nvuhlp\n`
+      };
+    });
+    return { runId, patches };
+  }
+
+  private async collectDiffArtifacts(runId: UUID): Promise<Array<{ artifact: Artifact; content: string }>> {
+    const record = this.requireRun(runId);
+    const diffs = Array.from(record.artifacts.values())
+      .filter((artifact) => artifact.kind === "diff")
+      .sort((a, b) => a.createdAt.localeCompare(b.createdAt));
+    const entries: Array<{ artifact: Artifact; content: string }> = [];
+    for (const artifact of diffs) {
+      try {
+        const content = await fs.readFile(artifact.path, "utf8");
+        entries.push({ artifact, content });
+      } catch (error) {
+        const message = error instanceof Error ? error.message : String(error);
+        this.logger.warn("skipping unreadable diff artifact", { runId, artifactId: artifact.id, message });
+      }
+    }
+    return entries;
+  }
+
   async listDirectory(dirPath?: string): Promise<ListDirectoryResponse> {
     const target = dirPath ? path.resolve(dirPath) : process.cwd();
     const entries = await fs.readdir(target, { withFileTypes: true });
@@ -1111,16 +1757,37 @@ export class Runtime {
   createRun({
     mode = "AUTO",
     globalMode = "IMPLEMENTATION",
-    cwd
-  }: { mode?: OrchestrationMode; globalMode?: GlobalMode; cwd?: string }): RunState {
+    cwd,
+    envSet,
+    sandboxImage,
+    readOnly,
+    budget
+  }: {
+    mode?: OrchestrationMode;
+    globalMode?: GlobalMode;
+    cwd?: string;
+    envSet?: string;
+    sandboxImage?: string;
+    readOnly?: boolean;
+    budget?: RunBudget;
+  }): RunState {
+    if (this.haltedAt !== null) {
+      throw new Error("daemon is halted; lift the kill switch with POST /api/admin/resume");
+    }
     const now = nowIso();
+    const workspace = path.resolve(cwd ?? this.repoRoot);
+    const status: RunStatus = this.hasRunCapacity(workspace) ? "running" : "queued";
     const runState: RunState = {
       id: newId(),
       contractVersion: "1",
-      status: "running",
+      status,
       mode,
       globalMode,
       cwd: cwd ?? this.repoRoot,
+      envSet,
+      sandboxImage: sandboxImage ?? defaultSandboxImage(),
+      readOnly: readOnly === true ? true : undefined,
+      budget: this.normalizeBudget(budget),
       createdAt: now,
       updatedAt: now,
       usage: { promptTokens: 0, completionTokens: 0, totalTokens: 0 },
@@ -1152,61 +1819,1166 @@ export class Runtime {
       globalMode: runState.globalMode
     });
     void this.saveRunSnapshot(runState.id);
+    if (status === "queued") {
+      this.logger.info("run queued; workspace or global concurrency limit reached", {
+        runId: runState.id,
+        workspace
+      });
+      this.refreshQueuePositions();
+    } else {
+      void this.captureWorkspaceSnapshot(runState.id, "start");
+    }
     return runState;
   }
 
-  createNode(runId: UUID, config: NodeConfigInput): NodeState {
+  /** True when starting another run now stays inside the global and per-workspace caps. */
+  private hasRunCapacity(workspace: string): boolean {
+    let active = 0;
+    let workspaceActive = 0;
+    for (const record of this.store.listRunRecords()) {
+      const status = record.state.status;
+      if (status !== "running" && status !== "paused" && status !== "budget_exceeded") {
+        continue;
+      }
+      active += 1;
+      if (path.resolve(record.state.cwd ?? this.repoRoot) === workspace) {
+        workspaceActive += 1;
+      }
+    }
+    const globalCap = maxActiveRuns();
+    if (globalCap > 0 && active >= globalCap) {
+      return false;
+    }
+    return workspaceActive < maxRunsPerWorkspace();
+  }
+
+  /**
+   * Starts queued runs in FIFO order wherever capacity has opened up,
+   * then refreshes the queue positions of whatever is still waiting.
+   * Called whenever an active run frees its slot.
+   */
+  private processRunQueue(): void {
+    if (this.haltedAt !== null) {
+      return;
+    }
+    const queued = this.store
+      .listRunRecords()
+      .filter((record) => record.state.status === "queued")
+      .sort((a, b) => a.state.createdAt.localeCompare(b.state.createdAt));
+    for (const record of queued) {
+      const workspace = path.resolve(record.state.cwd ?? this.repoRoot);
+      if (!this.hasRunCapacity(workspace)) {
+        continue;
+      }
+      const now = nowIso();
+      record.state.status = "running";
+      record.state.queuePosition = undefined;
+      record.state.updatedAt = now;
+      this.logger.info("run dequeued", { runId: record.state.id, workspace });
+      this.emitEvent(record.state.id, {
+        id: newId(),
+        runId: record.state.id,
+        ts: now,
+        type: "run.patch",
+        patch: { status: "running", updatedAt: now }
+      });
+      void this.saveRunSnapshot(record.state.id);
+      void this.captureWorkspaceSnapshot(record.state.id, "start");
+    }
+    this.refreshQueuePositions();
+  }
+
+  /** Recomputes 1-based queue positions (FIFO by creation) and patches runs whose place moved. */
+  private refreshQueuePositions(): void {
+    const queued = this.store
+      .listRunRecords()
+      .filter((record) => record.state.status === "queued")
+      .sort((a, b) => a.state.createdAt.localeCompare(b.state.createdAt));
+    queued.forEach((record, index) => {
+      const position = index + 1;
+      if (record.state.queuePosition === position) {
+        return;
+      }
+      const now = nowIso();
+      record.state.queuePosition = position;
+      record.state.updatedAt = now;
+      this.emitEvent(record.state.id, {
+        id: newId(),
+        runId: record.state.id,
+        ts: now,
+        type: "run.patch",
+        patch: { queuePosition: position, updatedAt: now }
+      });
+    });
+  }
+
+  /**
+   * Moves the run into a copy-on-write git worktree under the data dir,
+   * on its own branch (vuhlp/run-<id>). The original tree is never
+   * touched: successful changes live on the branch to merge back or
+   * export as a patch, and a failed run is discarded with its worktree.
+   */
+  async isolateWorkspace(runId: UUID): Promise<RunState> {
     const record = this.requireRun(runId);
-    const now = nowIso();
-    const normalized = this.normalizeNodeConfig(config);
-    const nodeId = normalized.id ?? newId();
-    if (normalized.alias) {
-      this.assertAliasAvailable(record, normalized.alias, nodeId);
+    const base = record.state.cwd ?? this.repoRoot;
+    const short = runId.slice(0, 8);
+    const branch = `vuhlp/run-${short}`;
+    const worktreePath = path.resolve(this.dataDir, "worktrees", short);
+    try {
+      await exec(`git -C "${base}" worktree add -b "${branch}" "${worktreePath}"`, {
+        timeout: 60_000,
+        maxBuffer: 10 * 1024 * 1024
+      });
+    } catch (error) {
+      const message = error instanceof Error ? error.message : String(error);
+      this.logger.error("failed to create isolation worktree", { runId, base, message });
+      throw new Error(`workspace isolation failed: ${message}`);
     }
-    const nodeState: NodeState = {
-      id: nodeId,
-      runId,
-      label: normalized.label,
-      alias: normalized.alias,
-      roleTemplate: normalized.roleTemplate,
-      customSystemPrompt: normalized.customSystemPrompt ?? null,
-      provider: normalized.provider,
-      status: "idle",
-      summary: "idle",
-      lastActivityAt: now,
-      usage: { promptTokens: 0, completionTokens: 0, totalTokens: 0 },
-      capabilities: normalized.capabilities,
-      permissions: normalized.permissions,
-      session: {
-        sessionId: "pending",
-        resetCommands: normalized.session.resetCommands
-      },
-      connection: {
-        status: "idle",
-        streaming: false,
-        lastHeartbeatAt: now,
-        lastOutputAt: now
-      },
-      inboxCount: 0
-    };
-    this.store.addNode(runId, nodeState, normalized);
+    const now = nowIso();
+    record.state.cwd = worktreePath;
+    record.state.worktree = { path: worktreePath, branch, base };
     this.touchRun(record, now);
     this.emitEvent(runId, {
       id: newId(),
       runId,
       ts: now,
-      type: "node.patch",
-      nodeId: nodeState.id,
-      patch: nodeState
+      type: "run.patch",
+      patch: { cwd: worktreePath, worktree: record.state.worktree, updatedAt: now }
     });
+    this.logger.info("run isolated in worktree", { runId, worktreePath, branch });
+    void this.captureWorkspaceSnapshot(runId, "start");
+    return record.state;
+  }
+
+  /**
+   * Switches the workspace onto a fresh `run/<id>` branch and turns on
+   * per-turn commits: every turn that changes files lands as one commit,
+   * so the run's whole history is the branch's `base..head` range —
+   * reviewable and revertable with plain git. Composes with
+   * `isolateWorkspace`; the branch is then created inside the worktree.
+   */
+  async enableAutoCommit(runId: UUID): Promise<RunState> {
+    const record = this.requireRun(runId);
+    if (record.state.autoCommit) {
+      return record.state;
+    }
+    const cwd = record.state.cwd ?? this.repoRoot;
+    const branch = `run/${runId.slice(0, 8)}`;
+    let base: string;
+    try {
+      const { stdout } = await exec(`git -C "${cwd}" rev-parse HEAD`, { timeout: 10_000 });
+      base = stdout.trim();
+      await exec(`git -C "${cwd}" checkout -b "${branch}"`, { timeout: 30_000 });
+    } catch (error) {
+      const message = error instanceof Error ? error.message : String(error);
+      this.logger.error("failed to create auto-commit branch", { runId, cwd, branch, message });
+      throw new Error(`auto-commit setup failed: ${message}`);
+    }
+    const now = nowIso();
+    record.state.autoCommit = { branch, base, count: 0 };
+    this.touchRun(record, now);
     this.emitEvent(runId, {
       id: newId(),
       runId,
       ts: now,
       type: "run.patch",
-      patch: {
-        nodeConfigs: { [nodeState.id]: normalized },
-        updatedAt: now
+      patch: { autoCommit: record.state.autoCommit, updatedAt: now }
+    });
+    this.logger.info("auto-commit enabled", { runId, cwd, branch, base });
+    void this.saveRunSnapshot(runId);
+    return record.state;
+  }
+
+  /**
+   * Records the workspace git commit (and a hash of the dirty state) on the
+   * run, so exports and run comparisons are anchored to exact code states.
+   * Best-effort: non-git workspaces simply get no snapshot.
+   */
+  private async captureWorkspaceSnapshot(runId: UUID, phase: "start" | "end"): Promise<void> {
+    const record = this.store.getRun(runId);
+    if (!record) {
+      return;
+    }
+    const cwd = record.state.cwd ?? this.repoRoot;
+    let snapshot: WorkspaceSnapshot;
+    try {
+      const { stdout: commitOut } = await exec("git rev-parse HEAD", { cwd, timeout: 5000 });
+      const { stdout: statusOut } = await exec("git status --porcelain", { cwd, timeout: 10000 });
+      const dirty = statusOut.trim();
+      snapshot = {
+        commit: commitOut.trim(),
+        dirtyHash: dirty ? hashString(dirty) : undefined,
+        capturedAt: nowIso()
+      };
+    } catch (error) {
+      const message = error instanceof Error ? error.message : String(error);
+      this.logger.warn("workspace snapshot capture failed", { runId, phase, cwd, message });
+      return;
+    }
+    const current = this.store.getRun(runId);
+    if (!current) {
+      return;
+    }
+    const patch: Partial<RunState> =
+      phase === "start" ? { workspaceStart: snapshot } : { workspaceEnd: snapshot };
+    current.state = { ...current.state, ...patch };
+    this.logger.info("workspace snapshot captured", {
+      runId,
+      phase,
+      commit: snapshot.commit,
+      dirty: Boolean(snapshot.dirtyHash)
+    });
+    this.emitEvent(runId, {
+      id: newId(),
+      runId,
+      ts: snapshot.capturedAt,
+      type: "run.patch",
+      patch
+    });
+    void this.saveRunSnapshot(runId);
+  }
+
+  /**
+   * Asks the summary model to wrap up a just-finished run: what changed,
+   * what was verified, what's left. Best-effort and fire-and-forget; the
+   * result lands on the run as `summary` plus a `run.summary` event.
+   */
+  private async generateRunSummary(runId: UUID): Promise<void> {
+    if (!this.runSummarizer.enabled) {
+      return;
+    }
+    const record = this.store.getRun(runId);
+    if (!record) {
+      return;
+    }
+    const summary = await this.runSummarizer.summarize({
+      status: record.state.status,
+      report: this.buildRunReport(record.state)
+    });
+    if (!summary) {
+      return;
+    }
+    const current = this.store.getRun(runId);
+    if (!current) {
+      return;
+    }
+    current.state.summary = summary;
+    current.state.updatedAt = summary.generatedAt;
+    this.emitEvent(runId, {
+      id: newId(),
+      runId,
+      ts: summary.generatedAt,
+      type: "run.patch",
+      patch: { summary, updatedAt: summary.generatedAt }
+    });
+    this.emitEvent(runId, {
+      id: newId(),
+      runId,
+      ts: summary.generatedAt,
+      type: "run.summary",
+      summary
+    });
+    this.logger.info("run summary generated", { runId, model: summary.model });
+    void this.saveRunSnapshot(runId);
+  }
+
+  /** Plain-text report of the run's final state, fed to the summary model. */
+  private buildRunReport(state: RunState): string {
+    const lines: string[] = [];
+    const minutes = Math.round((Date.parse(state.updatedAt) - Date.parse(state.createdAt)) / 60_000);
+    lines.push(`Duration: ${minutes} minutes`);
+    if (state.cancellation) {
+      lines.push(
+        `Stopped by ${state.cancellation.actor ?? state.cancellation.source}` +
+          `${state.cancellation.reason ? `: ${state.cancellation.reason}` : ""}`
+      );
+    }
+    if (state.usage) {
+      lines.push(
+        `Tokens: ${state.usage.totalTokens}` +
+          `${state.costUsd !== undefined ? ` (estimated $${state.costUsd.toFixed(2)})` : ""}`
+      );
+    }
+    if (state.autoCommit?.head) {
+      lines.push(
+        `Commits: ${state.autoCommit.count} on ${state.autoCommit.branch} ` +
+          `(${state.autoCommit.base.slice(0, 8)}..${state.autoCommit.head.slice(0, 8)})`
+      );
+    }
+    lines.push("", "Agents:");
+    for (const node of Object.values(state.nodes)) {
+      lines.push(`- ${node.label} (${node.roleTemplate}): ${node.summary}`);
+    }
+    if (state.plan) {
+      lines.push("", `Plan ${state.plan.status}${state.plan.goal ? ` (goal: ${state.plan.goal})` : ""}:`);
+      for (const step of state.plan.steps) {
+        lines.push(`- [${step.status}] ${step.name}${step.error ? `: ${step.error}` : ""}`);
+      }
+    }
+    if (state.loop) {
+      lines.push(
+        "",
+        `Spec loop "${state.loop.specName}" ${state.loop.status} after ` +
+          `${state.loop.iteration} of ${state.loop.maxIterations} iterations` +
+          `${state.loop.error ? `: ${state.loop.error}` : ""}`
+      );
+    }
+    return lines.join("\n");
+  }
+
+  /**
+   * Clones a run's graph into a fresh run against the same workspace, for
+   * re-executing after a spec or environment fix. Node and edge configs are
+   * copied; messages, artifacts, and session state are not.
+   */
+  rerunRun(runId: UUID, options: { globalMode?: GlobalMode } = {}): RunState {
+    const source = this.requireRun(runId).state;
+    const run = this.createRun({
+      mode: source.mode,
+      globalMode: options.globalMode ?? source.globalMode,
+      cwd: source.cwd
+    });
+    const record = this.requireRun(run.id);
+    record.state.parentRunId = runId;
+    const now = nowIso();
+    this.emitEvent(run.id, {
+      id: newId(),
+      runId: run.id,
+      ts: now,
+      type: "run.patch",
+      patch: { parentRunId: runId }
+    });
+    const idMap = new Map<UUID, UUID>();
+    for (const [nodeId, config] of Object.entries(source.nodeConfigs)) {
+      const node = this.createNode(run.id, { ...config, id: undefined });
+      idMap.set(nodeId, node.id);
+    }
+    for (const edge of Object.values(source.edges)) {
+      const from = idMap.get(edge.from);
+      const to = idMap.get(edge.to);
+      if (!from || !to) {
+        this.logger.warn("skipping edge with unmapped endpoint during rerun", {
+          runId,
+          edgeId: edge.id
+        });
+        continue;
+      }
+      this.createEdge(run.id, {
+        from,
+        to,
+        bidirectional: edge.bidirectional,
+        type: edge.type,
+        label: edge.label
+      });
+    }
+    this.logger.info("run cloned for rerun", {
+      parentRunId: runId,
+      runId: run.id,
+      nodes: idMap.size,
+      edges: Object.keys(source.edges).length
+    });
+    void this.saveRunSnapshot(run.id);
+    return record.state;
+  }
+
+  /**
+   * Creates one run per spec markdown file in a directory, grouped under a
+   * batch id so the set can be monitored and stopped as a unit. Specs can
+   * be filtered on a frontmatter field (e.g. "status=approved"); each run
+   * gets an implementer node seeded with the spec content.
+   */
+  async createRunBatch(input: {
+    specsDir: string;
+    filter?: string;
+    mode?: OrchestrationMode;
+    globalMode?: GlobalMode;
+    cwd?: string;
+    provider?: ProviderName;
+  }): Promise<{ batchId: UUID; runs: RunState[]; skipped: string[] }> {
+    const specsDir = path.resolve(input.specsDir);
+    const entries = await fs.readdir(specsDir, { withFileTypes: true });
+    const specFiles = entries
+      .filter((entry) => entry.isFile() && entry.name.toLowerCase().endsWith(".md"))
+      .map((entry) => entry.name)
+      .sort();
+    if (specFiles.length === 0) {
+      throw new Error(`no spec markdown files found in ${specsDir}`);
+    }
+    let filter: { key: string; value: string } | undefined;
+    if (input.filter) {
+      const [key, value] = input.filter.split("=").map((part) => part.trim());
+      if (!key || !value) {
+        throw new Error(`invalid filter "${input.filter}"; expected key=value`);
+      }
+      filter = { key, value };
+    }
+    const batchId = newId();
+    const runs: RunState[] = [];
+    const skipped: string[] = [];
+    for (const name of specFiles) {
+      const specPath = path.join(specsDir, name);
+      const content = await fs.readFile(specPath, "utf8");
+      if (filter && this.specField(content, filter.key) !== filter.value) {
+        skipped.push(name);
+        continue;
+      }
+      const run = this.createRun({ mode: input.mode, globalMode: input.globalMode, cwd: input.cwd });
+      const record = this.requireRun(run.id);
+      record.state.batchId = batchId;
+      this.emitEvent(run.id, {
+        id: newId(),
+        runId: run.id,
+        ts: nowIso(),
+        type: "run.patch",
+        patch: { batchId }
+      });
+      const node = this.createNode(run.id, {
+        label: name.replace(/\.md$/i, ""),
+        provider: input.provider ?? "claude",
+        roleTemplate: "implementer"
+      });
+      this.postMessage(run.id, node.id, `Spec (${name}):\n\n${content}`);
+      void this.saveRunSnapshot(run.id);
+      runs.push(record.state);
+    }
+    this.logger.info("run batch created", {
+      batchId,
+      specsDir,
+      runs: runs.length,
+      skipped: skipped.length
+    });
+    return { batchId, runs, skipped };
+  }
+
+  /**
+   * Launches N runs of the same spec with different providers (best-of-N),
+   * grouped under a batch id. Each variant gets an isolated git worktree
+   * under dataDir/worktrees when the base workspace is a git checkout, so
+   * the variants cannot trample each other's edits.
+   */
+  async createVariantRuns(input: {
+    spec: string;
+    variants: Array<{ provider: ProviderName; label?: string }>;
+    mode?: OrchestrationMode;
+    globalMode?: GlobalMode;
+    cwd?: string;
+  }): Promise<{ batchId: UUID; runs: RunState[] }> {
+    if (!input.spec || input.spec.trim().length === 0) {
+      throw new Error("spec is required");
+    }
+    if (!Array.isArray(input.variants) || input.variants.length < 2) {
+      throw new Error("at least two variants are required");
+    }
+    const baseCwd = path.resolve(input.cwd ?? this.repoRoot);
+    const batchId = newId();
+    const runs: RunState[] = [];
+    for (const variant of input.variants) {
+      const run = this.createRun({ mode: input.mode, globalMode: input.globalMode, cwd: baseCwd });
+      const record = this.requireRun(run.id);
+      record.state.batchId = batchId;
+      const worktree = await this.createVariantWorktree(baseCwd, run.id);
+      if (worktree) {
+        record.state.cwd = worktree;
+      }
+      this.emitEvent(run.id, {
+        id: newId(),
+        runId: run.id,
+        ts: nowIso(),
+        type: "run.patch",
+        patch: { batchId, cwd: record.state.cwd }
+      });
+      const node = this.createNode(run.id, {
+        label: variant.label ?? variant.provider,
+        provider: variant.provider,
+        roleTemplate: "implementer"
+      });
+      this.postMessage(run.id, node.id, input.spec);
+      void this.saveRunSnapshot(run.id);
+      runs.push(record.state);
+    }
+    this.logger.info("variant runs created", { batchId, variants: runs.length, baseCwd });
+    return { batchId, runs };
+  }
+
+  /** Adds a detached git worktree for the variant; null when not a git checkout. */
+  private async createVariantWorktree(baseCwd: string, runId: UUID): Promise<string | null> {
+    const worktreePath = path.join(this.dataDir, "worktrees", runId);
+    try {
+      await exec(`git -C "${baseCwd}" worktree add --detach "${worktreePath}"`);
+      this.logger.info("variant worktree created", { runId, worktreePath });
+      return worktreePath;
+    } catch (error) {
+      const message = error instanceof Error ? error.message : String(error);
+      this.logger.warn("variant worktree unavailable; sharing base workspace", { runId, message });
+      return null;
+    }
+  }
+
+  /**
+   * Scores the variants in a batch: run status plus the model-judge
+   * evaluation (turn evaluator) when enabled. The highest score wins and
+   * its patch series is ready to merge.
+   */
+  compareBatch(batchId: UUID): CompareBatchResponse {
+    const runs = this.listBatchRuns(batchId);
+    if (runs.length === 0) {
+      throw new Error(`Batch ${batchId} not found`);
+    }
+    const variants: VariantComparisonEntry[] = runs.map((run) => {
+      const record = this.requireRun(run.id);
+      const node = Object.values(run.nodes)[0];
+      const diffArtifacts = Array.from(record.artifacts.values())
+        .filter((artifact) => artifact.kind === "diff")
+        .sort((a, b) => a.createdAt.localeCompare(b.createdAt));
+      const filesChanged = [
+        ...new Set(diffArtifacts.flatMap((artifact) => artifact.metadata?.filesChanged ?? []))
+      ];
+      const evaluation = node?.lastEvaluation;
+      let score = run.status === "completed" ? 2 : run.status === "failed" ? 0 : 1;
+      if (evaluation) {
+        score += evaluation.progress;
+        if (evaluation.risk === "high") {
+          score -= 0.5;
+        }
+      }
+      return {
+        runId: run.id,
+        label: node?.label ?? run.id,
+        provider: node?.provider ?? "custom",
+        status: run.status,
+        usage: run.usage,
+        filesChanged,
+        diffArtifacts: diffArtifacts.map((artifact) => artifact.id),
+        evaluation,
+        score
+      };
+    });
+    variants.sort((a, b) => b.score - a.score);
+    const winner = variants[0];
+    return {
+      batchId,
+      variants,
+      winnerRunId: winner && winner.score > 0 ? winner.runId : undefined
+    };
+  }
+
+  listBatchRuns(batchId: UUID): RunState[] {
+    return this.listRuns().filter((run) => run.batchId === batchId);
+  }
+
+  stopBatch(batchId: UUID): UUID[] {
+    const stopped: UUID[] = [];
+    for (const run of this.listBatchRuns(batchId)) {
+      if (run.status === "running" || run.status === "paused" || run.status === "queued") {
+        this.updateRun(run.id, { status: "stopped" }, { reason: `batch ${batchId} stopped` });
+        stopped.push(run.id);
+      }
+    }
+    this.logger.info("run batch stopped", { batchId, stopped: stopped.length });
+    return stopped;
+  }
+
+  /**
+   * Aggregates token usage, per-provider cost estimates, and tool-call
+   * counts from the run's event log. Pass a nodeId to scope the report to
+   * one node. Prices come from VUHLP_PRICE_<PROVIDER> env vars
+   * ("promptUsdPerMTok:completionUsdPerMTok"); providers without a price
+   * are reported without a cost.
+   */
+  async getUsage(runId: UUID, nodeId?: UUID): Promise<GetUsageResponse> {
+    const record = this.requireRun(runId);
+    if (nodeId) {
+      this.requireNode(record, nodeId);
+    }
+    const byProvider = new Map<ProviderName, { models: Set<string>; usage: UsageTotals }>();
+    await record.eventLog.replay((event) => {
+      if (event.type === "telemetry.usage") {
+        if (nodeId && event.nodeId !== nodeId) {
+          return;
+        }
+        let entry = byProvider.get(event.provider);
+        if (!entry) {
+          entry = { models: new Set(), usage: { promptTokens: 0, completionTokens: 0, totalTokens: 0 } };
+          byProvider.set(event.provider, entry);
+        }
+        entry.models.add(event.model);
+        entry.usage = addUsage(entry.usage, event.usage);
+      }
+    });
+    const byTool = new Map<string, ToolUsageStats>();
+    const totalToolCalls = await this.collectToolStats(record, byTool, nodeId);
+    const providers: ProviderUsageBreakdown[] = [];
+    let usage: UsageTotals = { promptTokens: 0, completionTokens: 0, totalTokens: 0 };
+    let totalCost: number | undefined;
+    for (const [provider, entry] of byProvider) {
+      usage = addUsage(usage, entry.usage);
+      const price = this.providerPrice(provider);
+      let costUsd: number | undefined;
+      if (price) {
+        costUsd =
+          (entry.usage.promptTokens * price.promptUsdPerMTok +
+            entry.usage.completionTokens * price.completionUsdPerMTok) /
+          1_000_000;
+        totalCost = (totalCost ?? 0) + costUsd;
+      }
+      providers.push({
+        provider,
+        models: [...entry.models].sort(),
+        usage: entry.usage,
+        costUsd
+      });
+    }
+    providers.sort((a, b) => a.provider.localeCompare(b.provider));
+    return {
+      runId,
+      nodeId,
+      usage,
+      providers,
+      costUsd: totalCost,
+      toolCalls: {
+        total: totalToolCalls,
+        byTool: Object.fromEntries([...byTool.entries()].sort(([a], [b]) => a.localeCompare(b)))
+      }
+    };
+  }
+
+  /**
+   * Replays one run's event log into per-tool stats: call counts, failure
+   * counts, wall-clock duration (tool.started to tool.completed
+   * timestamps), and output bytes fed back to the model. Accumulates into
+   * the caller's map so cross-run aggregates can share it; returns the
+   * number of calls seen.
+   */
+  private async collectToolStats(
+    record: RunRecord,
+    into: Map<string, ToolUsageStats>,
+    nodeId?: UUID
+  ): Promise<number> {
+    const started = new Map<UUID, { name: string; ts: number }>();
+    let total = 0;
+    await record.eventLog.replay((event) => {
+      if (event.type === "tool.started") {
+        if (nodeId && event.nodeId !== nodeId) {
+          return;
+        }
+        total += 1;
+        started.set(event.tool.id, { name: event.tool.name, ts: Date.parse(event.ts) });
+        let stats = into.get(event.tool.name);
+        if (!stats) {
+          stats = { calls: 0, failures: 0, durationMs: 0, outputBytes: 0 };
+          into.set(event.tool.name, stats);
+        }
+        stats.calls += 1;
+        return;
+      }
+      if (event.type === "tool.completed") {
+        const open = started.get(event.toolId);
+        if (!open) {
+          return;
+        }
+        started.delete(event.toolId);
+        const stats = into.get(open.name);
+        if (!stats) {
+          return;
+        }
+        if (!event.result.ok) {
+          stats.failures += 1;
+        }
+        const completedAt = Date.parse(event.ts);
+        if (Number.isFinite(open.ts) && Number.isFinite(completedAt) && completedAt >= open.ts) {
+          stats.durationMs += completedAt - open.ts;
+        }
+        const output =
+          typeof event.result.output === "string"
+            ? event.result.output
+            : event.result.output !== undefined
+              ? JSON.stringify(event.result.output)
+              : "";
+        stats.outputBytes += Buffer.byteLength(output, "utf8");
+      }
+    });
+    return total;
+  }
+
+  /**
+   * Aggregates per-tool usage stats across every run's event log, for
+   * spotting which tools dominate turn latency daemon-wide.
+   */
+  async getToolStats(): Promise<GetToolStatsResponse> {
+    const tools = new Map<string, ToolUsageStats>();
+    const records = this.store.listRunRecords();
+    for (const record of records) {
+      await this.collectToolStats(record, tools);
+    }
+    this.logger.info("tool stats aggregated", { runs: records.length, tools: tools.size });
+    return {
+      runs: records.length,
+      tools: Object.fromEntries([...tools.entries()].sort(([a], [b]) => a.localeCompare(b)))
+    };
+  }
+
+  /** Parses VUHLP_PRICE_<PROVIDER>="promptUsdPerMTok:completionUsdPerMTok". */
+  private providerPrice(
+    provider: ProviderName
+  ): { promptUsdPerMTok: number; completionUsdPerMTok: number } | undefined {
+    const raw = process.env[`VUHLP_PRICE_${provider.toUpperCase()}`];
+    if (!raw) {
+      return undefined;
+    }
+    const [prompt, completion] = raw.split(":").map((part) => Number.parseFloat(part.trim()));
+    if (!Number.isFinite(prompt) || !Number.isFinite(completion) || prompt < 0 || completion < 0) {
+      this.logger.warn("invalid provider price; expected promptUsdPerMTok:completionUsdPerMTok", {
+        provider,
+        raw
+      });
+      return undefined;
+    }
+    return { promptUsdPerMTok: prompt, completionUsdPerMTok: completion };
+  }
+
+  /**
+   * Re-reads the .env file and applies hot-applicable settings without a
+   * restart. Most subsystems read env lazily per call and pick changes up
+   * automatically; the consensus planner and webhook manifest are
+   * re-initialized here. Listen address, TLS, and data dir still require
+   * a restart.
+   */
+  async reloadConfig(): Promise<{ envPath: string | null; changed: string[]; webhooks: number }> {
+    const { envPath, changed } = reloadEnv(this.logger);
+    this.consensusPlanner = new ConsensusPlanner(this.logger);
+    const webhooks = await this.webhooks.reload();
+    const plugins = await loadPlugins(this.logger);
+    this.logger.info("configuration reloaded", { envPath, changed, webhooks, plugins: plugins.length });
+    return { envPath, changed, webhooks };
+  }
+
+  /**
+   * Creates an onboarding run for a new repo: an "onboarder" node that
+   * explores with read tools and proposes AGENTS.md, verify commands, an
+   * approval-rules starter, and an architecture diagram source. Writes
+   * stay behind the approval flow (cliPermissionsMode "gated") so the
+   * whole bootstrap lands as one reviewed patch.
+   */
+  createBootstrapRun(input: { cwd?: string; provider?: ProviderName }): { run: RunState; nodeId: UUID } {
+    const run = this.createRun({ mode: "AUTO", globalMode: "IMPLEMENTATION", cwd: input.cwd });
+    const node = this.createNode(run.id, {
+      label: "onboard",
+      provider: input.provider ?? "claude",
+      roleTemplate: "onboarder",
+      permissions: { cliPermissionsMode: "gated" }
+    });
+    this.postMessage(
+      run.id,
+      node.id,
+      "Onboard this repository: explore it with read tools, then produce AGENTS.md, " +
+        "suggested verify commands, a .vuhlp/approval-rules.json starter, and " +
+        "docs/architecture.mmd in a single reviewed patch."
+    );
+    this.logger.info("bootstrap run created", { runId: run.id, nodeId: node.id, cwd: input.cwd });
+    return { run: this.getRun(run.id), nodeId: node.id };
+  }
+
+  /**
+   * Exports the run's conversation in another tool's transcript format
+   * (the inverse of importConversation). Pass a nodeId to export one
+   * node's conversation; otherwise all message events are included in
+   * log order.
+   */
+  async exportConversation(
+    runId: UUID,
+    format: ConversationExportFormat,
+    nodeId?: UUID
+  ): Promise<ConversationExport> {
+    const record = this.requireRun(runId);
+    if (nodeId) {
+      this.requireNode(record, nodeId);
+    }
+    const messages: ImportedMessage[] = [];
+    await record.eventLog.replay((event) => {
+      if (event.type === "message.user") {
+        if (nodeId && event.message.nodeId !== nodeId) {
+          return;
+        }
+        if (event.message.content.trim().length > 0) {
+          messages.push({ role: "user", content: event.message.content });
+        }
+        return;
+      }
+      if (event.type === "message.assistant.final") {
+        if (nodeId && event.nodeId !== nodeId) {
+          return;
+        }
+        if (event.content.trim().length > 0) {
+          messages.push({ role: "assistant", content: event.content });
+        }
+      }
+    });
+    if (messages.length === 0) {
+      throw new Error(`run ${runId} has no messages to export`);
+    }
+    this.logger.info("conversation exported", { runId, nodeId, format, messages: messages.length });
+    return renderConversation(format, runId, messages);
+  }
+
+  /**
+   * Run templates are parameterized run recipes stored under
+   * dataDir/run-templates/<name>.json. String fields may contain
+   * {{param}} placeholders filled in at instantiation time.
+   */
+  async listRunTemplates(): Promise<RunTemplate[]> {
+    const dir = this.runTemplatesDir();
+    const templates: RunTemplate[] = [];
+    try {
+      const entries = await fs.readdir(dir, { withFileTypes: true });
+      for (const entry of entries) {
+        if (!entry.isFile() || !entry.name.endsWith(".json")) {
+          continue;
+        }
+        try {
+          const contents = await fs.readFile(path.join(dir, entry.name), "utf8");
+          templates.push(JSON.parse(contents) as RunTemplate);
+        } catch (error) {
+          const message = error instanceof Error ? error.message : String(error);
+          this.logger.warn("failed to read run template", { file: entry.name, message });
+        }
+      }
+    } catch (error) {
+      const code = (error as NodeJS.ErrnoException).code;
+      if (code !== "ENOENT") {
+        throw error;
+      }
+    }
+    templates.sort((a, b) => a.name.localeCompare(b.name));
+    return templates;
+  }
+
+  async putRunTemplate(name: string, template: Omit<RunTemplate, "name">): Promise<RunTemplate> {
+    const templateName = this.validateName("run template", name);
+    if (!template.instructions || typeof template.instructions !== "string") {
+      throw new Error("template instructions are required");
+    }
+    const full: RunTemplate = { ...template, name: templateName };
+    const dir = this.runTemplatesDir();
+    await fs.mkdir(dir, { recursive: true });
+    const templatePath = path.join(dir, `${templateName}.json`);
+    await fs.writeFile(templatePath, JSON.stringify(full, null, 2), "utf8");
+    this.logger.info("run template saved", { name: templateName, path: templatePath });
+    return full;
+  }
+
+  async deleteRunTemplate(name: string): Promise<void> {
+    const templateName = this.validateName("run template", name);
+    const templatePath = path.join(this.runTemplatesDir(), `${templateName}.json`);
+    try {
+      await fs.unlink(templatePath);
+    } catch (error) {
+      const code = (error as NodeJS.ErrnoException).code;
+      if (code === "ENOENT") {
+        throw new Error(`run template not found: ${templateName}`);
+      }
+      throw error;
+    }
+    this.logger.info("run template deleted", { name: templateName });
+  }
+
+  async createRunFromTemplate(name: string, params?: Record<string, string>): Promise<RunState> {
+    const templateName = this.validateName("run template", name);
+    const templatePath = path.join(this.runTemplatesDir(), `${templateName}.json`);
+    let template: RunTemplate;
+    try {
+      const contents = await fs.readFile(templatePath, "utf8");
+      template = JSON.parse(contents) as RunTemplate;
+    } catch (error) {
+      const code = (error as NodeJS.ErrnoException).code;
+      if (code === "ENOENT") {
+        throw new Error(`run template not found: ${templateName}`);
+      }
+      throw error;
+    }
+    const values = params ?? {};
+    for (const param of template.params ?? []) {
+      if (!(param in values)) {
+        throw new Error(`template parameter "${param}" is required`);
+      }
+    }
+    const fill = (input: string): string => {
+      const rendered = input.replace(/\{\{([a-zA-Z0-9_-]+)\}\}/g, (match, key: string) =>
+        key in values ? values[key] : match
+      );
+      const unresolved = rendered.match(/\{\{[a-zA-Z0-9_-]+\}\}/);
+      if (unresolved) {
+        throw new Error(`unresolved template parameter ${unresolved[0]} in "${input}"`);
+      }
+      return rendered;
+    };
+    const run = this.createRun({
+      mode: template.mode,
+      globalMode: template.globalMode,
+      cwd: template.cwd ? fill(template.cwd) : undefined
+    });
+    const node = this.createNode(run.id, {
+      label: templateName,
+      provider: template.provider ?? "claude",
+      roleTemplate: template.roleTemplate ?? "implementer"
+    });
+    this.postMessage(run.id, node.id, fill(template.instructions));
+    this.logger.info("run created from template", { runId: run.id, template: templateName });
+    return this.getRun(run.id);
+  }
+
+  private runTemplatesDir(): string {
+    return path.join(this.dataDir, "run-templates");
+  }
+
+  /**
+   * Imports a transcript exported from another agent tool into a fresh
+   * run. Messages land in the event log as regular user/assistant
+   * messages so the node can continue the conversation under this
+   * daemon's approval policies; attachments are stubbed by the parser.
+   */
+  importConversation(input: {
+    format: ConversationImportFormat;
+    content: string;
+    label?: string;
+    provider?: ProviderName;
+    mode?: OrchestrationMode;
+    cwd?: string;
+  }): { run: RunState; nodeId: UUID; imported: number } {
+    const messages = parseConversation(input.format, input.content);
+    if (messages.length === 0) {
+      throw new Error(`no messages found in ${input.format} transcript`);
+    }
+    const run = this.createRun({ mode: input.mode ?? "INTERACTIVE", cwd: input.cwd });
+    const node = this.createNode(run.id, {
+      label: input.label ?? `imported-${input.format}`,
+      provider: input.provider ?? "claude",
+      roleTemplate: "implementer"
+    });
+    for (const message of messages) {
+      const now = nowIso();
+      if (message.role === "user") {
+        this.emitEvent(run.id, {
+          id: newId(),
+          runId: run.id,
+          ts: now,
+          type: "message.user",
+          message: {
+            id: newId(),
+            runId: run.id,
+            nodeId: node.id,
+            role: "user",
+            content: message.content,
+            createdAt: now
+          }
+        });
+      } else {
+        this.emitEvent(run.id, {
+          id: newId(),
+          runId: run.id,
+          ts: now,
+          type: "message.assistant.final",
+          nodeId: node.id,
+          content: message.content
+        });
+      }
+    }
+    this.logger.info("conversation imported", {
+      runId: run.id,
+      nodeId: node.id,
+      format: input.format,
+      messages: messages.length
+    });
+    void this.saveRunSnapshot(run.id);
+    return { run: this.getRun(run.id), nodeId: node.id, imported: messages.length };
+  }
+
+  async listSpecs(runId: UUID): Promise<SpecInfo[]> {
+    const specsDir = this.specsDirFor(runId);
+    const specs: SpecInfo[] = [];
+    try {
+      const entries = await fs.readdir(specsDir, { withFileTypes: true });
+      for (const entry of entries) {
+        if (!entry.isFile() || !entry.name.endsWith(".md")) {
+          continue;
+        }
+        const name = entry.name.replace(/\.md$/, "");
+        if (!/^[a-zA-Z0-9_-]+$/.test(name)) {
+          continue;
+        }
+        const specPath = path.join(specsDir, entry.name);
+        const stats = await fs.stat(specPath);
+        specs.push({ name, path: specPath, updatedAt: stats.mtime.toISOString() });
+      }
+    } catch (error) {
+      const code = (error as NodeJS.ErrnoException).code;
+      if (code !== "ENOENT") {
+        throw error;
+      }
+    }
+    specs.sort((a, b) => a.name.localeCompare(b.name));
+    return specs;
+  }
+
+  async getSpec(runId: UUID, name: string): Promise<{ name: string; content: string }> {
+    const specName = this.validateName("spec", name);
+    const specPath = path.join(this.specsDirFor(runId), `${specName}.md`);
+    try {
+      const content = await fs.readFile(specPath, "utf8");
+      return { name: specName, content };
+    } catch (error) {
+      const code = (error as NodeJS.ErrnoException).code;
+      if (code === "ENOENT") {
+        throw new Error(`spec not found: ${specName}`);
+      }
+      throw error;
+    }
+  }
+
+  async putSpec(runId: UUID, name: string, content: string): Promise<{ name: string; path: string; created: boolean }> {
+    const specName = this.validateName("spec", name);
+    if (typeof content !== "string") {
+      throw new Error("spec content is required");
+    }
+    const specsDir = this.specsDirFor(runId);
+    const specPath = path.join(specsDir, `${specName}.md`);
+    let created = false;
+    try {
+      await fs.access(specPath);
+    } catch (error) {
+      const code = (error as NodeJS.ErrnoException).code;
+      if (code !== "ENOENT") {
+        throw error;
+      }
+      created = true;
+    }
+    await fs.mkdir(specsDir, { recursive: true });
+    await fs.writeFile(specPath, content, "utf8");
+    this.logger.info(created ? "spec created" : "spec updated", { runId, name: specName, path: specPath });
+    return { name: specName, path: specPath, created };
+  }
+
+  async deleteSpec(runId: UUID, name: string): Promise<void> {
+    const specName = this.validateName("spec", name);
+    const specPath = path.join(this.specsDirFor(runId), `${specName}.md`);
+    try {
+      await fs.unlink(specPath);
+    } catch (error) {
+      const code = (error as NodeJS.ErrnoException).code;
+      if (code === "ENOENT") {
+        throw new Error(`spec not found: ${specName}`);
+      }
+      throw error;
+    }
+    this.logger.info("spec deleted", { runId, name: specName, path: specPath });
+  }
+
+  /**
+   * Runs the acceptance tests parsed from a spec's "# Acceptance tests"
+   * section as the final verification gate, reporting per-criterion
+   * outcomes in an `acceptance.results` event.
+   */
+  async runAcceptance(runId: UUID, specName: string): Promise<RunAcceptanceResponse> {
+    const record = this.requireRun(runId);
+    const spec = await this.getSpec(runId, specName);
+    const tests = parseAcceptanceTests(spec.content);
+    if (tests.length === 0) {
+      throw new Error(`spec ${spec.name} has no runnable acceptance tests (bullets with a backticked command)`);
+    }
+    const cwd = record.state.cwd ?? this.repoRoot;
+    this.logger.info("running acceptance tests", { runId, spec: spec.name, tests: tests.length, cwd });
+    const results = await runAcceptanceTests(tests, cwd, this.logger);
+    const passed = results.filter((result) => result.ok).length;
+    const failed = results.length - passed;
+    this.emitEvent(runId, {
+      id: newId(),
+      runId,
+      ts: nowIso(),
+      type: "acceptance.results",
+      specName: spec.name,
+      results,
+      passed,
+      failed
+    });
+    this.logger.info("acceptance tests finished", { runId, spec: spec.name, passed, failed });
+    return { specName: spec.name, results, passed, failed };
+  }
+
+  /** Specs live under specs/ inside the run's workspace (cwd). */
+  private specsDirFor(runId: UUID): string {
+    const record = this.requireRun(runId);
+    return path.resolve(record.state.cwd ?? this.repoRoot, "specs");
+  }
+
+  private validateName(kind: string, name: string): string {
+    const trimmed = name.trim();
+    if (!trimmed) {
+      throw new Error(`${kind} name is required`);
+    }
+    if (!/^[a-zA-Z0-9_-]+$/.test(trimmed)) {
+      throw new Error(`invalid ${kind} name: only alphanumeric, underscore, and hyphen allowed`);
+    }
+    return trimmed;
+  }
+
+  /** Reads a frontmatter-style field ("key: value") from spec markdown. */
+  private specField(content: string, key: string): string | undefined {
+    const pattern = new RegExp(`^${key.replace(/[.*+?^${}()|[\\]\\\\]/g, "\\$&")}\\s*:\\s*(.+)$`, "im");
+    const match = content.match(pattern);
+    return match ? match[1].trim() : undefined;
+  }
+
+  createNode(runId: UUID, config: NodeConfigInput): NodeState {
+    const record = this.requireRun(runId);
+    const now = nowIso();
+    const normalized = this.normalizeNodeConfig(config);
+    const nodeId = normalized.id ?? newId();
+    if (normalized.alias) {
+      this.assertAliasAvailable(record, normalized.alias, nodeId);
+    }
+    const nodeState: NodeState = {
+      id: nodeId,
+      runId,
+      label: normalized.label,
+      alias: normalized.alias,
+      roleTemplate: normalized.roleTemplate,
+      customSystemPrompt: normalized.customSystemPrompt ?? null,
+      provider: normalized.provider,
+      status: "idle",
+      summary: "idle",
+      lastActivityAt: now,
+      usage: { promptTokens: 0, completionTokens: 0, totalTokens: 0 },
+      capabilities: normalized.capabilities,
+      permissions: normalized.permissions,
+      session: {
+        sessionId: "pending",
+        resetCommands: normalized.session.resetCommands
+      },
+      connection: {
+        status: "idle",
+        streaming: false,
+        lastHeartbeatAt: now,
+        lastOutputAt: now
+      },
+      inboxCount: 0
+    };
+    this.store.addNode(runId, nodeState, normalized);
+    this.touchRun(record, now);
+    this.emitEvent(runId, {
+      id: newId(),
+      runId,
+      ts: now,
+      type: "node.patch",
+      nodeId: nodeState.id,
+      patch: nodeState
+    });
+    this.emitEvent(runId, {
+      id: newId(),
+      runId,
+      ts: now,
+      type: "run.patch",
+      patch: {
+        nodeConfigs: { [nodeState.id]: normalized },
+        updatedAt: now
       }
     });
     this.logger.info("node config snapshot stored", {
@@ -1251,6 +3023,83 @@ export class Runtime {
     };
   }
 
+  /**
+   * Spawns a constrained child node for a delegated subtask and waits for
+   * its first turn to finish, returning the child's summary to the parent
+   * turn. Linkage is an ordinary "subagent" edge, so the graph and events
+   * show who delegated what.
+   */
+  private async spawnSubagentFromTool(
+    runId: UUID,
+    fromNodeId: UUID,
+    request: SpawnSubagentRequest
+  ): Promise<SpawnSubagentResult> {
+    const record = this.requireRun(runId);
+    const caller = this.requireNode(record, fromNodeId);
+    this.ensureEdgeManagementForSpawn(caller);
+    const node = this.createNode(runId, {
+      label: request.label,
+      roleTemplate: request.roleTemplate ?? "implementer",
+      provider: request.provider ?? caller.provider,
+      // The child never manages the graph itself; everything else is opt-in.
+      capabilities: { ...request.capabilities, edgeManagement: "none", delegateOnly: false }
+    });
+    this.createEdge(runId, {
+      from: fromNodeId,
+      to: node.id,
+      bidirectional: true,
+      type: "handoff",
+      label: "subagent"
+    });
+    const timeoutMs =
+      typeof request.timeoutMs === "number" && request.timeoutMs > 0 ? request.timeoutMs : subagentTimeout();
+    const outcome = new Promise<SpawnSubagentResult["status"]>((resolve) => {
+      let timer: NodeJS.Timeout;
+      const off = this.eventBus.on((event) => {
+        if (event.type !== "turn.status" || event.nodeId !== node.id) {
+          return;
+        }
+        if (event.status === "turn.completed") {
+          clearTimeout(timer);
+          off();
+          resolve("completed");
+        } else if (event.status === "turn.failed" || event.status === "turn.interrupted") {
+          clearTimeout(timer);
+          off();
+          resolve("failed");
+        }
+      });
+      timer = setTimeout(() => {
+        off();
+        this.logger.warn("subagent budget expired; parent resumes without result", {
+          runId,
+          parentNodeId: fromNodeId,
+          nodeId: node.id,
+          timeoutMs
+        });
+        resolve("timeout");
+      }, timeoutMs);
+    });
+    this.deliverEnvelope(runId, {
+      kind: "handoff",
+      id: newId(),
+      fromNodeId,
+      toNodeId: node.id,
+      createdAt: nowIso(),
+      payload: { message: request.instructions }
+    });
+    this.logger.info("subagent spawned", {
+      runId,
+      parentNodeId: fromNodeId,
+      nodeId: node.id,
+      label: node.label,
+      timeoutMs
+    });
+    const status = await outcome;
+    const child = this.requireNode(this.requireRun(runId), node.id);
+    return { nodeId: node.id, status, summary: child.summary };
+  }
+
   private async createEdgeFromTool(
     runId: UUID,
     fromNodeId: UUID,
@@ -1364,6 +3213,72 @@ export class Runtime {
     return false;
   }
 
+  /**
+   * Adds a session-scoped auto-approve rule to a node, so routine calls
+   * ("approve everything matching `go test*` for the next hour") stop
+   * interrupting the reviewer. Destructive commands, deny/approve
+   * patterns, and protected-path writes still pause regardless of rules.
+   */
+  addAutoApproveRule(
+    runId: UUID,
+    nodeId: UUID,
+    input: { tool?: string; command?: string; ttlMs?: number }
+  ): AutoApproveRule {
+    const record = this.requireRun(runId);
+    const nodeRecord = this.requireNode(record, nodeId);
+    const command = input.command?.trim() || undefined;
+    const tool = input.tool?.trim() || (command ? "*" : "");
+    if (!tool) {
+      throw new Error('auto-approve rule requires a tool name ("net"/"*" allowed) or a command pattern');
+    }
+    if (input.ttlMs !== undefined && (!Number.isFinite(input.ttlMs) || input.ttlMs <= 0)) {
+      throw new Error("ttlMs must be a positive number of milliseconds");
+    }
+    const now = Date.now();
+    const rule: AutoApproveRule = {
+      id: newId(),
+      tool,
+      command,
+      expiresAt: input.ttlMs !== undefined ? new Date(now + input.ttlMs).toISOString() : undefined,
+      createdAt: nowIso()
+    };
+    const rules = [
+      ...(nodeRecord.state.autoApprove ?? []).filter(
+        (existing) => !existing.expiresAt || Date.parse(existing.expiresAt) > now
+      ),
+      rule
+    ];
+    this.updateNode(runId, nodeId, { autoApprove: rules });
+    this.logger.info("auto-approve rule added", {
+      runId,
+      nodeId,
+      ruleId: rule.id,
+      tool: rule.tool,
+      command: rule.command,
+      expiresAt: rule.expiresAt
+    });
+    this.audit.record(
+      "policy",
+      "auto-approve-added",
+      { ruleId: rule.id, tool: rule.tool, command: rule.command, expiresAt: rule.expiresAt },
+      { runId, nodeId }
+    );
+    return rule;
+  }
+
+  removeAutoApproveRule(runId: UUID, nodeId: UUID, ruleId: UUID): void {
+    const record = this.requireRun(runId);
+    const nodeRecord = this.requireNode(record, nodeId);
+    const rules = nodeRecord.state.autoApprove ?? [];
+    if (!rules.some((rule) => rule.id === ruleId)) {
+      throw new Error(`auto-approve rule not found: ${ruleId}`);
+    }
+    const remaining = rules.filter((rule) => rule.id !== ruleId);
+    this.updateNode(runId, nodeId, { autoApprove: remaining.length > 0 ? remaining : undefined });
+    this.logger.info("auto-approve rule removed", { runId, nodeId, ruleId });
+    this.audit.record("policy", "auto-approve-removed", { ruleId }, { runId, nodeId });
+  }
+
   updateNode(runId: UUID, nodeId: UUID, patch: Partial<NodeState>, config?: Partial<NodeConfig>): NodeState {
     const record = this.requireRun(runId);
     const now = nowIso();
@@ -1515,13 +3430,492 @@ export class Runtime {
     return this.store.listApprovals();
   }
 
+  /**
+   * Looks up a pending approval for a signed approval link. Throws for a
+   * bad or expired token, and for approvals already resolved - which is
+   * what makes a used link dead.
+   */
+  getApprovalForLink(approvalId: UUID, token: string): { runId: UUID; approval: ApprovalRequest } {
+    if (!verifyApprovalLinkToken(approvalId, token)) {
+      this.logger.warn("approval link rejected", { approvalId });
+      throw new Error("invalid or expired approval link");
+    }
+    const found = this.store.findApprovalById(approvalId);
+    if (!found) {
+      throw new Error("approval not found or already resolved");
+    }
+    return found;
+  }
+
+  listApiKeys(): Promise<ApiKeyInfo[]> {
+    return this.apiKeys.list();
+  }
+
+  async createApiKey(name: string, scopes: ApiKeyScope[]): Promise<{ key: string; apiKey: ApiKeyInfo }> {
+    const created = await this.apiKeys.create(name, scopes);
+    this.audit.record("api-key", "created", { apiKeyId: created.apiKey.id, name, scopes });
+    return created;
+  }
+
+  async revokeApiKey(apiKeyId: UUID): Promise<void> {
+    await this.apiKeys.revoke(apiKeyId);
+    this.audit.record("api-key", "revoked", { apiKeyId });
+  }
+
+  authEnforced(): Promise<boolean> {
+    return this.apiKeys.enforced();
+  }
+
+  async verifyApiKey(token: string | undefined, required: ApiKeyScope): Promise<ApiKeyInfo | null> {
+    const info = await this.apiKeys.verify(token, required);
+    if (info) {
+      this.audit.record("api-key", "used", { apiKeyId: info.id, name: info.name, scope: required });
+    }
+    return info;
+  }
+
+  /** Full audit trail with the hash chain re-verified, for compliance export. */
+  exportAudit(): Promise<AuditVerification> {
+    return this.audit.export();
+  }
+
+  listWebhooks(): Promise<WebhookSubscription[]> {
+    return this.webhooks.list();
+  }
+
+  createWebhook(input: {
+    url: string;
+    secret?: string;
+    events?: string[];
+  }): Promise<{ webhook: WebhookSubscription; secret?: string }> {
+    return this.webhooks.create(input);
+  }
+
+  deleteWebhook(webhookId: UUID): Promise<void> {
+    return this.webhooks.delete(webhookId);
+  }
+
+  async generateConsensusPlan(
+    runId: UUID,
+    nodeId: UUID,
+    input: { goal: string; context?: string }
+  ): Promise<{ plan: Artifact; candidates: Artifact[]; result: ConsensusPlanResult }> {
+    const record = this.requireRun(runId);
+    if (!record.nodes.has(nodeId)) {
+      throw new Error(`Node ${nodeId} not found`);
+    }
+    if (!this.consensusPlanner.enabled) {
+      throw new Error(
+        "consensus planning disabled; set VUHLP_CONSENSUS_API_KEY and two models in VUHLP_CONSENSUS_MODELS"
+      );
+    }
+    const goal = input.goal?.trim();
+    if (!goal) {
+      throw new Error("goal is required");
+    }
+    const result = await this.consensusPlanner.plan({ goal, context: input.context });
+    const candidates: Artifact[] = [];
+    for (const candidate of result.candidates) {
+      candidates.push(
+        await this.recordArtifact(runId, nodeId, "plan", `plan-candidate-${candidate.model}.md`, candidate.content, {
+          summary: `Plan candidate from ${candidate.model}`
+        })
+      );
+    }
+    const plan = await this.recordArtifact(runId, nodeId, "plan", "plan-consensus.md", result.plan, {
+      summary: `Consensus plan reconciled by ${result.reconciledBy}`
+    });
+    this.logger.info("consensus plan recorded", {
+      runId,
+      nodeId,
+      reconciledBy: result.reconciledBy,
+      candidates: result.candidates.map((candidate) => candidate.model)
+    });
+    return { plan, candidates, result };
+  }
+
+  /**
+   * Pauses a run between steps: in-flight turns are interrupted, plan
+   * steps go back to pending, and nothing new dispatches until resume.
+   */
+  pauseRun(runId: UUID): RunState {
+    const record = this.requireRun(runId);
+    if (record.state.status !== "running") {
+      throw new Error(`run is ${record.state.status}; only a running run can be paused`);
+    }
+    return this.updateRun(runId, { status: "paused" });
+  }
+
+  /** Resumes a paused or budget-exceeded run from the next pending work. */
+  resumeRun(runId: UUID): RunState {
+    const record = this.requireRun(runId);
+    if (record.state.status !== "paused" && record.state.status !== "budget_exceeded") {
+      throw new Error(`run is ${record.state.status}; only a paused or budget_exceeded run can be resumed`);
+    }
+    if (this.haltedAt !== null) {
+      throw new Error("daemon is halted; lift the halt before resuming runs");
+    }
+    const reason = this.budgetExceededReason(record.state);
+    if (record.state.status === "budget_exceeded" && reason) {
+      throw new Error(`${reason}; raise it via PUT /api/runs/:id/budget before resuming`);
+    }
+    return this.updateRun(runId, { status: "running" });
+  }
+
+  /**
+   * Replaces the run's spend limits. Raising them is how a
+   * budget_exceeded run gets unblocked: set a higher budget, then
+   * resume.
+   */
+  setRunBudget(runId: UUID, budget: RunBudget): RunState {
+    const record = this.requireRun(runId);
+    const normalized = this.normalizeBudget(budget);
+    const now = nowIso();
+    record.state.budget = normalized;
+    record.state.updatedAt = now;
+    this.logger.info("run budget updated", {
+      runId,
+      maxTokens: normalized?.maxTokens,
+      maxCostUsd: normalized?.maxCostUsd
+    });
+    this.emitEvent(runId, {
+      id: newId(),
+      runId,
+      ts: now,
+      type: "run.patch",
+      patch: { budget: normalized, updatedAt: now }
+    });
+    void this.saveRunSnapshot(runId);
+    return record.state;
+  }
+
+  /** Validates and trims a requested budget; returns undefined when no cap is set. */
+  private normalizeBudget(budget: RunBudget | undefined): RunBudget | undefined {
+    if (!budget) {
+      return undefined;
+    }
+    if (budget.maxTokens !== undefined && (!Number.isFinite(budget.maxTokens) || budget.maxTokens <= 0)) {
+      throw new Error("budget maxTokens must be a positive number");
+    }
+    if (budget.maxCostUsd !== undefined && (!Number.isFinite(budget.maxCostUsd) || budget.maxCostUsd <= 0)) {
+      throw new Error("budget maxCostUsd must be a positive number");
+    }
+    if (budget.maxTokens === undefined && budget.maxCostUsd === undefined) {
+      return undefined;
+    }
+    return {
+      maxTokens: budget.maxTokens !== undefined ? Math.floor(budget.maxTokens) : undefined,
+      maxCostUsd: budget.maxCostUsd
+    };
+  }
+
+  /**
+   * Why the run is over budget, or undefined while it is within limits.
+   * Per-run budgets win over the VUHLP_BUDGET_* defaults.
+   */
+  private budgetExceededReason(state: RunState): string | undefined {
+    const tokenCap = state.budget?.maxTokens ?? budgetTokensDefault();
+    const tokensUsed = state.usage?.totalTokens ?? 0;
+    if (tokenCap > 0 && tokensUsed >= tokenCap) {
+      return `token budget exhausted (${tokensUsed} of ${tokenCap})`;
+    }
+    const costCap = state.budget?.maxCostUsd ?? budgetUsdDefault();
+    const costUsed = state.costUsd ?? 0;
+    if (costCap > 0 && costUsed >= costCap) {
+      return `cost budget exhausted ($${costUsed.toFixed(4)} of $${costCap.toFixed(2)})`;
+    }
+    return undefined;
+  }
+
+  createPlan(runId: UUID, input: CreatePlanRequest): PlanState {
+    return this.planRunner.createPlan(runId, input);
+  }
+
+  updatePlan(runId: UUID, input: CreatePlanRequest): PlanState {
+    return this.planRunner.updatePlan(runId, input);
+  }
+
+  approvePlan(runId: UUID, approver?: string): PlanState {
+    return this.planRunner.approvePlan(runId, approver);
+  }
+
+  getPlan(runId: UUID): PlanState {
+    return this.planRunner.getPlan(runId);
+  }
+
+  startPlan(runId: UUID): PlanState {
+    return this.planRunner.startPlan(runId);
+  }
+
+  cancelPlan(runId: UUID): PlanState {
+    return this.planRunner.cancelPlan(runId);
+  }
+
+  startSpecLoop(runId: UUID, input: StartSpecLoopRequest): Promise<SpecLoopState> {
+    return this.specLoop.start(runId, input);
+  }
+
+  getSpecLoop(runId: UUID): SpecLoopState {
+    return this.specLoop.get(runId);
+  }
+
+  cancelSpecLoop(runId: UUID): SpecLoopState {
+    return this.specLoop.cancel(runId);
+  }
+
+  async buildContextPack(runId: UUID, nodeId: UUID): Promise<ContextPack> {
+    const record = this.requireRun(runId);
+    const nodeRecord = this.requireNode(record, nodeId);
+    const node = nodeRecord.state;
+    const docsRoot = path.join(this.repoRoot, "docs");
+    const docRefs: ContextPack["docRefs"] = [];
+    const templatePath = path.join(docsRoot, "templates", `${node.roleTemplate}.md`);
+    try {
+      const content = await fs.readFile(templatePath, "utf8");
+      docRefs.push({ path: templatePath, excerpt: content.slice(0, 400) });
+    } catch {
+      // Role template may live in the system templates dir or not exist;
+      // the pack records only what the node can actually see in-repo.
+    }
+    const relevantFiles: ContextPack["relevantFiles"] = [];
+    const seenFiles = new Set<string>();
+    const artifacts: ContextPack["artifacts"] = [];
+    for (const artifact of record.artifacts.values()) {
+      if (artifact.nodeId !== nodeId) {
+        continue;
+      }
+      artifacts.push({ id: artifact.id, kind: artifact.kind });
+      for (const file of artifact.metadata?.filesChanged ?? []) {
+        if (!seenFiles.has(file)) {
+          seenFiles.add(file);
+          relevantFiles.push({ path: file, summary: `changed by ${artifact.name}` });
+        }
+      }
+    }
+    // Annotate changed files with their top-level symbols so the pack
+    // orients the node without a read_file round-trip per file.
+    const repoMap = await buildRepoMap(record.state.cwd ?? this.repoRoot, relevantFiles.map((file) => file.path));
+    const languages = new Set<string>();
+    for (const entry of repoMap) {
+      languages.add(entry.language);
+      if (entry.symbols.length === 0) {
+        continue;
+      }
+      const target = relevantFiles.find((file) => file.path === entry.path);
+      if (target) {
+        const names = entry.symbols
+          .slice(0, 8)
+          .map((symbol) => `${symbol.kind} ${symbol.name}`)
+          .join(", ");
+        target.summary = `${target.summary}; ${names}`;
+      }
+    }
+    return {
+      packId: newId(),
+      runId,
+      nodeId,
+      createdAt: nowIso(),
+      goal: node.summary || node.label,
+      definitionOfDone: [],
+      globalMode: record.state.globalMode,
+      nodeMode: record.state.mode,
+      docsRoot,
+      docRefs,
+      repoFacts: {
+        repoRoot: this.repoRoot,
+        cwd: record.state.cwd ?? this.repoRoot,
+        languages: [...languages].sort()
+      },
+      relevantFiles,
+      inputs: nodeRecord.runtime.inbox.map((envelope) => ({ payloadId: envelope.id })),
+      artifacts,
+      constraints: {
+        capabilities: node.capabilities,
+        permissions: node.permissions
+      }
+    };
+  }
+
+  async getContextPack(
+    runId: UUID,
+    nodeId: UUID
+  ): Promise<{ contextPack: ContextPack; tokenEstimate: number; markdown: string }> {
+    const contextPack = await this.buildContextPack(runId, nodeId);
+    const markdown = this.renderContextPackMarkdown(contextPack);
+    // Rough chars/4 heuristic; good enough to judge whether the pack fits a window.
+    const tokenEstimate = Math.ceil(markdown.length / 4);
+    return { contextPack, tokenEstimate, markdown };
+  }
+
+  async pinContextPack(
+    runId: UUID,
+    nodeId: UUID
+  ): Promise<{ contextPack: ContextPack; artifact: Artifact }> {
+    const contextPack = await this.buildContextPack(runId, nodeId);
+    const artifact = await this.recordArtifact(
+      runId,
+      nodeId,
+      "contextpack",
+      "context-pack.json",
+      JSON.stringify(contextPack, null, 2),
+      { summary: `Pinned context pack ${contextPack.packId}` }
+    );
+    const record = this.requireRun(runId);
+    const nodeRecord = this.requireNode(record, nodeId);
+    const now = nowIso();
+    nodeRecord.state = { ...nodeRecord.state, pinnedContextPackId: contextPack.packId };
+    record.state.nodes[nodeId] = nodeRecord.state;
+    record.state.updatedAt = now;
+    this.logger.info("context pack pinned", { runId, nodeId, packId: contextPack.packId, artifactId: artifact.id });
+    this.emitEvent(runId, {
+      id: newId(),
+      runId,
+      ts: now,
+      type: "node.patch",
+      nodeId,
+      patch: { pinnedContextPackId: contextPack.packId }
+    });
+    return { contextPack, artifact };
+  }
+
+  private renderContextPackMarkdown(pack: ContextPack): string {
+    const lines: string[] = [];
+    lines.push(`# Context pack ${pack.packId}`);
+    lines.push("");
+    lines.push(`- Run: ${pack.runId}`);
+    lines.push(`- Node: ${pack.nodeId}`);
+    lines.push(`- Created: ${pack.createdAt}`);
+    lines.push(`- Goal: ${pack.goal}`);
+    lines.push(`- Global mode: ${pack.globalMode}`);
+    lines.push(`- Orchestration: ${pack.nodeMode}`);
+    lines.push("");
+    lines.push("## Doc refs");
+    lines.push(...(pack.docRefs.length === 0 ? ["- none"] : pack.docRefs.map((ref) => `- ${ref.path}`)));
+    lines.push("");
+    lines.push("## Relevant files");
+    lines.push(
+      ...(pack.relevantFiles.length === 0
+        ? ["- none"]
+        : pack.relevantFiles.map((file) => `- ${file.path} (${file.summary})`))
+    );
+    lines.push("");
+    lines.push("## Artifacts");
+    lines.push(
+      ...(pack.artifacts.length === 0
+        ? ["- none"]
+        : pack.artifacts.map((artifact) => `- ${artifact.id} (${artifact.kind})`))
+    );
+    lines.push("");
+    lines.push("## Constraints");
+    lines.push("```json");
+    lines.push(JSON.stringify(pack.constraints, null, 2));
+    lines.push("```");
+    return lines.join("\n");
+  }
+
+  addApprovalComment(
+    approvalId: UUID,
+    input: { file?: string; hunk?: string; line?: number; body: string }
+  ): { approvalId: UUID; comment: ApprovalComment } {
+    const body = input.body?.trim();
+    if (!body) {
+      throw new Error("comment body is required");
+    }
+    const found = this.store.findApprovalById(approvalId);
+    if (!found) {
+      throw new Error(`Approval ${approvalId} not found`);
+    }
+    const now = nowIso();
+    const comment: ApprovalComment = {
+      id: newId(),
+      file: input.file,
+      hunk: input.hunk,
+      line: input.line,
+      body,
+      createdAt: now
+    };
+    found.approval.comments = [...(found.approval.comments ?? []), comment];
+    this.logger.info("approval comment added", {
+      runId: found.runId,
+      approvalId,
+      nodeId: found.approval.nodeId,
+      file: comment.file
+    });
+    this.emitEvent(found.runId, {
+      id: newId(),
+      runId: found.runId,
+      ts: now,
+      type: "approval.comment",
+      approvalId,
+      nodeId: found.approval.nodeId,
+      comment
+    });
+    return { approvalId, comment };
+  }
+
+  /**
+   * Records an approval vote against a multi-approver policy. Returns true
+   * once the approval should actually resolve: denials and modifications
+   * short-circuit, approvals wait for the required count of distinct
+   * approvers. Partial state stays visible on the pending approval.
+   */
+  private approvalQuorumReached(
+    runId: UUID,
+    approval: ApprovalRequest,
+    resolution: ApprovalResolution
+  ): boolean {
+    const required = approval.requiredApprovals ?? 1;
+    if (required <= 1 || resolution.status !== "approved") {
+      return true;
+    }
+    const approver = resolution.approver?.trim() || "anonymous";
+    const approvedBy = approval.approvedBy ?? [];
+    if (!approvedBy.includes(approver)) {
+      approvedBy.push(approver);
+      approval.approvedBy = approvedBy;
+    }
+    if (approvedBy.length >= required) {
+      this.logger.info("approval quorum reached", {
+        runId,
+        approvalId: approval.approvalId,
+        approvedBy,
+        required
+      });
+      return true;
+    }
+    this.logger.info("partial approval recorded", {
+      runId,
+      approvalId: approval.approvalId,
+      approver,
+      approvals: approvedBy.length,
+      required
+    });
+    this.emitEvent(runId, {
+      id: newId(),
+      runId,
+      ts: nowIso(),
+      type: "approval.vote",
+      approvalId: approval.approvalId,
+      nodeId: approval.nodeId,
+      approver,
+      approvals: approvedBy.length,
+      requiredApprovals: required
+    });
+    return false;
+  }
+
   resolveApproval(runId: UUID, approvalId: UUID, resolution: ApprovalResolution): void {
     const record = this.requireRun(runId);
     const now = nowIso();
+    const pending = this.store.findApprovalById(approvalId);
+    if (pending && !this.approvalQuorumReached(pending.runId, pending.approval, resolution)) {
+      return;
+    }
     const approval = this.store.resolveApproval(runId, approvalId);
     if (!approval) {
       return;
     }
+    resolution = this.withApprovalComments(approval, resolution);
     const resolver = this.runner.resolveApproval?.(approvalId, resolution);
     if (resolver) {
       resolver.catch((error) => {
@@ -1540,15 +3934,28 @@ export class Runtime {
       approvalId,
       resolution
     });
+    this.audit.record(
+      "approval",
+      resolution.status,
+      { approvalId, tool: approval.tool.name, approver: resolution.approver, reason: resolution.reason },
+      { runId, nodeId: approval.nodeId }
+    );
+    this.recordApprovalDecision(record, approval, resolution, now);
+    this.applyResolutionScope(runId, approval, resolution);
     this.unblockNode(record, approval.nodeId, now);
   }
 
   resolveApprovalById(approvalId: UUID, resolution: ApprovalResolution): void {
     const now = nowIso();
+    const pending = this.store.findApprovalById(approvalId);
+    if (pending && !this.approvalQuorumReached(pending.runId, pending.approval, resolution)) {
+      return;
+    }
     const resolved = this.store.resolveApprovalById(approvalId);
     if (!resolved) {
       return;
     }
+    resolution = this.withApprovalComments(resolved.approval, resolution);
     const resolver = this.runner.resolveApproval?.(approvalId, resolution);
     if (resolver) {
       resolver.catch((error) => {
@@ -1567,10 +3974,126 @@ export class Runtime {
       approvalId,
       resolution
     });
+    this.audit.record(
+      "approval",
+      resolution.status,
+      { approvalId, tool: resolved.approval.tool.name, approver: resolution.approver, reason: resolution.reason },
+      { runId: resolved.runId, nodeId: resolved.approval.nodeId }
+    );
+    this.applyResolutionScope(resolved.runId, resolved.approval, resolution);
     const record = this.requireRun(resolved.runId);
+    this.recordApprovalDecision(record, resolved.approval, resolution, now);
     this.unblockNode(record, resolved.approval.nodeId, now);
   }
 
+  /**
+   * Keeps who-decided-what on the run state, so run detail responses
+   * show approver identities without replaying the event log. Capped to
+   * the most recent decisions; the audit log holds the full history.
+   */
+  private recordApprovalDecision(
+    record: RunRecord,
+    approval: ApprovalRequest,
+    resolution: ApprovalResolution,
+    ts: string
+  ): void {
+    const decision: ApprovalDecision = {
+      approvalId: approval.approvalId,
+      nodeId: approval.nodeId,
+      tool: approval.tool.name,
+      status: resolution.status,
+      approver: resolution.approver,
+      ts
+    };
+    record.state.approvalDecisions = [...(record.state.approvalDecisions ?? []), decision].slice(
+      -APPROVAL_DECISION_HISTORY
+    );
+  }
+
+  /**
+   * A "session"-scoped approval records an auto-approve rule for the
+   * tool on the node, so later turns stop asking too. Scope "turn" is
+   * handled entirely inside the runner's tool queue.
+   */
+  private applyResolutionScope(runId: UUID, approval: ApprovalRequest, resolution: ApprovalResolution): void {
+    if (resolution.scope !== "session" || resolution.status === "denied") {
+      return;
+    }
+    try {
+      this.addAutoApproveRule(runId, approval.nodeId, { tool: approval.tool.name });
+    } catch (error) {
+      this.logger.error("failed to record session-scoped approval rule", {
+        runId,
+        nodeId: approval.nodeId,
+        tool: approval.tool.name,
+        message: error instanceof Error ? error.message : String(error)
+      });
+    }
+  }
+
+  /**
+   * Auto-resolves approvals that have waited longer than VUHLP_APPROVAL_TIMEOUT.
+   * Timed-out requests are denied by default; tools listed in
+   * VUHLP_APPROVAL_TIMEOUT_AUTO_APPROVE are approved instead, unless the
+   * request is destructive or needs more than one approver. The
+   * approval.expired event reaches any webhook subscribed to it, which is
+   * the escalation hook.
+   */
+  private sweepExpiredApprovals(): void {
+    const timeout = approvalTimeout();
+    if (timeout === null) {
+      return;
+    }
+    const autoApprove = approvalTimeoutAutoApprove();
+    const now = Date.now();
+    for (const record of this.store.listRunRecords()) {
+      for (const approval of [...record.approvals.values()]) {
+        const requestedAt = approval.requestedAt ? Date.parse(approval.requestedAt) : NaN;
+        if (!Number.isFinite(requestedAt) || now - requestedAt < timeout) {
+          continue;
+        }
+        const lowRisk =
+          autoApprove.has(approval.tool.name) &&
+          approval.severity !== "destructive" &&
+          (approval.requiredApprovals ?? 1) <= 1;
+        const action = lowRisk ? "approved" : "denied";
+        this.logger.warn("approval timed out", {
+          runId: record.state.id,
+          approvalId: approval.approvalId,
+          tool: approval.tool.name,
+          action,
+          timeoutMs: timeout
+        });
+        this.emitEvent(record.state.id, {
+          id: newId(),
+          runId: record.state.id,
+          ts: nowIso(),
+          type: "approval.expired",
+          approvalId: approval.approvalId,
+          nodeId: approval.nodeId,
+          action,
+          timeoutMs: timeout
+        });
+        this.resolveApproval(record.state.id, approval.approvalId, {
+          status: action,
+          approver: "system",
+          reason: `approval timed out after ${timeout}ms`
+        });
+      }
+    }
+  }
+
+  private withApprovalComments(approval: ApprovalRequest, resolution: ApprovalResolution): ApprovalResolution {
+    if (resolution.status !== "denied") {
+      return resolution;
+    }
+    const comments = approval.comments ?? [];
+    if (comments.length === 0 || (resolution.comments?.length ?? 0) > 0) {
+      return resolution;
+    }
+    return { ...resolution, comments };
+  }
+
   async recordArtifact(
     runId: UUID,
     nodeId: UUID,
@@ -1639,20 +4162,47 @@ export class Runtime {
       });
       return;
     }
-    let usagePatch: { nodeId?: UUID; nodeUsage?: UsageTotals; runUsage?: UsageTotals; ts: string } | null = null;
+    if (event.type === "tool.started") {
+      this.audit.record(
+        "tool",
+        event.tool.name,
+        { toolId: event.tool.id },
+        { runId, nodeId: event.nodeId }
+      );
+    }
+    let usagePatch: {
+      nodeId?: UUID;
+      nodeUsage?: UsageTotals;
+      nodeCostUsd?: number;
+      runUsage?: UsageTotals;
+      runCostUsd?: number;
+      ts: string;
+    } | null = null;
 
     if (event.type === "telemetry.usage") {
       const timestamp = event.ts ?? nowIso();
       const runUsage = addUsage(record.state.usage, event.usage);
       record.state.usage = runUsage;
       record.state.updatedAt = timestamp;
+      const price = this.providerPrice(event.provider);
+      let costDelta: number | undefined;
+      if (price) {
+        costDelta =
+          (event.usage.promptTokens * price.promptUsdPerMTok +
+            event.usage.completionTokens * price.completionUsdPerMTok) /
+          1_000_000;
+        record.state.costUsd = (record.state.costUsd ?? 0) + costDelta;
+      }
 
       let nodeUsage: UsageTotals | undefined;
+      let nodeCostUsd: number | undefined;
       if (event.nodeId) {
         const nodeRecord = record.nodes.get(event.nodeId);
         if (nodeRecord) {
           nodeUsage = addUsage(nodeRecord.state.usage, event.usage);
-          nodeRecord.state = { ...nodeRecord.state, usage: nodeUsage };
+          nodeCostUsd =
+            costDelta !== undefined ? (nodeRecord.state.costUsd ?? 0) + costDelta : nodeRecord.state.costUsd;
+          nodeRecord.state = { ...nodeRecord.state, usage: nodeUsage, costUsd: nodeCostUsd };
           record.state.nodes[event.nodeId] = nodeRecord.state;
         }
       }
@@ -1660,7 +4210,9 @@ export class Runtime {
       usagePatch = {
         nodeId: event.nodeId,
         nodeUsage,
+        nodeCostUsd,
         runUsage,
+        runCostUsd: record.state.costUsd,
         ts: timestamp
       };
     }
@@ -1677,6 +4229,24 @@ export class Runtime {
       this.logger.error("failed to append run event", { runId, type: event.type, message });
     });
     this.eventBus.emit(event);
+    this.webhooks.dispatch(event);
+    this.notifier.notify(event);
+    if (
+      event.type === "approval.requested" ||
+      event.type === "approval.resolved" ||
+      event.type === "plan.updated" ||
+      event.type === "loop.updated"
+    ) {
+      // Pending approvals and plan/loop progress ride along in the
+      // snapshot; persist promptly so a restart between debounced saves
+      // does not strand a waiting turn or lose a checkpoint.
+      void this.saveRunSnapshot(runId);
+    }
+    if (event.type === "turn.status") {
+      // Settled turns advance whichever plan step is running on that node.
+      this.planRunner.onTurnSettled(runId, event.nodeId, event.status, event.detail);
+      this.specLoop.onTurnSettled(runId, event.nodeId, event.status, event.detail);
+    }
 
     if (usagePatch?.nodeId && usagePatch.nodeUsage) {
       this.emitEvent(runId, {
@@ -1685,7 +4255,7 @@ export class Runtime {
         ts: usagePatch.ts,
         type: "node.patch",
         nodeId: usagePatch.nodeId,
-        patch: { usage: usagePatch.nodeUsage }
+        patch: { usage: usagePatch.nodeUsage, costUsd: usagePatch.nodeCostUsd }
       });
     }
 
@@ -1695,9 +4265,17 @@ export class Runtime {
         runId,
         ts: usagePatch.ts,
         type: "run.patch",
-        patch: { usage: usagePatch.runUsage, updatedAt: usagePatch.ts }
+        patch: { usage: usagePatch.runUsage, costUsd: usagePatch.runCostUsd, updatedAt: usagePatch.ts }
       });
     }
+
+    if (usagePatch && record.state.status === "running") {
+      const reason = this.budgetExceededReason(record.state);
+      if (reason) {
+        this.logger.warn("run budget exceeded; interrupting run", { runId, reason });
+        this.updateRun(runId, { status: "budget_exceeded" });
+      }
+    }
   }
 
   private touchRun(record: RunRecord, timestamp: string): void {
@@ -1,3 +1,5 @@
+import { exec as execCallback } from "child_process";
+import { promisify } from "util";
 import type {
   Artifact,
   ArtifactMetadata,
@@ -15,6 +17,11 @@ import type { NodeRecord, RunRecord, RunStore } from "./store.js";
 import { updateStallState } from "./loop-safety.js";
 import { hashString, newId, nowIso } from "./utils.js";
 import { ArtifactStore } from "./artifact-store.js";
+import { CheckpointStore } from "./checkpoint-store.js";
+import { TurnEvaluator } from "./turn-evaluator.js";
+import { inQuietHours } from "./quiet-hours.js";
+
+const exec = promisify(execCallback);
 
 export interface SchedulerOptions {
   store: RunStore;
@@ -22,6 +29,8 @@ export interface SchedulerOptions {
   runner: NodeRunner;
   dataDir: string;
   stallThreshold?: number;
+  /** Global kill switch; while true no new turns start. */
+  isHalted?: () => boolean;
   logger?: Logger;
 }
 
@@ -32,9 +41,13 @@ export class Scheduler {
   private readonly dataDir: string;
   private readonly stallThreshold: number;
   private readonly logger: Logger;
+  private readonly isHalted?: () => boolean;
   private timer?: NodeJS.Timeout;
   private ticking = false;
+  private inQuietWindow = false;
   private artifactStores = new Map<UUID, ArtifactStore>();
+  private checkpointStores = new Map<UUID, CheckpointStore>();
+  private readonly turnEvaluator: TurnEvaluator;
 
   constructor(options: SchedulerOptions) {
     this.store = options.store;
@@ -42,7 +55,9 @@ export class Scheduler {
     this.runner = options.runner;
     this.dataDir = options.dataDir;
     this.stallThreshold = options.stallThreshold ?? 20;
+    this.isHalted = options.isHalted;
     this.logger = options.logger ?? new ConsoleLogger({ scope: "scheduler" });
+    this.turnEvaluator = new TurnEvaluator(this.logger);
   }
 
   start(intervalMs = 250): void {
@@ -63,6 +78,19 @@ export class Scheduler {
     if (this.ticking) {
       return;
     }
+    if (this.isHalted?.()) {
+      return;
+    }
+    const quiet = inQuietHours();
+    if (quiet !== this.inQuietWindow) {
+      this.inQuietWindow = quiet;
+      this.logger.info(quiet ? "quiet hours started; queuing new turns" : "quiet hours ended; resuming turns", {
+        window: process.env.VUHLP_QUIET_HOURS
+      });
+    }
+    if (quiet) {
+      return;
+    }
     this.ticking = true;
     try {
       const runs = this.store.listRuns();
@@ -237,7 +265,10 @@ export class Scheduler {
     const runId = record.state.id;
     const nodeId = nodeRecord.state.id;
     const now = nowIso();
-    record.approvals.set(result.approval.approvalId, result.approval);
+    this.store.addApproval(runId, {
+      ...result.approval,
+      requestedAt: result.approval.requestedAt ?? now
+    });
     nodeRecord.runtime.pendingTurn = true;
     const blockedConnection: NodeConnection | undefined = nodeRecord.state.connection
       ? {
@@ -261,7 +292,10 @@ export class Scheduler {
       approvalId: result.approval.approvalId,
       nodeId,
       tool: result.approval.tool,
-      context: result.approval.context
+      context: result.approval.context,
+      summary: result.approval.summary,
+      risk: result.approval.risk,
+      cwd: result.approval.cwd
     });
     this.emitEvent(runId, {
       id: newId(),
@@ -443,9 +477,133 @@ export class Scheduler {
       summary: result.summary
     });
 
+    await this.captureCheckpoint(record, nodeId);
+    await this.commitTurnWork(record, nodeId, result.diff);
+
+    // Fire-and-forget: the evaluation verdict lands via node.patch and
+    // turn.evaluated events once the cheap model call returns.
+    void this.evaluateTurn(record, nodeRecord, result);
+
     this.queueAutoPrompt(record, nodeRecord);
   }
 
+  private async evaluateTurn(
+    record: RunRecord,
+    nodeRecord: NodeRecord,
+    result: Extract<TurnResult, { kind: "completed" }>
+  ): Promise<void> {
+    if (!this.turnEvaluator.enabled) {
+      return;
+    }
+    const runId = record.state.id;
+    const nodeId = nodeRecord.state.id;
+    const evaluation = await this.turnEvaluator.evaluate({
+      roleTemplate: nodeRecord.state.roleTemplate,
+      summary: result.summary,
+      message: result.message,
+      verificationFailure: result.verificationFailure
+    });
+    if (!evaluation || !record.nodes.has(nodeId)) {
+      return;
+    }
+    this.logger.info("turn evaluated", {
+      runId,
+      nodeId,
+      progress: evaluation.progress,
+      risk: evaluation.risk,
+      needsHuman: evaluation.needsHuman
+    });
+    this.patchNode(record, nodeRecord, { lastEvaluation: evaluation });
+    this.emitEvent(runId, {
+      id: newId(),
+      runId,
+      ts: evaluation.ts,
+      type: "turn.evaluated",
+      nodeId,
+      evaluation
+    });
+  }
+
+  private async captureCheckpoint(record: RunRecord, nodeId: UUID): Promise<void> {
+    const cwd = record.state.cwd;
+    if (!cwd) {
+      return;
+    }
+    const runId = record.state.id;
+    const store = this.getCheckpointStore(runId);
+    const checkpoint = await store.capture(cwd, nodeId);
+    if (!checkpoint) {
+      return;
+    }
+    this.emitEvent(runId, {
+      id: newId(),
+      runId,
+      ts: checkpoint.ts,
+      type: "checkpoint.created",
+      nodeId,
+      turn: checkpoint.turn,
+      tree: checkpoint.tree
+    });
+  }
+
+  /**
+   * Lands the turn's file changes as one commit on the run's
+   * auto-commit branch, with a message generated from the turn's diff.
+   * Best-effort, like checkpoints: a failed commit is logged and the
+   * run keeps going.
+   */
+  private async commitTurnWork(
+    record: RunRecord,
+    nodeId: UUID,
+    diff?: { content: string; filesChanged?: string[]; summary?: string }
+  ): Promise<void> {
+    const auto = record.state.autoCommit;
+    const cwd = record.state.cwd;
+    if (!auto || !cwd || !diff) {
+      return;
+    }
+    const runId = record.state.id;
+    try {
+      const { stdout: status } = await exec("git status --porcelain", {
+        cwd,
+        timeout: 30_000,
+        maxBuffer: 10 * 1024 * 1024
+      });
+      if (!status.trim()) {
+        return;
+      }
+      await exec("git add -A", { cwd, timeout: 60_000, maxBuffer: 10 * 1024 * 1024 });
+      const files = diff.filesChanged ?? [];
+      const fileSummary =
+        files.length === 0
+          ? "workspace changes"
+          : files.length <= 3
+            ? files.join(", ")
+            : `${files.slice(0, 3).join(", ")} (+${files.length - 3} more)`;
+      // The summary comes from the model; keep the subject one line and
+      // strip anything the shell would interpret inside the quotes.
+      const summary = diff.summary?.replace(/\s+/g, " ").trim();
+      const subject = (summary || `update ${fileSummary}`).replace(/["`$\\]/g, "").slice(0, 72);
+      await exec(`git commit -m "${subject}"`, { cwd, timeout: 60_000 });
+      const { stdout: headOut } = await exec("git rev-parse HEAD", { cwd, timeout: 10_000 });
+      const now = nowIso();
+      auto.head = headOut.trim();
+      auto.count += 1;
+      record.state.updatedAt = now;
+      this.emitEvent(runId, {
+        id: newId(),
+        runId,
+        ts: now,
+        type: "run.patch",
+        patch: { autoCommit: auto, updatedAt: now }
+      });
+      this.logger.info("turn auto-committed", { runId, nodeId, commit: auto.head, files: files.length });
+    } catch (error) {
+      const message = error instanceof Error ? error.message : String(error);
+      this.logger.error("auto-commit failed; continuing without it", { runId, nodeId, message });
+    }
+  }
+
   private async handleInterrupted(
     record: RunRecord,
     nodeRecord: NodeRecord,
@@ -612,6 +770,16 @@ export class Scheduler {
     return roleTemplate.trim().toLowerCase() === "orchestrator";
   }
 
+  private getCheckpointStore(runId: UUID): CheckpointStore {
+    const existing = this.checkpointStores.get(runId);
+    if (existing) {
+      return existing;
+    }
+    const store = new CheckpointStore(this.dataDir, runId, this.logger);
+    this.checkpointStores.set(runId, store);
+    return store;
+  }
+
   private getArtifactStore(runId: UUID): ArtifactStore {
     const existing = this.artifactStores.get(runId);
     if (existing) {
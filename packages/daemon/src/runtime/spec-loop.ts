@@ -0,0 +1,342 @@
+import type {
+  AcceptanceResult,
+  EventEnvelope,
+  RunAcceptanceResponse,
+  SpecLoopState,
+  SpecLoopStatus,
+  StartSpecLoopRequest,
+  TurnStatus,
+  UUID
+} from "@vuhlp/contracts";
+import { ConsoleLogger, type Logger } from "@vuhlp/providers";
+import type { RunRecord, RunStore } from "./store.js";
+import { newId, nowIso } from "./utils.js";
+
+/** Max implement/verify iterations per loop before it fails. */
+const loopIterationLimit = (): number => {
+  const raw = Number.parseInt(process.env.VUHLP_LOOP_MAX_ITERATIONS ?? "", 10);
+  return Number.isFinite(raw) && raw > 0 ? raw : 5;
+};
+
+export interface SpecLoopRunnerOptions {
+  store: RunStore;
+  emitEvent: (runId: UUID, event: EventEnvelope) => void;
+  /** Delivers an iteration's prompt to the node; the scheduler runs it as the node's next turn. */
+  dispatch: (runId: UUID, nodeId: UUID, content: string) => void;
+  readSpec: (runId: UUID, name: string) => Promise<{ name: string; content: string }>;
+  /** Runs the spec's acceptance tests; already emits `acceptance.results`. */
+  runAcceptance: (runId: UUID, specName: string) => Promise<RunAcceptanceResponse>;
+  logger?: Logger;
+}
+
+/**
+ * Drives a spec through a node's full tool loop until its acceptance
+ * tests pass. Unlike a plan - a fixed task list executed step by step -
+ * the loop is agentic: the node reads, searches, patches, and verifies
+ * however it sees fit each turn, and the loop closes the feedback cycle
+ * by running the acceptance tests after every turn and prompting the
+ * node with whatever still fails.
+ */
+export class SpecLoopRunner {
+  private readonly store: RunStore;
+  private readonly emitEvent: (runId: UUID, event: EventEnvelope) => void;
+  private readonly dispatch: (runId: UUID, nodeId: UUID, content: string) => void;
+  private readonly readSpec: (runId: UUID, name: string) => Promise<{ name: string; content: string }>;
+  private readonly runAcceptance: (runId: UUID, specName: string) => Promise<RunAcceptanceResponse>;
+  private readonly logger: Logger;
+  /** Loops whose acceptance run is outstanding; further turn settles are not theirs. */
+  private readonly checksInFlight = new Set<UUID>();
+  /** Last dispatched prompt per loop, so a paused run can resume mid-iteration. */
+  private readonly prompts = new Map<UUID, string>();
+
+  constructor(options: SpecLoopRunnerOptions) {
+    this.store = options.store;
+    this.emitEvent = options.emitEvent;
+    this.dispatch = options.dispatch;
+    this.readSpec = options.readSpec;
+    this.runAcceptance = options.runAcceptance;
+    this.logger = options.logger ?? new ConsoleLogger({ scope: "spec-loop" });
+  }
+
+  async start(runId: UUID, input: StartSpecLoopRequest): Promise<SpecLoopState> {
+    const record = this.requireRun(runId);
+    if (record.state.loop && record.state.loop.status === "running") {
+      throw new Error("run already has an active spec loop; cancel it first");
+    }
+    if (!input.spec?.trim()) {
+      throw new Error("spec loop needs a spec name");
+    }
+    const nodeIds = Object.keys(record.state.nodes);
+    const nodeId = input.nodeId ?? (nodeIds.length === 1 ? nodeIds[0] : undefined);
+    if (!nodeId) {
+      throw new Error(`spec loop needs a nodeId (run has ${nodeIds.length} nodes)`);
+    }
+    if (!record.state.nodes[nodeId]) {
+      throw new Error(`spec loop targets unknown node ${nodeId}`);
+    }
+    if (
+      input.maxIterations !== undefined &&
+      (!Number.isFinite(input.maxIterations) || input.maxIterations < 1)
+    ) {
+      throw new Error("spec loop maxIterations must be a positive number");
+    }
+    const spec = await this.readSpec(runId, input.spec);
+    const now = nowIso();
+    const loop: SpecLoopState = {
+      id: newId(),
+      runId,
+      specName: spec.name,
+      nodeId,
+      status: "running",
+      iteration: 1,
+      maxIterations:
+        input.maxIterations !== undefined ? Math.floor(input.maxIterations) : loopIterationLimit(),
+      createdAt: now,
+      updatedAt: now
+    };
+    record.state.loop = loop;
+    this.logger.info("spec loop started", {
+      runId,
+      loopId: loop.id,
+      spec: loop.specName,
+      nodeId,
+      maxIterations: loop.maxIterations
+    });
+    this.emitLoopUpdated(record);
+    this.dispatchIteration(
+      loop,
+      `Implement the following spec. After each of your turns its acceptance tests run ` +
+        `automatically and any failures come back to you, so work in verifiable increments ` +
+        `and keep iterating until they pass.\n\n${spec.content}`
+    );
+    return loop;
+  }
+
+  get(runId: UUID): SpecLoopState {
+    const loop = this.requireRun(runId).state.loop;
+    if (!loop) {
+      throw new Error("run has no spec loop");
+    }
+    return loop;
+  }
+
+  cancel(runId: UUID): SpecLoopState {
+    const record = this.requireRun(runId);
+    const loop = record.state.loop;
+    if (!loop) {
+      throw new Error("run has no spec loop");
+    }
+    if (loop.status !== "running") {
+      return loop;
+    }
+    this.settle(record, loop, "cancelled");
+    this.logger.info("spec loop cancelled", { runId, loopId: loop.id });
+    return loop;
+  }
+
+  /**
+   * Advances the loop when a turn on its node settles. Plan steps claim
+   * their node's turns first - a running plan step on the same node means
+   * the settled turn was the step's, not the loop's.
+   */
+  onTurnSettled(runId: UUID, nodeId: UUID, status: TurnStatus, detail?: string): void {
+    if (status !== "turn.completed" && status !== "turn.failed" && status !== "turn.interrupted") {
+      return;
+    }
+    const record = this.store.getRun(runId);
+    const loop = record?.state.loop;
+    if (!record || !loop || loop.status !== "running" || loop.nodeId !== nodeId) {
+      return;
+    }
+    if (this.checksInFlight.has(loop.id)) {
+      return;
+    }
+    if (
+      record.state.plan?.status === "running" &&
+      record.state.plan.steps.some((step) => step.status === "running" && step.nodeId === nodeId)
+    ) {
+      return;
+    }
+    if (status === "turn.interrupted") {
+      if (record.state.status !== "running") {
+        // Run pause interrupts in-flight turns; resume re-dispatches the
+        // current iteration, so there is nothing to advance here.
+        this.logger.info("spec loop suspended by run pause", { runId, loopId: loop.id });
+        return;
+      }
+      this.settle(record, loop, "failed", detail ?? "turn interrupted");
+      this.logger.warn("spec loop failed; turn interrupted", { runId, loopId: loop.id });
+      return;
+    }
+    if (status === "turn.failed") {
+      this.iterate(record, loop, `The previous turn failed: ${detail ?? "turn failed"}`);
+      return;
+    }
+    this.checksInFlight.add(loop.id);
+    void this.runAcceptance(runId, loop.specName)
+      .then((outcome) => {
+        this.checksInFlight.delete(loop.id);
+        this.onAcceptanceSettled(runId, loop.id, outcome);
+      })
+      .catch((cause: Error) => {
+        this.checksInFlight.delete(loop.id);
+        const current = this.store.getRun(runId);
+        if (!current || current.state.loop?.id !== loop.id || loop.status !== "running") {
+          return;
+        }
+        this.settle(current, loop, "failed", `acceptance run failed: ${cause.message}`);
+        this.logger.error("spec loop failed; could not run acceptance tests", {
+          runId,
+          loopId: loop.id,
+          message: cause.message
+        });
+      });
+  }
+
+  /** Re-dispatches the current iteration's prompt after a paused run resumes. */
+  resumeDispatch(runId: UUID): void {
+    const record = this.store.getRun(runId);
+    const loop = record?.state.loop;
+    if (!record || !loop || loop.status !== "running" || this.checksInFlight.has(loop.id)) {
+      return;
+    }
+    const prompt = this.prompts.get(loop.id);
+    if (prompt) {
+      this.logger.info("resuming spec loop", { runId, loopId: loop.id, iteration: loop.iteration });
+      this.dispatch(runId, loop.nodeId, prompt);
+      return;
+    }
+    // The prompt did not survive a daemon restart; rebuild from the spec
+    // and let the node pick up from the workspace's current state.
+    void this.readSpec(runId, loop.specName)
+      .then((spec) => {
+        if (loop.status !== "running") {
+          return;
+        }
+        this.logger.info("resuming spec loop after restart", {
+          runId,
+          loopId: loop.id,
+          iteration: loop.iteration
+        });
+        this.dispatchIteration(
+          loop,
+          `The daemon restarted mid-loop; the workspace keeps whatever progress was made. ` +
+            `Continue implementing the following spec until its acceptance tests pass.\n\n${spec.content}`,
+          loop.iteration
+        );
+      })
+      .catch((cause: Error) => {
+        const current = this.store.getRun(runId);
+        if (!current || current.state.loop?.id !== loop.id || loop.status !== "running") {
+          return;
+        }
+        this.settle(current, loop, "failed", `spec unavailable on resume: ${cause.message}`);
+        this.logger.error("spec loop failed; spec unavailable on resume", {
+          runId,
+          loopId: loop.id,
+          message: cause.message
+        });
+      });
+  }
+
+  private onAcceptanceSettled(runId: UUID, loopId: UUID, outcome: RunAcceptanceResponse): void {
+    const record = this.store.getRun(runId);
+    const loop = record?.state.loop;
+    if (!record || !loop || loop.id !== loopId || loop.status !== "running") {
+      return;
+    }
+    if (outcome.failed === 0) {
+      this.settle(record, loop, "passed");
+      this.logger.info("spec loop passed", {
+        runId,
+        loopId: loop.id,
+        spec: loop.specName,
+        iterations: loop.iteration
+      });
+      return;
+    }
+    const failures = outcome.results.filter((result) => !result.ok);
+    this.iterate(
+      record,
+      loop,
+      `${outcome.failed} of ${outcome.results.length} acceptance tests are still failing:\n\n` +
+        failures.map((result) => this.formatFailure(result)).join("\n")
+    );
+  }
+
+  /** Moves to the next iteration with `feedback`, or fails the loop when none are left. */
+  private iterate(record: RunRecord, loop: SpecLoopState, feedback: string): void {
+    if (loop.iteration >= loop.maxIterations) {
+      this.settle(record, loop, "failed", `acceptance tests still failing after ${loop.iteration} iterations`);
+      this.logger.warn("spec loop failed; iterations exhausted", {
+        runId: loop.runId,
+        loopId: loop.id,
+        spec: loop.specName,
+        iterations: loop.iteration
+      });
+      return;
+    }
+    loop.iteration += 1;
+    loop.updatedAt = nowIso();
+    this.logger.info("spec loop iterating", {
+      runId: loop.runId,
+      loopId: loop.id,
+      iteration: loop.iteration,
+      maxIterations: loop.maxIterations
+    });
+    this.emitLoopUpdated(record);
+    this.dispatchIteration(
+      loop,
+      `${feedback}\n\nFix the failures and keep iterating until the acceptance tests pass.`,
+      loop.iteration
+    );
+  }
+
+  private dispatchIteration(loop: SpecLoopState, body: string, iteration = 1): void {
+    const prompt = `[spec loop: ${loop.specName} - iteration ${iteration}/${loop.maxIterations}]\n${body}`;
+    this.prompts.set(loop.id, prompt);
+    const record = this.store.getRun(loop.runId);
+    if (record && record.state.status !== "running") {
+      // A paused run dispatches nothing; resume re-sends the stored prompt.
+      return;
+    }
+    this.dispatch(loop.runId, loop.nodeId, prompt);
+  }
+
+  private formatFailure(result: AcceptanceResult): string {
+    const exit = result.exitCode === null ? "timed out or killed" : `exit ${result.exitCode}`;
+    const output = result.output.trim();
+    return `- ${result.name} (\`${result.command}\`, ${exit})${output ? `:\n${output}` : ""}`;
+  }
+
+  private settle(record: RunRecord, loop: SpecLoopState, status: SpecLoopStatus, error?: string): void {
+    loop.status = status;
+    loop.error = error;
+    loop.updatedAt = nowIso();
+    this.prompts.delete(loop.id);
+    this.emitLoopUpdated(record);
+  }
+
+  private emitLoopUpdated(record: RunRecord): void {
+    const loop = record.state.loop;
+    if (!loop) {
+      return;
+    }
+    this.emitEvent(loop.runId, {
+      id: newId(),
+      runId: loop.runId,
+      ts: nowIso(),
+      type: "loop.updated",
+      nodeId: loop.nodeId,
+      loop
+    });
+  }
+
+  private requireRun(runId: UUID): RunRecord {
+    const record = this.store.getRun(runId);
+    if (!record) {
+      throw new Error(`Run ${runId} not found`);
+    }
+    return record;
+  }
+}
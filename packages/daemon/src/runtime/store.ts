@@ -149,6 +149,9 @@ export class RunStore {
   addApproval(runId: UUID, approval: ApprovalRequest): void {
     const record = this.requireRun(runId);
     record.approvals.set(approval.approvalId, approval);
+    // Mirrored into state so run snapshots carry pending approvals
+    // across a daemon restart.
+    record.state.approvals = { ...(record.state.approvals ?? {}), [approval.approvalId]: approval };
   }
 
   resolveApproval(runId: UUID, approvalId: UUID): ApprovalRequest | undefined {
@@ -156,6 +159,7 @@ export class RunStore {
     const approval = record.approvals.get(approvalId);
     if (approval) {
       record.approvals.delete(approvalId);
+      this.removeApprovalFromState(record, approvalId);
     }
     return approval;
   }
@@ -170,17 +174,36 @@ export class RunStore {
     return approvals;
   }
 
+  findApprovalById(approvalId: UUID): { runId: UUID; approval: ApprovalRequest } | undefined {
+    for (const record of this.runs.values()) {
+      const approval = record.approvals.get(approvalId);
+      if (approval) {
+        return { runId: record.state.id, approval };
+      }
+    }
+    return undefined;
+  }
+
   resolveApprovalById(approvalId: UUID): { runId: UUID; approval: ApprovalRequest } | undefined {
     for (const record of this.runs.values()) {
       const approval = record.approvals.get(approvalId);
       if (approval) {
         record.approvals.delete(approvalId);
+        this.removeApprovalFromState(record, approvalId);
         return { runId: record.state.id, approval };
       }
     }
     return undefined;
   }
 
+  private removeApprovalFromState(record: RunRecord, approvalId: UUID): void {
+    if (!record.state.approvals) {
+      return;
+    }
+    const { [approvalId]: _resolved, ...remaining } = record.state.approvals;
+    record.state.approvals = Object.keys(remaining).length > 0 ? remaining : undefined;
+  }
+
   enqueueEnvelope(runId: UUID, nodeId: UUID, envelope: Envelope): void {
     const record = this.requireRun(runId);
     const nodeRecord = this.requireNode(record, nodeId);
@@ -6,6 +6,7 @@
  */
 
 import {
+  getPluginTools,
   getToolRegistry,
   getVuhlpOnlyToolNames,
   getVuhlpToolNames,
@@ -54,6 +55,22 @@ export const CLI_TOOL_PROTOCOL_VUHLP = [
   "send_handoff requires to + message and an existing edge between nodes; optional structured, artifacts, status, response, contextRef."
 ].join("\n");
 
+/**
+ * Plugin tools are registered at startup (VUHLP_PLUGINS), so their
+ * schemas are appended to the protocol per prompt rather than baked into
+ * the constants above.
+ */
+export function pluginToolProtocolLines(): string[] {
+  const plugins = getPluginTools();
+  if (plugins.length === 0) {
+    return [];
+  }
+  return [
+    "Plugin tools (org-specific; call via tool_call JSON):",
+    ...plugins.map((plugin) => `${plugin.protocolSchema} - ${plugin.description}`)
+  ];
+}
+
 /**
  * Tool protocol for provider-native tool handling
  * Used when nativeToolHandling is 'provider'
@@ -0,0 +1,201 @@
+import crypto from "crypto";
+import { promises as fs } from "fs";
+import path from "path";
+import type { EventEnvelope, UUID, WebhookSubscription } from "@vuhlp/contracts";
+import type { Logger } from "@vuhlp/providers";
+import { newId, nowIso } from "./utils.js";
+import { inQuietHours } from "./quiet-hours.js";
+
+const MAX_DELIVERY_ATTEMPTS = 3;
+const DELIVERY_TIMEOUT_MS = 10_000;
+const BACKOFF_BASE_MS = 1_000;
+
+const delay = (ms: number): Promise<void> => new Promise((resolve) => setTimeout(resolve, ms));
+
+interface StoredWebhook extends WebhookSubscription {
+  /** HMAC signing secret; returned once from create and never from list. */
+  secret?: string;
+}
+
+/**
+ * Delivers run events to registered webhook URLs so external systems can
+ * react without holding a WebSocket open.
+ *
+ * Subscriptions persist to dataDir/webhooks.json. Deliveries are fire-and-
+ * forget with exponential backoff; payloads are HMAC-signed (sha256) when
+ * the subscription has a secret.
+ */
+export class WebhookDispatcher {
+  private readonly manifestPath: string;
+  private readonly logger?: Logger;
+  private webhooks: StoredWebhook[] = [];
+  private loaded = false;
+
+  constructor(dataDir: string, logger?: Logger) {
+    this.manifestPath = path.join(dataDir, "webhooks.json");
+    this.logger = logger;
+  }
+
+  /** Re-reads the manifest from disk; used by the admin reload endpoint. */
+  async reload(): Promise<number> {
+    this.loaded = false;
+    await this.ensureLoaded();
+    this.logger?.info("webhooks manifest reloaded", { count: this.webhooks.length });
+    return this.webhooks.length;
+  }
+
+  /** Subscriptions without their signing secrets — readable by read-scope keys. */
+  async list(): Promise<WebhookSubscription[]> {
+    await this.ensureLoaded();
+    return this.webhooks.map(({ secret: _secret, ...info }) => info);
+  }
+
+  async create(input: {
+    url: string;
+    secret?: string;
+    events?: string[];
+  }): Promise<{ webhook: WebhookSubscription; secret?: string }> {
+    await this.ensureLoaded();
+    const url = input.url?.trim();
+    if (!url) {
+      throw new Error("webhook url is required");
+    }
+    let parsed: URL;
+    try {
+      parsed = new URL(url);
+    } catch {
+      throw new Error(`invalid webhook url: ${url}`);
+    }
+    if (parsed.protocol !== "http:" && parsed.protocol !== "https:") {
+      throw new Error(`webhook url must be http or https: ${url}`);
+    }
+    const stored: StoredWebhook = {
+      id: newId(),
+      url,
+      secret: input.secret,
+      events: input.events?.filter((type) => type.trim().length > 0),
+      createdAt: nowIso()
+    };
+    this.webhooks.push(stored);
+    await this.persist();
+    this.logger?.info("webhook registered", { webhookId: stored.id, url, events: stored.events });
+    const { secret, ...webhook } = stored;
+    return { webhook, secret };
+  }
+
+  async delete(webhookId: UUID): Promise<void> {
+    await this.ensureLoaded();
+    const index = this.webhooks.findIndex((webhook) => webhook.id === webhookId);
+    if (index === -1) {
+      throw new Error(`Webhook ${webhookId} not found`);
+    }
+    this.webhooks.splice(index, 1);
+    await this.persist();
+    this.logger?.info("webhook deleted", { webhookId });
+  }
+
+  dispatch(event: EventEnvelope): void {
+    if (inQuietHours()) {
+      this.logger?.debug("webhook delivery suppressed during quiet hours", { type: event.type });
+      return;
+    }
+    if (!this.loaded && this.webhooks.length === 0) {
+      // Load lazily on the first event; deliveries for this event still go out.
+      void this.ensureLoaded().then(() => this.dispatchLoaded(event));
+      return;
+    }
+    this.dispatchLoaded(event);
+  }
+
+  private dispatchLoaded(event: EventEnvelope): void {
+    for (const webhook of this.webhooks) {
+      if (webhook.events && webhook.events.length > 0 && !webhook.events.includes(event.type)) {
+        continue;
+      }
+      void this.deliver(webhook, event);
+    }
+  }
+
+  private async deliver(webhook: StoredWebhook, event: EventEnvelope): Promise<void> {
+    const body = JSON.stringify({ event });
+    const headers: Record<string, string> = {
+      "Content-Type": "application/json",
+      "X-Vuhlp-Event": event.type,
+      "X-Vuhlp-Delivery": newId()
+    };
+    if (webhook.secret) {
+      const signature = crypto.createHmac("sha256", webhook.secret).update(body).digest("hex");
+      headers["X-Vuhlp-Signature"] = `sha256=${signature}`;
+    }
+    for (let attempt = 1; attempt <= MAX_DELIVERY_ATTEMPTS; attempt += 1) {
+      try {
+        const controller = new AbortController();
+        const timer = setTimeout(() => controller.abort(), DELIVERY_TIMEOUT_MS);
+        try {
+          const response = await fetch(webhook.url, {
+            method: "POST",
+            headers,
+            body,
+            signal: controller.signal
+          });
+          if (response.ok) {
+            this.logger?.debug("webhook delivered", {
+              webhookId: webhook.id,
+              type: event.type,
+              status: response.status,
+              attempt
+            });
+            return;
+          }
+          this.logger?.warn("webhook delivery rejected", {
+            webhookId: webhook.id,
+            type: event.type,
+            status: response.status,
+            attempt
+          });
+        } finally {
+          clearTimeout(timer);
+        }
+      } catch (error) {
+        const message = error instanceof Error ? error.message : String(error);
+        this.logger?.warn("webhook delivery failed", {
+          webhookId: webhook.id,
+          type: event.type,
+          attempt,
+          message
+        });
+      }
+      if (attempt < MAX_DELIVERY_ATTEMPTS) {
+        await delay(BACKOFF_BASE_MS * 2 ** (attempt - 1));
+      }
+    }
+    this.logger?.error("webhook delivery gave up", {
+      webhookId: webhook.id,
+      type: event.type,
+      attempts: MAX_DELIVERY_ATTEMPTS
+    });
+  }
+
+  private async persist(): Promise<void> {
+    await fs.mkdir(path.dirname(this.manifestPath), { recursive: true });
+    await fs.writeFile(this.manifestPath, JSON.stringify(this.webhooks, null, 2), "utf8");
+  }
+
+  private async ensureLoaded(): Promise<void> {
+    if (this.loaded) {
+      return;
+    }
+    this.loaded = true;
+    try {
+      const contents = await fs.readFile(this.manifestPath, "utf8");
+      this.webhooks = JSON.parse(contents) as StoredWebhook[];
+    } catch (error) {
+      const code = (error as NodeJS.ErrnoException).code;
+      if (code !== "ENOENT") {
+        const message = error instanceof Error ? error.message : String(error);
+        this.logger?.warn("failed to load webhooks manifest", { message });
+      }
+      this.webhooks = [];
+    }
+  }
+}
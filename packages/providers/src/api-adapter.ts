@@ -18,6 +18,9 @@ import type {
 } from "./types.js";
 import { executeToolCall, type ToolExecutionResult } from "./tool-runner.js";
 import type { ModelProvider, ModelResponse, TokenUsage } from "./providers/base.js";
+import { resolveEnvSet } from "./utils/env-sets.js";
+import { ProviderCallLog } from "./utils/provider-log.js";
+import { StreamStallError } from "./utils/streaming.js";
 import { ClaudeProvider } from "./providers/claude.js";
 import { GeminiProvider } from "./providers/gemini.js";
 import { OpenAIProvider } from "./providers/openai.js";
@@ -66,6 +69,7 @@ export class ApiProviderAdapter implements ProviderAdapter {
 
   private currentResponseId: UUID | null = null;
   private readonly debug = process.env.VUHLP_DEBUG_API === "true";
+  private readonly callLog: ProviderCallLog | null;
 
   private withRunMeta(meta?: LogMeta): LogMeta {
     return { ...(meta ?? {}), runId: this.config.runId };
@@ -75,6 +79,7 @@ export class ApiProviderAdapter implements ProviderAdapter {
     this.config = config;
     this.logger = logger;
     this.sessionId = randomUUID();
+    this.callLog = ProviderCallLog.fromEnv(logger);
 
     switch (config.provider) {
       case "claude":
@@ -281,14 +286,22 @@ export class ApiProviderAdapter implements ProviderAdapter {
 
   private async runTool(tool: ToolCall): Promise<ToolExecutionResult> {
     this.emitEvent(normalizeCliEvent(this.eventContext(), { type: "tool.started", tool }));
+    const envSet = this.config.envSet ? resolveEnvSet(this.config.envSet, this.logger) : null;
     const result = await executeToolCall(tool, {
       cwd: this.config.cwd ?? process.cwd(),
+      ownerId: this.config.nodeId,
+      env: envSet?.env,
+      secrets: envSet?.secrets,
+      sandboxImage: this.config.sandboxImage,
+      readOnly: this.config.readOnly,
       capabilities: this.config.capabilities,
       globalMode: this.config.globalMode,
       defaultProvider: this.config.provider,
       spawnNode: this.config.spawnNode,
       createEdge: this.config.createEdge,
       sendHandoff: this.config.sendHandoff,
+      spawnSubagent: this.config.spawnSubagent,
+      updateTodos: this.config.updateTodos,
       logger: this.logger
     });
     this.emitEvent(
@@ -328,13 +341,81 @@ export class ApiProviderAdapter implements ProviderAdapter {
   }
 
   private async callModel(): Promise<ModelResponse> {
-    return this.provider.call({
-      onDelta: (delta: string) => this.emitDelta(delta),
-      onThinkingDelta: (delta: string) => this.emitThinkingDelta(delta),
-      onThinkingFinal: (content: string) => this.emitThinkingFinal(content),
-      onUsage: (usage: TokenUsage) => this.emitUsage(usage),
-      debugLog: (message: string, meta?: LogMeta) => this.debugLog(message, meta)
-    });
+    try {
+      return await this.callModelOnce();
+    } catch (error) {
+      if (!(error instanceof StreamStallError)) {
+        throw error;
+      }
+      // One retry on a stalled stream before failing the turn.
+      this.emitStreamStalled(error, true);
+      this.logger.warn(
+        "model stream stalled; retrying once",
+        this.withRunMeta({ elapsedMs: error.elapsedMs, timeoutMs: error.timeoutMs })
+      );
+      try {
+        return await this.callModelOnce();
+      } catch (retryError) {
+        if (retryError instanceof StreamStallError) {
+          this.emitStreamStalled(retryError, false);
+        }
+        throw retryError;
+      }
+    }
+  }
+
+  private emitStreamStalled(error: StreamStallError, willRetry: boolean): void {
+    this.emitEvent(
+      normalizeCliEvent(this.eventContext(), {
+        type: "model.stream.stalled",
+        elapsedMs: error.elapsedMs,
+        timeoutMs: error.timeoutMs,
+        willRetry
+      })
+    );
+  }
+
+  private async callModelOnce(): Promise<ModelResponse> {
+    const startedAt = Date.now();
+    let lastUsage: TokenUsage | undefined;
+    try {
+      const response = await this.provider.call({
+        onDelta: (delta: string) => this.emitDelta(delta),
+        onThinkingDelta: (delta: string) => this.emitThinkingDelta(delta),
+        onThinkingFinal: (content: string) => this.emitThinkingFinal(content),
+        onUsage: (usage: TokenUsage) => {
+          lastUsage = usage;
+          this.emitUsage(usage);
+        },
+        debugLog: (message: string, meta?: LogMeta) => this.debugLog(message, meta)
+      });
+      this.callLog?.record({
+        ts: nowIso(),
+        provider: this.config.provider,
+        model: this.config.model,
+        runId: this.config.runId,
+        nodeId: this.config.nodeId,
+        latencyMs: Date.now() - startedAt,
+        outcome: "ok",
+        toolCallCount: response.toolCalls.length,
+        usage: lastUsage,
+        responseText: response.text
+      });
+      return response;
+    } catch (error) {
+      this.callLog?.record({
+        ts: nowIso(),
+        provider: this.config.provider,
+        model: this.config.model,
+        runId: this.config.runId,
+        nodeId: this.config.nodeId,
+        latencyMs: Date.now() - startedAt,
+        outcome: "error",
+        usage: lastUsage,
+        error: error instanceof Error ? error.message : String(error)
+      });
+      throw error;
+    }
   }
 
   private emitDelta(delta: string): void {
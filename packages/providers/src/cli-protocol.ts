@@ -18,7 +18,8 @@ export type ParsedCliEvent =
     model: string;
     usage: UsageTotals;
   }
-  | { type: "message.user"; message: ParsedUserMessage };
+  | { type: "message.user"; message: ParsedUserMessage }
+  | { type: "model.stream.stalled"; elapsedMs: number; timeoutMs: number; willRetry: boolean };
 
 export interface ParsedUserMessage {
   role: "user";
@@ -5,3 +5,14 @@ export * from "./api-adapter.js";
 export * from "./tool-runner.js";
 export * from "./provider-factory.js";
 export * from "./utils/tools.js";
+export * from "./utils/guardrails.js";
+export * from "./utils/code-review.js";
+export * from "./utils/env-sets.js";
+export * from "./utils/patch.js";
+export * from "./utils/plugins.js";
+export * from "./utils/processes.js";
+export * from "./utils/protected-paths.js";
+export * from "./utils/sql.js";
+export * from "./utils/tool-config.js";
+export * from "./utils/workspace-policy.js";
+export * from "./utils/provider-log.js";
@@ -6,6 +6,7 @@ import type {
   MessageAssistantFinalEvent,
   MessageAssistantThinkingDeltaEvent,
   MessageAssistantThinkingFinalEvent,
+  ModelStreamStalledEvent,
   TelemetryUsageEvent,
   ToolCompletedEvent,
   ToolProposedEvent,
@@ -141,6 +142,19 @@ export function normalizeCliEvent(context: EventContext, event: ParsedCliEvent):
       };
       return envelope;
     }
+    case "model.stream.stalled": {
+      const envelope: ModelStreamStalledEvent = {
+        id: context.makeId(),
+        runId: context.runId,
+        ts: context.now(),
+        type: event.type,
+        nodeId: context.nodeId,
+        elapsedMs: event.elapsedMs,
+        timeoutMs: event.timeoutMs,
+        willRetry: event.willRetry
+      };
+      return envelope;
+    }
     case "message.user": {
       const envelope: MessageUserEvent = {
         id: context.makeId(),
@@ -8,11 +8,15 @@ import { streamSse } from "../utils/streaming.js";
 import { claudeToolDefinitions } from "../utils/tools.js";
 import type { ModelProvider, ModelProviderCallbacks, ModelResponse } from "./base.js";
 
+type ClaudeResultBlock =
+    | { type: "text"; text: string }
+    | { type: "image"; source: { type: "base64"; media_type: string; data: string } };
+
 type ClaudeContentBlock =
     | { type: "text"; text: string }
     | { type: "thinking"; thinking: string }
     | { type: "tool_use"; id: string; name: string; input: Record<string, unknown> }
-    | { type: "tool_result"; tool_use_id: string; content: string; is_error?: boolean };
+    | { type: "tool_result"; tool_use_id: string; content: string | ClaudeResultBlock[]; is_error?: boolean };
 
 type ClaudeMessage = {
     role: "user" | "assistant";
@@ -34,13 +38,22 @@ export class ClaudeProvider implements ModelProvider {
             output: result.output,
             error: result.error
         };
+        const content: string | ClaudeResultBlock[] = result.image
+            ? [
+                  { type: "text", text: JSON.stringify(payload) },
+                  {
+                      type: "image",
+                      source: { type: "base64", media_type: result.image.mimeType, data: result.image.base64 }
+                  }
+              ]
+            : JSON.stringify(payload);
         this.history.push({
             role: "user",
             content: [
                 {
                     type: "tool_result",
                     tool_use_id: tool.id,
-                    content: JSON.stringify(payload),
+                    content,
                     is_error: !result.ok
                 }
             ]
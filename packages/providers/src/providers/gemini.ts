@@ -10,6 +10,7 @@ import type { ModelProvider, ModelProviderCallbacks, ModelResponse } from "./bas
 
 type GeminiPart =
     | { text: string }
+    | { inlineData: { mimeType: string; data: string } }
     | { functionCall: { name: string; args: Record<string, unknown> } }
     | { functionResponse: { name: string; response: Record<string, unknown> } };
 
@@ -39,17 +40,18 @@ export class GeminiProvider implements ModelProvider {
             output: result.output,
             error: result.error
         };
-        this.history.push({
-            role: "user",
-            parts: [
-                {
-                    functionResponse: {
-                        name: tool.name,
-                        response: payload
-                    }
+        const parts: GeminiPart[] = [
+            {
+                functionResponse: {
+                    name: tool.name,
+                    response: payload
                 }
-            ]
-        });
+            }
+        ];
+        if (result.image) {
+            parts.push({ inlineData: { mimeType: result.image.mimeType, data: result.image.base64 } });
+        }
+        this.history.push({ role: "user", parts });
     }
 
     resetHistory(): void {
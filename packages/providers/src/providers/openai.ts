@@ -20,8 +20,13 @@ type OpenAIUsage = {
     total_tokens: number;
 };
 
+type OpenAIUserPart =
+    | { type: "text"; text: string }
+    | { type: "image_url"; image_url: { url: string } };
+
 type OpenAIMessage =
     | { role: "system" | "user"; content: string }
+    | { role: "user"; content: OpenAIUserPart[] }
     | { role: "assistant"; content: string | null; tool_calls?: OpenAIToolCall[] }
     | { role: "tool"; tool_call_id: string; content: string };
 
@@ -45,6 +50,20 @@ export class OpenAIProvider implements ModelProvider {
             tool_call_id: tool.id,
             content: JSON.stringify(payload)
         });
+        if (result.image) {
+            // Tool messages are text-only in the chat completions API;
+            // the image rides a follow-up user message as a vision part.
+            this.history.push({
+                role: "user",
+                content: [
+                    { type: "text", text: `Image returned by ${tool.name} (${tool.id}):` },
+                    {
+                        type: "image_url",
+                        image_url: { url: `data:${result.image.mimeType};base64,${result.image.base64}` }
+                    }
+                ]
+            });
+        }
     }
 
     resetHistory(): void {
@@ -4,12 +4,14 @@ import path from "node:path";
 import { promisify } from "node:util";
 import type {
   ArtifactRef,
+  FileAccess,
   GlobalMode,
   HandoffResponse,
   HandoffResponseExpectation,
   HandoffStatus,
   NodeCapabilities,
   ProviderName,
+  TodoItem,
   ToolCall
 } from "@vuhlp/contracts";
 import { getString, isJsonObject, parseJsonValue, type JsonObject } from "./json.js";
@@ -19,20 +21,61 @@ import type {
   SendHandoffHandler,
   SendHandoffRequest,
   SpawnNodeHandler,
-  SpawnNodeRequest
+  SpawnNodeRequest,
+  SpawnSubagentHandler,
+  SpawnSubagentRequest,
+  UpdateTodosHandler
 } from "./types.js";
 import type { Logger } from "./logger.js";
+import { describeBinary, imageMimeType, isBinaryContent } from "./utils/binary.js";
+import { browserCapture } from "./utils/browser.js";
+import { discoverBuildTargets } from "./utils/build-targets.js";
+import { reviewConfigured, reviewDiff } from "./utils/code-review.js";
+import { parseChangedLines, parseCoverageSummary, parseGoCoverProfile, uncoveredInDiff } from "./utils/coverage.js";
+import { runDiagnostics } from "./utils/diagnostics.js";
+import { redactSecrets } from "./utils/env-sets.js";
+import { globPaths } from "./utils/glob.js";
+import { applyOutputGuardrails } from "./utils/guardrails.js";
+import { checkDomainAllowed, httpFetch } from "./utils/http-fetch.js";
+import { appendMemory, readMemory } from "./utils/memory.js";
+import { applyOutputLimit, readSpooledOutput, toolOutputLimit } from "./utils/output-limit.js";
+import { applyHunks, contentFromCreation, parseUnifiedDiff } from "./utils/patch.js";
+import { findPluginTool, invokePluginTool } from "./utils/plugins.js";
+import { getProcess, startProcess, stopProcess, tailLog } from "./utils/processes.js";
+import { isProtectedPath } from "./utils/protected-paths.js";
+import { wrapWithRlimits } from "./utils/resource-limits.js";
+import { sandboxCommand, validSandboxImage } from "./utils/sandbox.js";
+import { isSqlMutation, runSqlQuery, sqlDsnFor } from "./utils/sql.js";
+import { toolEnabled, toolOutputLimitOverride, toolTimeout } from "./utils/tool-config.js";
+import { parseTestOutput } from "./utils/test-results.js";
+import type { WorkspacePolicy } from "./utils/workspace-policy.js";
 
 const exec = promisify(execCallback);
 
 export interface ToolExecutionOptions {
   cwd: string;
+  /** Session/node id owning spawned background processes, for cleanup. */
+  ownerId?: string;
+  /** Extra variables injected into command-running tools. */
+  env?: Record<string, string>;
+  /** Values redacted from every tool result before it leaves the runner. */
+  secrets?: string[];
+  /** Docker image; when set, command and run_tests run inside a sandbox container. */
+  sandboxImage?: string;
+  /** Rejects every write/exec tool, for zero-risk explain-the-codebase sessions. */
+  readOnly?: boolean;
+  /** Set when a human approved this specific call; lifts protected-path enforcement. */
+  elevated?: boolean;
+  /** Policy loaded from .vuhlp/policy.json at the workspace root. */
+  policy?: WorkspacePolicy;
   capabilities?: NodeCapabilities;
   globalMode?: GlobalMode;
   defaultProvider?: ProviderName;
   spawnNode?: SpawnNodeHandler;
   createEdge?: CreateEdgeHandler;
   sendHandoff?: SendHandoffHandler;
+  spawnSubagent?: SpawnSubagentHandler;
+  updateTodos?: UpdateTodosHandler;
   logger?: Logger;
 }
 
@@ -40,10 +83,59 @@ export interface ToolExecutionResult {
   ok: boolean;
   output: string;
   error?: string;
+  /** Present when prompt-injection guardrails flagged and wrapped the output. */
+  guardrail?: { reasons: string[] };
+  /** File paths the tool touched, for per-turn audit manifests. */
+  files?: FileAccess[];
+  /**
+   * Present when read_file hit an image: API providers attach it as a
+   * vision part instead of mangled text. CLI providers only see the
+   * textual summary in `output`.
+   */
+  image?: { mimeType: string; base64: string };
 }
 
 const DOCS_ROOT = "docs";
 
+function toolEnv(options: ToolExecutionOptions): NodeJS.ProcessEnv | undefined {
+  if (!options.env || Object.keys(options.env).length === 0) {
+    return undefined;
+  }
+  return { ...process.env, ...options.env };
+}
+
+const DEFAULT_DOCKER_TIMEOUT = 300_000;
+
+function dockerTimeout(): number {
+  const raw = Number(process.env.VUHLP_DOCKER_TIMEOUT);
+  return Number.isFinite(raw) && raw > 0 ? raw : DEFAULT_DOCKER_TIMEOUT;
+}
+
+/**
+ * Runs a shell command on the host, or inside the run's sandbox
+ * container when sandboxImage is set. Sandboxed commands see only the
+ * env-set variables - the host environment and credentials stay out.
+ */
+async function execShell(
+  cmd: string,
+  cwd: string,
+  root: string,
+  options: ToolExecutionOptions
+): Promise<{ stdout: string; stderr: string }> {
+  if (options.sandboxImage) {
+    if (!validSandboxImage(options.sandboxImage)) {
+      throw new Error(`invalid sandbox image: ${options.sandboxImage}`);
+    }
+    options.logger?.info("executing command in sandbox", { image: options.sandboxImage, cwd });
+    return exec(sandboxCommand(options.sandboxImage, root, cwd, cmd, options.env), {
+      cwd: root,
+      timeout: dockerTimeout(),
+      maxBuffer: 10 * 1024 * 1024
+    });
+  }
+  return exec(wrapWithRlimits(cmd), { cwd, env: toolEnv(options), maxBuffer: 10 * 1024 * 1024 });
+}
+
 function normalizeRoot(root: string): string {
   return path.resolve(root);
 }
@@ -65,6 +157,9 @@ function isDocsPath(root: string, target: string): boolean {
 }
 
 function canRunCommands(options: ToolExecutionOptions): string | null {
+  if (options.readOnly) {
+    return "workspace is read-only (run has readOnly set)";
+  }
   if (!options.capabilities) {
     return null;
   }
@@ -78,6 +173,9 @@ function canRunCommands(options: ToolExecutionOptions): string | null {
 }
 
 function canWritePath(options: ToolExecutionOptions, target: string): string | null {
+  if (options.readOnly) {
+    return "workspace is read-only (run has readOnly set)";
+  }
   if (!options.capabilities && !options.globalMode) {
     return null;
   }
@@ -96,6 +194,12 @@ function canWritePath(options: ToolExecutionOptions, target: string): string | n
   } else if (options.capabilities && !options.capabilities.writeCode) {
     return "writeCode capability is disabled";
   }
+  if (!options.elevated) {
+    const rel = path.relative(normalizeRoot(root), resolvePath(root, target));
+    if (isProtectedPath(rel, options.policy?.protectedPaths)) {
+      return `path is protected (VUHLP_PROTECTED_PATHS or workspace policy) and requires approval: ${rel}`;
+    }
+  }
   return null;
 }
 
@@ -107,6 +211,9 @@ function guardEdgeManagement(options: ToolExecutionOptions, toolName: string): s
   if (toolName === "spawn_node") {
     return scope === "all" ? null : "edgeManagement=all required to spawn nodes";
   }
+  if (toolName === "spawn_subagent") {
+    return scope === "all" ? null : "edgeManagement=all required to spawn subagents";
+  }
   if (toolName === "create_edge") {
     return scope === "none" ? "edgeManagement capability is disabled" : null;
   }
@@ -269,6 +376,27 @@ function buildSpawnRequest(args: Record<string, unknown>, defaultProvider?: Prov
   };
 }
 
+function parseTodoItems(value: unknown): TodoItem[] | null {
+  if (!Array.isArray(value)) {
+    return null;
+  }
+  const todos: TodoItem[] = [];
+  for (const item of value) {
+    if (!isRecord(item)) {
+      return null;
+    }
+    const { content, status, activeForm } = item;
+    if (typeof content !== "string" || typeof activeForm !== "string") {
+      return null;
+    }
+    if (status !== "pending" && status !== "in_progress" && status !== "completed") {
+      return null;
+    }
+    todos.push({ content, status, activeForm });
+  }
+  return todos.length > 0 ? todos : null;
+}
+
 function buildCreateEdgeRequest(args: Record<string, unknown>): CreateEdgeRequest | null {
   const from = typeof args.from === "string" ? args.from.trim() : "";
   const to = typeof args.to === "string" ? args.to.trim() : "";
@@ -284,6 +412,70 @@ function buildCreateEdgeRequest(args: Record<string, unknown>): CreateEdgeReques
 export async function executeToolCall(
   tool: ToolCall,
   options: ToolExecutionOptions
+): Promise<ToolExecutionResult> {
+  if (!toolEnabled(tool.name)) {
+    return { ok: false, output: "", error: `tool ${tool.name} is disabled (VUHLP_TOOLS_DISABLED)` };
+  }
+  if (options.policy?.toolsDisabled?.includes(tool.name)) {
+    return { ok: false, output: "", error: `tool ${tool.name} is disabled by workspace policy (.vuhlp/policy.json)` };
+  }
+  const raw = await runToolCallWithTimeout(tool, options);
+  // Secrets are scrubbed first so they never reach guardrail logs,
+  // events, artifacts, or the model.
+  const result =
+    options.secrets && options.secrets.length > 0
+      ? {
+          ...raw,
+          output: redactSecrets(raw.output, options.secrets),
+          error: raw.error !== undefined ? redactSecrets(raw.error, options.secrets) : undefined
+        }
+      : raw;
+  const guarded = applyOutputGuardrails(tool.name, result, options.logger);
+  if (tool.name === "read_tool_output") {
+    // The paging tool already returns limit-sized chunks; re-truncating
+    // it would make the remainder unreachable.
+    return guarded;
+  }
+  return applyOutputLimit(
+    tool.id,
+    guarded,
+    normalizeRoot(options.cwd ?? process.cwd()),
+    options.logger,
+    toolOutputLimitOverride(tool.name)
+  );
+}
+
+/**
+ * Applies the per-tool timeout (VUHLP_TOOL_TIMEOUT_<NAME>) when one is
+ * configured. The losing execution is not killed - node cannot cancel an
+ * arbitrary async operation - but its result is discarded and the model
+ * gets a visible timeout error instead of hanging the turn.
+ */
+async function runToolCallWithTimeout(
+  tool: ToolCall,
+  options: ToolExecutionOptions
+): Promise<ToolExecutionResult> {
+  const timeout = toolTimeout(tool.name);
+  if (timeout === null) {
+    return runToolCall(tool, options);
+  }
+  let timer: NodeJS.Timeout | undefined;
+  const expired = new Promise<ToolExecutionResult>((resolve) => {
+    timer = setTimeout(() => {
+      options.logger?.warn("tool execution timed out", { tool: tool.name, toolId: tool.id, timeout });
+      resolve({ ok: false, output: "", error: `${tool.name} timed out after ${timeout}ms` });
+    }, timeout);
+  });
+  try {
+    return await Promise.race([runToolCall(tool, options), expired]);
+  } finally {
+    clearTimeout(timer);
+  }
+}
+
+async function runToolCall(
+  tool: ToolCall,
+  options: ToolExecutionOptions
 ): Promise<ToolExecutionResult> {
   const root = normalizeRoot(options.cwd ?? process.cwd());
 
@@ -300,10 +492,7 @@ export async function executeToolCall(
       }
       try {
         const cwd = resolvePath(root, cwdInput);
-        const result = await exec(cmd, {
-          cwd,
-          maxBuffer: 10 * 1024 * 1024
-        });
+        const result = await execShell(cmd, cwd, root, options);
         const output = [result.stdout, result.stderr].filter(Boolean).join("");
         const toolCallLine = findToolCallJsonLine(output);
         if (toolCallLine) {
@@ -330,8 +519,22 @@ export async function executeToolCall(
       }
       try {
         const resolved = resolvePath(root, target);
-        const content = await fs.readFile(resolved, "utf8");
-        return { ok: true, output: content };
+        const buffer = await fs.readFile(resolved);
+        const files: FileAccess[] = [{ path: target, op: "read" }];
+        const mime = imageMimeType(target);
+        if (mime) {
+          options.logger?.info("read_file returning image", { path: target, mime, bytes: buffer.length });
+          return {
+            ok: true,
+            output: `image ${target} (${mime}, ${buffer.length} bytes) attached`,
+            image: { mimeType: mime, base64: buffer.toString("base64") },
+            files
+          };
+        }
+        if (isBinaryContent(buffer)) {
+          return { ok: true, output: describeBinary(target, buffer), files };
+        }
+        return { ok: true, output: buffer.toString("utf8"), files };
       } catch (error) {
         const message = error instanceof Error ? error.message : String(error);
         return { ok: false, output: "", error: message };
@@ -352,7 +555,103 @@ export async function executeToolCall(
         const resolved = resolvePath(root, target);
         await fs.mkdir(path.dirname(resolved), { recursive: true });
         await fs.writeFile(resolved, content, "utf8");
-        return { ok: true, output: `wrote ${target}` };
+        const diagnostics = await runDiagnostics(root, [target], options.logger);
+        const output = diagnostics ? `wrote ${target}\n\ndiagnostics:\n${diagnostics}` : `wrote ${target}`;
+        return { ok: true, output, files: [{ path: target, op: "write" }] };
+      } catch (error) {
+        const message = error instanceof Error ? error.message : String(error);
+        return { ok: false, output: "", error: message };
+      }
+    }
+
+    case "edit_file": {
+      const target = typeof tool.args.path === "string" ? tool.args.path : null;
+      const oldStr = typeof tool.args.old_str === "string" ? tool.args.old_str : null;
+      const newStr = typeof tool.args.new_str === "string" ? tool.args.new_str : null;
+      const replaceAll = tool.args.replaceAll === true;
+      if (!target || oldStr === null || newStr === null) {
+        return { ok: false, output: "", error: "edit_file requires path, old_str, and new_str" };
+      }
+      if (oldStr.length === 0) {
+        return { ok: false, output: "", error: "edit_file old_str must not be empty" };
+      }
+      if (oldStr === newStr) {
+        return { ok: false, output: "", error: "edit_file old_str and new_str are identical" };
+      }
+      const guard = canWritePath(options, target);
+      if (guard) {
+        return { ok: false, output: "", error: guard };
+      }
+      try {
+        const resolved = resolvePath(root, target);
+        const content = await fs.readFile(resolved, "utf8");
+        const occurrences = content.split(oldStr).length - 1;
+        if (occurrences === 0) {
+          return { ok: false, output: "", error: `old_str not found in ${target}` };
+        }
+        if (occurrences > 1 && !replaceAll) {
+          return {
+            ok: false,
+            output: "",
+            error: `old_str matches ${occurrences} times in ${target}; add surrounding context or set replaceAll`
+          };
+        }
+        const updated = replaceAll ? content.split(oldStr).join(newStr) : content.replace(oldStr, newStr);
+        await fs.writeFile(resolved, updated, "utf8");
+        const diagnostics = await runDiagnostics(root, [target], options.logger);
+        const summary = `edited ${target} (${occurrences} replacement${occurrences === 1 ? "" : "s"})`;
+        return {
+          ok: true,
+          output: diagnostics ? `${summary}\n\ndiagnostics:\n${diagnostics}` : summary,
+          files: [{ path: target, op: "write" }]
+        };
+      } catch (error) {
+        const message = error instanceof Error ? error.message : String(error);
+        return { ok: false, output: "", error: message };
+      }
+    }
+
+    case "apply_patch": {
+      const patchText = typeof tool.args.patch === "string" ? tool.args.patch : null;
+      if (!patchText || patchText.trim().length === 0) {
+        return { ok: false, output: "", error: "apply_patch requires patch" };
+      }
+      try {
+        const filePatches = parseUnifiedDiff(patchText);
+        const files: FileAccess[] = [];
+        const applied: string[] = [];
+        for (const filePatch of filePatches) {
+          const target = filePatch.newPath ?? filePatch.oldPath;
+          if (!target) {
+            return { ok: false, output: "", error: "patch entry has neither old nor new path" };
+          }
+          const writeGuard = canWritePath(options, target);
+          if (writeGuard) {
+            return { ok: false, output: "", error: writeGuard };
+          }
+          const resolved = resolvePath(root, target);
+          if (filePatch.newPath === null) {
+            await fs.rm(resolved, { force: true });
+            files.push({ path: target, op: "delete" });
+            applied.push(`deleted ${target}`);
+            continue;
+          }
+          if (filePatch.oldPath === null) {
+            await fs.mkdir(path.dirname(resolved), { recursive: true });
+            await fs.writeFile(resolved, contentFromCreation(filePatch.hunks, target), "utf8");
+            files.push({ path: target, op: "write" });
+            applied.push(`created ${target}`);
+            continue;
+          }
+          const content = await fs.readFile(resolved, "utf8");
+          await fs.writeFile(resolved, applyHunks(content, filePatch.hunks, target), "utf8");
+          files.push({ path: target, op: "write" });
+          applied.push(`patched ${target}`);
+        }
+        const touched = files.filter((file) => file.op === "write").map((file) => file.path);
+        const diagnostics = touched.length > 0 ? await runDiagnostics(root, touched, options.logger) : null;
+        const output = diagnostics ? `${applied.join("\n")}\n\ndiagnostics:\n${diagnostics}` : applied.join("\n");
+        return { ok: true, output, files };
       } catch (error) {
         const message = error instanceof Error ? error.message : String(error);
         return { ok: false, output: "", error: message };
@@ -368,7 +667,7 @@ export async function executeToolCall(
           name: entry.name,
           type: entry.isDirectory() ? "dir" : "file"
         }));
-        return { ok: true, output: JSON.stringify(listing, null, 2) };
+        return { ok: true, output: JSON.stringify(listing, null, 2), files: [{ path: target, op: "list" }] };
       } catch (error) {
         const message = error instanceof Error ? error.message : String(error);
         return { ok: false, output: "", error: message };
@@ -387,13 +686,652 @@ export async function executeToolCall(
       try {
         const resolved = resolvePath(root, target);
         await fs.rm(resolved, { force: true });
-        return { ok: true, output: `deleted ${target}` };
+        return { ok: true, output: `deleted ${target}`, files: [{ path: target, op: "delete" }] };
+      } catch (error) {
+        const message = error instanceof Error ? error.message : String(error);
+        return { ok: false, output: "", error: message };
+      }
+    }
+
+    case "read_tool_output": {
+      const id = typeof tool.args.id === "string" ? tool.args.id.trim() : "";
+      if (id.length === 0) {
+        return { ok: false, output: "", error: "read_tool_output requires id" };
+      }
+      const offset = typeof tool.args.offset === "number" && tool.args.offset >= 0 ? tool.args.offset : 0;
+      const limit =
+        typeof tool.args.limit === "number" && tool.args.limit > 0 ? tool.args.limit : toolOutputLimit();
+      try {
+        const page = await readSpooledOutput(root, id, offset, limit);
+        const header =
+          page.remaining > 0
+            ? `chars ${page.offset}-${page.offset + page.chunk.length} of ${page.total} (${page.remaining} remaining)`
+            : `chars ${page.offset}-${page.total} of ${page.total} (end of output)`;
+        return { ok: true, output: `${header}\n\n${page.chunk}` };
+      } catch {
+        return { ok: false, output: "", error: `no spooled output for id: ${id}` };
+      }
+    }
+
+    case "glob": {
+      const pattern = typeof tool.args.pattern === "string" ? tool.args.pattern : null;
+      if (!pattern) {
+        return { ok: false, output: "", error: "glob requires pattern" };
+      }
+      const limit = typeof tool.args.limit === "number" && tool.args.limit > 0 ? tool.args.limit : undefined;
+      try {
+        const matches = await globPaths(root, pattern, limit);
+        return { ok: true, output: JSON.stringify(matches, null, 2) };
+      } catch (error) {
+        const message = error instanceof Error ? error.message : String(error);
+        return { ok: false, output: "", error: message };
+      }
+    }
+
+    case "move_file": {
+      const from = typeof tool.args.from === "string" ? tool.args.from : null;
+      const to = typeof tool.args.to === "string" ? tool.args.to : null;
+      if (!from || !to) {
+        return { ok: false, output: "", error: "move_file requires from and to" };
+      }
+      const guard = canWritePath(options, from) ?? canWritePath(options, to);
+      if (guard) {
+        return { ok: false, output: "", error: guard };
+      }
+      try {
+        const resolvedFrom = resolvePath(root, from);
+        const resolvedTo = resolvePath(root, to);
+        await fs.mkdir(path.dirname(resolvedTo), { recursive: true });
+        await fs.rename(resolvedFrom, resolvedTo);
+        const diagnostics = await runDiagnostics(root, [to], options.logger);
+        return {
+          ok: true,
+          output: diagnostics ? `moved ${from} -> ${to}\n\ndiagnostics:\n${diagnostics}` : `moved ${from} -> ${to}`,
+          files: [
+            { path: from, op: "delete" },
+            { path: to, op: "write" }
+          ]
+        };
+      } catch (error) {
+        const message = error instanceof Error ? error.message : String(error);
+        return { ok: false, output: "", error: message };
+      }
+    }
+
+    case "make_dir": {
+      const target = typeof tool.args.path === "string" ? tool.args.path : null;
+      if (!target) {
+        return { ok: false, output: "", error: "make_dir requires path" };
+      }
+      const guard = canWritePath(options, target);
+      if (guard) {
+        return { ok: false, output: "", error: guard };
+      }
+      try {
+        const resolved = resolvePath(root, target);
+        await fs.mkdir(resolved, { recursive: true });
+        return { ok: true, output: `created ${target}`, files: [{ path: target, op: "write" }] };
+      } catch (error) {
+        const message = error instanceof Error ? error.message : String(error);
+        return { ok: false, output: "", error: message };
+      }
+    }
+
+    case "run_tests": {
+      const guard = canRunCommands(options);
+      if (guard) {
+        return { ok: false, output: "", error: guard };
+      }
+      const cmd = typeof tool.args.cmd === "string" ? tool.args.cmd : null;
+      const cwdInput = typeof tool.args.cwd === "string" ? tool.args.cwd : root;
+      if (!cmd) {
+        return { ok: false, output: "", error: "run_tests requires cmd" };
+      }
+      let output = "";
+      let execError: string | undefined;
+      try {
+        const cwd = resolvePath(root, cwdInput);
+        const result = await execShell(cmd, cwd, root, options);
+        output = [result.stdout, result.stderr].filter(Boolean).join("");
+      } catch (error) {
+        // Failing tests exit non-zero; the output still carries results.
+        const stdout = (error as { stdout?: string }).stdout ?? "";
+        const stderr = (error as { stderr?: string }).stderr ?? "";
+        output = [stdout, stderr].filter(Boolean).join("");
+        execError = error instanceof Error ? error.message : String(error);
+      }
+      const summary = parseTestOutput(cmd, output);
+      if (!summary) {
+        if (execError && output.length === 0) {
+          return { ok: false, output: "", error: execError };
+        }
+        options.logger?.warn("run_tests could not parse output; returning raw", { cmd });
+        return { ok: !execError, output, error: execError };
+      }
+      const lines = [
+        `${summary.framework}: ${summary.passed} passed, ${summary.failed} failed, ${summary.skipped} skipped`
+      ];
+      if (summary.failures.length > 0) {
+        lines.push("", "failing tests:", ...summary.failures.map((name) => `- ${name}`));
+      }
+      return {
+        ok: summary.failed === 0 && !(execError && summary.passed === 0 && summary.failed === 0),
+        output: `${JSON.stringify(summary)}\n\n${lines.join("\n")}`,
+        error: summary.failed > 0 ? `${summary.failed} test(s) failed` : undefined
+      };
+    }
+
+    case "coverage": {
+      const guard = canRunCommands(options);
+      if (guard) {
+        return { ok: false, output: "", error: guard };
+      }
+      const cwdInput = typeof tool.args.cwd === "string" ? tool.args.cwd : root;
+      const profileInput = typeof tool.args.profile === "string" ? tool.args.profile : "coverage.out";
+      let cmd = typeof tool.args.cmd === "string" && tool.args.cmd.trim().length > 0 ? tool.args.cmd.trim() : null;
+      try {
+        const cwd = resolvePath(root, cwdInput);
+        if (!cmd) {
+          const hasGoMod = await fs
+            .access(path.join(cwd, "go.mod"))
+            .then(() => true)
+            .catch(() => false);
+          if (!hasGoMod) {
+            return { ok: false, output: "", error: "coverage requires cmd when the workspace has no go.mod" };
+          }
+          cmd = `go test ./... -coverprofile=${profileInput}`;
+        }
+        let output = "";
+        let execError: string | undefined;
+        try {
+          const result = await exec(cmd, { cwd, env: toolEnv(options), maxBuffer: 10 * 1024 * 1024 });
+          output = [result.stdout, result.stderr].filter(Boolean).join("");
+        } catch (error) {
+          // Failing tests exit non-zero but still write the profile.
+          const stdout = (error as { stdout?: string }).stdout ?? "";
+          const stderr = (error as { stderr?: string }).stderr ?? "";
+          output = [stdout, stderr].filter(Boolean).join("");
+          execError = error instanceof Error ? error.message : String(error);
+        }
+        const summary = parseCoverageSummary(output);
+        const lines: string[] = [];
+        if (summary.length > 0) {
+          lines.push("coverage:", ...summary.map((entry) => `- ${entry.subject}: ${entry.percent}%`));
+        }
+        const profilePath = path.resolve(cwd, profileInput);
+        const profile = await fs.readFile(profilePath, "utf8").catch(() => null);
+        if (profile) {
+          const diffResult = await exec(`git -C "${root}" diff -U0 HEAD`, { maxBuffer: 10 * 1024 * 1024 });
+          const missed = uncoveredInDiff(parseGoCoverProfile(profile), parseChangedLines(diffResult.stdout));
+          if (missed.length > 0) {
+            lines.push(
+              "",
+              "uncovered lines touched by the current diff:",
+              ...missed.map((range) => `- ${range.file}:${range.startLine}-${range.endLine}`)
+            );
+          } else {
+            lines.push("", "all diff-touched lines are covered");
+          }
+        }
+        if (lines.length === 0) {
+          options.logger?.warn("coverage could not parse output; returning raw", { cmd });
+          return { ok: !execError, output, error: execError };
+        }
+        return { ok: !execError, output: lines.join("\n"), error: execError };
+      } catch (error) {
+        const message = error instanceof Error ? error.message : String(error);
+        return { ok: false, output: "", error: message };
+      }
+    }
+
+    case "targets": {
+      try {
+        const targets = await discoverBuildTargets(root);
+        if (targets.length === 0) {
+          return { ok: true, output: "no Makefile targets, package.json scripts, Taskfile tasks, or justfile recipes found" };
+        }
+        const lines: string[] = [];
+        let currentSource = "";
+        for (const target of targets) {
+          if (target.source !== currentSource) {
+            currentSource = target.source;
+            if (lines.length > 0) {
+              lines.push("");
+            }
+            lines.push(`${currentSource}:`);
+          }
+          lines.push(target.detail ? `- ${target.name}: ${target.detail}` : `- ${target.name}`);
+        }
+        return { ok: true, output: lines.join("\n") };
+      } catch (error) {
+        const message = error instanceof Error ? error.message : String(error);
+        return { ok: false, output: "", error: message };
+      }
+    }
+
+    case "git_branch": {
+      const guard = canRunCommands(options);
+      if (guard) {
+        return { ok: false, output: "", error: guard };
+      }
+      const action = typeof tool.args.action === "string" ? tool.args.action : null;
+      const branch = typeof tool.args.branch === "string" ? tool.args.branch.trim() : "";
+      if (!action) {
+        return { ok: false, output: "", error: "git_branch requires action" };
+      }
+      if ((action === "create" || action === "switch") && branch.length === 0) {
+        return { ok: false, output: "", error: `git_branch ${action} requires branch` };
+      }
+      if (branch.length > 0 && !/^[a-zA-Z0-9._\/-]+$/.test(branch)) {
+        return { ok: false, output: "", error: `invalid branch name: ${branch}` };
+      }
+      const git = async (args: string): Promise<string> => {
+        const result = await exec(`git -C "${root}" ${args}`, { maxBuffer: 10 * 1024 * 1024 });
+        return [result.stdout, result.stderr].filter(Boolean).join("").trim();
+      };
+      try {
+        switch (action) {
+          case "current": {
+            const name = await git("rev-parse --abbrev-ref HEAD");
+            return { ok: true, output: name };
+          }
+          case "create": {
+            const output = await git(`checkout -b "${branch}"`);
+            return { ok: true, output: output || `created and switched to ${branch}` };
+          }
+          case "switch": {
+            // Refuse to carry uncommitted changes across branches; the
+            // model should stash (or commit) explicitly so nothing is
+            // silently mixed into the wrong branch.
+            const status = await git("status --porcelain");
+            if (status.length > 0) {
+              return {
+                ok: false,
+                output: status,
+                error: "uncommitted changes present; run git_branch stash (or commit) before switching"
+              };
+            }
+            const output = await git(`checkout "${branch}"`);
+            return { ok: true, output: output || `switched to ${branch}` };
+          }
+          case "stash": {
+            const output = await git("stash push --include-untracked");
+            return { ok: true, output: output || "nothing to stash" };
+          }
+          case "unstash": {
+            const output = await git("stash pop");
+            return { ok: true, output: output || "stash popped" };
+          }
+          default:
+            return { ok: false, output: "", error: `unsupported git_branch action: ${action}` };
+        }
+      } catch (error) {
+        const message = error instanceof Error ? error.message : String(error);
+        const stderr = (error as { stderr?: string }).stderr ?? "";
+        return { ok: false, output: stderr, error: message };
+      }
+    }
+
+    case "go_refactor": {
+      const guard = canRunCommands(options) ?? canWritePath(options, typeof tool.args.file === "string" ? tool.args.file : ".");
+      if (guard) {
+        return { ok: false, output: "", error: guard };
+      }
+      const action = typeof tool.args.action === "string" ? tool.args.action : null;
+      if (action !== "rename" && action !== "rewrite") {
+        return { ok: false, output: "", error: 'go_refactor requires action "rename" or "rewrite"' };
+      }
+      try {
+        if (action === "rename") {
+          const file = typeof tool.args.file === "string" ? tool.args.file : null;
+          const line = typeof tool.args.line === "number" ? tool.args.line : null;
+          const col = typeof tool.args.col === "number" ? tool.args.col : 1;
+          const newName = typeof tool.args.newName === "string" ? tool.args.newName.trim() : "";
+          if (!file || line === null || newName.length === 0) {
+            return { ok: false, output: "", error: "go_refactor rename requires file, line, and newName" };
+          }
+          if (!/^[A-Za-z_][A-Za-z0-9_]*$/.test(newName)) {
+            return { ok: false, output: "", error: `invalid Go identifier: ${newName}` };
+          }
+          const resolved = resolvePath(root, file);
+          const result = await exec(`gopls rename -w "${resolved}:${line}:${col}" "${newName}"`, {
+            cwd: root,
+            maxBuffer: 10 * 1024 * 1024
+          });
+          const output = [result.stdout, result.stderr].filter(Boolean).join("").trim();
+          return {
+            ok: true,
+            output: output || `renamed symbol at ${file}:${line}:${col} to ${newName}`,
+            files: [{ path: file, op: "write" }]
+          };
+        }
+        const pattern = typeof tool.args.pattern === "string" ? tool.args.pattern.trim() : "";
+        if (pattern.length === 0 || !pattern.includes("->")) {
+          return { ok: false, output: "", error: 'go_refactor rewrite requires pattern of the form "old -> new"' };
+        }
+        const dirInput = typeof tool.args.path === "string" ? tool.args.path : ".";
+        const dir = resolvePath(root, dirInput);
+        // -l lists the rewritten files so the result doubles as an audit.
+        const result = await exec(`gofmt -r '${pattern.replace(/'/g, "'\\''")}' -l -w "${dir}"`, {
+          cwd: root,
+          maxBuffer: 10 * 1024 * 1024
+        });
+        const changed = (result.stdout ?? "")
+          .split(/\r?\n/)
+          .map((entry) => entry.trim())
+          .filter((entry) => entry.length > 0)
+          .map((entry) => path.relative(root, path.resolve(root, entry)) || entry);
+        if (changed.length === 0) {
+          return { ok: true, output: "pattern matched nothing; no files changed" };
+        }
+        return {
+          ok: true,
+          output: `rewrote ${changed.length} file(s):\n${changed.join("\n")}`,
+          files: changed.map((entry) => ({ path: entry, op: "write" as const }))
+        };
+      } catch (error) {
+        const message = error instanceof Error ? error.message : String(error);
+        const stderr = (error as { stderr?: string }).stderr ?? "";
+        if (/not found|ENOENT/i.test(message)) {
+          return {
+            ok: false,
+            output: "",
+            error: "go_refactor requires the Go toolchain (gofmt, and gopls for rename) on PATH"
+          };
+        }
+        return { ok: false, output: stderr, error: message };
+      }
+    }
+
+    case "start_process": {
+      const guard = canRunCommands(options);
+      if (guard) {
+        return { ok: false, output: "", error: guard };
+      }
+      const cmd = typeof tool.args.cmd === "string" ? tool.args.cmd : null;
+      const cwdInput = typeof tool.args.cwd === "string" ? tool.args.cwd : root;
+      if (!cmd) {
+        return { ok: false, output: "", error: "start_process requires cmd" };
+      }
+      try {
+        const cwd = resolvePath(root, cwdInput);
+        const record = startProcess(cmd, cwd, options.ownerId, options.logger, toolEnv(options));
+        return {
+          ok: true,
+          output: JSON.stringify({ id: record.id, pid: record.pid, startedAt: record.startedAt })
+        };
+      } catch (error) {
+        const message = error instanceof Error ? error.message : String(error);
+        return { ok: false, output: "", error: message };
+      }
+    }
+
+    case "check_process": {
+      const id = typeof tool.args.id === "string" ? tool.args.id.trim() : "";
+      if (id.length === 0) {
+        return { ok: false, output: "", error: "check_process requires id" };
+      }
+      const record = getProcess(id);
+      if (!record) {
+        return { ok: false, output: "", error: `no such process: ${id}` };
+      }
+      const tail = typeof tool.args.tail === "number" && tool.args.tail > 0 ? tool.args.tail : undefined;
+      const status = record.exited ? `exited (code ${record.exitCode})` : "running";
+      const recent = tailLog(record, tail);
+      return {
+        ok: true,
+        output: `${record.id}: ${status} (pid ${record.pid}, started ${record.startedAt})\ncmd: ${record.cmd}` +
+          (recent.length > 0 ? `\n\nrecent output:\n${recent}` : "\n\nno output yet")
+      };
+    }
+
+    case "stop_process": {
+      const id = typeof tool.args.id === "string" ? tool.args.id.trim() : "";
+      if (id.length === 0) {
+        return { ok: false, output: "", error: "stop_process requires id" };
+      }
+      if (!stopProcess(id, options.logger)) {
+        return { ok: false, output: "", error: `no such process: ${id}` };
+      }
+      return { ok: true, output: `stopped ${id}` };
+    }
+
+    case "sql_query": {
+      const query = typeof tool.args.query === "string" ? tool.args.query : null;
+      if (!query || query.trim().length === 0) {
+        return { ok: false, output: "", error: "sql_query requires query" };
+      }
+      const db = typeof tool.args.db === "string" && tool.args.db.trim().length > 0 ? tool.args.db.trim() : "default";
+      const dsn = sqlDsnFor(db);
+      if (!dsn) {
+        return {
+          ok: false,
+          output: "",
+          error: `no DSN configured for "${db}"; set ${db === "default" ? "VUHLP_SQL_DSN" : `VUHLP_SQL_DSN_${db.toUpperCase().replace(/-/g, "_")}`}`
+        };
+      }
+      if (isSqlMutation(query) && tool.args.allowWrite !== true) {
+        return {
+          ok: false,
+          output: "",
+          error: "query mutates data; sql_query is read-only unless allowWrite is set (mutations require approval)"
+        };
+      }
+      const result = await runSqlQuery(dsn, query, options.logger);
+      return { ok: result.ok, output: result.output, error: result.error };
+    }
+
+    case "code_review": {
+      if (!reviewConfigured()) {
+        return {
+          ok: false,
+          output: "",
+          error: "code_review is not configured; set VUHLP_REVIEW_API_KEY (or VUHLP_EVAL_API_KEY)"
+        };
+      }
+      const base = typeof tool.args.base === "string" ? tool.args.base.trim() : "HEAD";
+      if (!/^[a-zA-Z0-9._~^\/-]+$/.test(base)) {
+        return { ok: false, output: "", error: `invalid base ref: ${base}` };
+      }
+      try {
+        const result = await exec(`git -C "${root}" diff "${base}"`, { maxBuffer: 10 * 1024 * 1024 });
+        const diff = result.stdout;
+        if (diff.trim().length === 0) {
+          return { ok: true, output: `no changes against ${base}; nothing to review` };
+        }
+        const findings = await reviewDiff(diff, options.logger);
+        if (!findings) {
+          return { ok: false, output: "", error: "review model call failed; see daemon log" };
+        }
+        options.logger?.info("code review completed", { base, findings: findings.length });
+        if (findings.length === 0) {
+          return { ok: true, output: `${JSON.stringify(findings)}\n\nreview clean: no findings against ${base}` };
+        }
+        const lines = findings.map(
+          (finding) =>
+            `- [${finding.severity}] ${finding.file}${finding.line !== undefined ? `:${finding.line}` : ""} ${finding.comment}`
+        );
+        const errors = findings.filter((finding) => finding.severity === "error").length;
+        return {
+          ok: errors === 0,
+          output: `${JSON.stringify(findings)}\n\n${findings.length} finding(s):\n${lines.join("\n")}`,
+          error: errors > 0 ? `${errors} error-severity finding(s); address them before completing` : undefined
+        };
       } catch (error) {
         const message = error instanceof Error ? error.message : String(error);
         return { ok: false, output: "", error: message };
       }
     }
 
+    case "memory_read": {
+      try {
+        const notes = await readMemory(root);
+        return {
+          ok: true,
+          output: notes.length > 0 ? notes : "no workspace memory recorded yet; use memory_write to add notes"
+        };
+      } catch (error) {
+        const message = error instanceof Error ? error.message : String(error);
+        return { ok: false, output: "", error: message };
+      }
+    }
+
+    case "memory_write": {
+      const note = typeof tool.args.note === "string" ? tool.args.note.trim() : "";
+      if (note.length === 0) {
+        return { ok: false, output: "", error: "memory_write requires note" };
+      }
+      try {
+        await appendMemory(root, note, options.logger);
+        return { ok: true, output: "noted; future sessions in this workspace will see it via memory_read" };
+      } catch (error) {
+        const message = error instanceof Error ? error.message : String(error);
+        return { ok: false, output: "", error: message };
+      }
+    }
+
+    case "docker": {
+      const guard = canRunCommands(options);
+      if (guard) {
+        return { ok: false, output: "", error: guard };
+      }
+      const action = typeof tool.args.action === "string" ? tool.args.action : null;
+      if (action !== "build" && action !== "run") {
+        return { ok: false, output: "", error: `docker requires action "build" or "run"` };
+      }
+      const refPattern = /^[a-zA-Z0-9][a-zA-Z0-9._:\/@-]*$/;
+      const timeout = dockerTimeout();
+      try {
+        if (action === "build") {
+          const contextInput = typeof tool.args.context === "string" ? tool.args.context : ".";
+          const context = resolvePath(root, contextInput);
+          const parts = [`docker build "${context}"`];
+          if (typeof tool.args.dockerfile === "string" && tool.args.dockerfile.trim().length > 0) {
+            parts.push(`-f "${resolvePath(root, tool.args.dockerfile.trim())}"`);
+          }
+          const tag = typeof tool.args.tag === "string" ? tool.args.tag.trim() : "";
+          if (tag.length > 0) {
+            if (!refPattern.test(tag)) {
+              return { ok: false, output: "", error: `invalid image tag: ${tag}` };
+            }
+            parts.push(`-t "${tag}"`);
+          }
+          // --quiet prints only the image id on stdout; progress stays on stderr.
+          parts.push("--quiet");
+          const result = await exec(parts.join(" "), { cwd: root, timeout, maxBuffer: 10 * 1024 * 1024 });
+          const imageId = result.stdout.trim();
+          options.logger?.info("docker build completed", { context: contextInput, tag: tag || undefined, imageId });
+          return { ok: true, output: tag.length > 0 ? `built ${imageId} (tagged ${tag})` : `built ${imageId}` };
+        }
+        const image = typeof tool.args.image === "string" ? tool.args.image.trim() : "";
+        if (image.length === 0) {
+          return { ok: false, output: "", error: "docker run requires image" };
+        }
+        if (!refPattern.test(image)) {
+          return { ok: false, output: "", error: `invalid image reference: ${image}` };
+        }
+        const cmd = typeof tool.args.cmd === "string" && tool.args.cmd.trim().length > 0 ? tool.args.cmd.trim() : null;
+        const run = cmd ? `docker run --rm "${image}" sh -c '${cmd.replace(/'/g, `'\\''`)}'` : `docker run --rm "${image}"`;
+        const result = await exec(run, { cwd: root, timeout, maxBuffer: 10 * 1024 * 1024 });
+        const logs = [result.stdout, result.stderr].filter(Boolean).join("");
+        options.logger?.info("docker run completed", { image, cmd: cmd || undefined });
+        return { ok: true, output: logs || `${image} exited cleanly with no output` };
+      } catch (error) {
+        const stdout = (error as { stdout?: string }).stdout ?? "";
+        const stderr = (error as { stderr?: string }).stderr ?? "";
+        const logs = [stdout, stderr].filter(Boolean).join("");
+        const message = error instanceof Error ? error.message : String(error);
+        return { ok: false, output: logs, error: message };
+      }
+    }
+
+    case "http_fetch": {
+      const url = typeof tool.args.url === "string" ? tool.args.url : null;
+      if (!url) {
+        return { ok: false, output: "", error: "http_fetch requires url" };
+      }
+      const methodArg = typeof tool.args.method === "string" ? tool.args.method.toUpperCase() : "GET";
+      if (methodArg !== "GET" && methodArg !== "HEAD") {
+        return { ok: false, output: "", error: `http_fetch supports GET and HEAD, got ${methodArg}` };
+      }
+      const maxBytes =
+        typeof tool.args.maxBytes === "number" && tool.args.maxBytes > 0 ? tool.args.maxBytes : undefined;
+      try {
+        const result = await httpFetch(url, methodArg, maxBytes, options.logger);
+        const header = `${methodArg} ${url} -> ${result.status} (${result.contentType || "unknown content type"})`;
+        return {
+          ok: result.status < 400,
+          output: result.body ? `${header}\n\n${result.body}` : header,
+          error: result.status >= 400 ? `http_fetch returned status ${result.status}` : undefined
+        };
+      } catch (error) {
+        const message = error instanceof Error ? error.message : String(error);
+        return { ok: false, output: "", error: message };
+      }
+    }
+
+    case "browser_open": {
+      const rawUrl = typeof tool.args.url === "string" ? tool.args.url : null;
+      if (!rawUrl) {
+        return { ok: false, output: "", error: "browser_open requires url" };
+      }
+      let url: URL;
+      try {
+        url = new URL(rawUrl);
+      } catch {
+        return { ok: false, output: "", error: `invalid url: ${rawUrl}` };
+      }
+      // Workspace dev servers are always reachable; anything else goes
+      // through the same allowlist as http_fetch.
+      const localHosts = new Set(["localhost", "127.0.0.1", "[::1]", "::1"]);
+      if (!localHosts.has(url.hostname)) {
+        const denial = checkDomainAllowed(url.hostname);
+        if (denial) {
+          return { ok: false, output: "", error: denial };
+        }
+      }
+      const screenshotRel =
+        typeof tool.args.screenshotPath === "string" && tool.args.screenshotPath.trim().length > 0
+          ? tool.args.screenshotPath.trim()
+          : path.join(".vuhlp", "screenshots", `${Date.now()}.png`);
+      try {
+        const screenshotAbs = resolvePath(root, screenshotRel);
+        await fs.mkdir(path.dirname(screenshotAbs), { recursive: true });
+        const result = await browserCapture(rawUrl, screenshotAbs, screenshotRel, options.logger);
+        const lines = [`screenshot: ${result.screenshotPath}`];
+        if (result.consoleErrors.length > 0) {
+          lines.push("", "console errors:", ...result.consoleErrors);
+        } else {
+          lines.push("no console errors");
+        }
+        return {
+          ok: true,
+          output: lines.join("\n"),
+          files: [{ path: screenshotRel, op: "write" }]
+        };
+      } catch (error) {
+        const message = error instanceof Error ? error.message : String(error);
+        return { ok: false, output: "", error: message };
+      }
+    }
+
+    case "update_todos": {
+      if (!options.updateTodos) {
+        return { ok: false, output: "", error: "update_todos not supported" };
+      }
+      const todos = parseTodoItems(tool.args.todos);
+      if (!todos) {
+        return {
+          ok: false,
+          output: "",
+          error: "update_todos requires todos: [{ content, status: pending|in_progress|completed, activeForm }]"
+        };
+      }
+      options.updateTodos(todos);
+      const done = todos.filter((item) => item.status === "completed").length;
+      return { ok: true, output: `todo list updated: ${todos.length} item(s), ${done} completed` };
+    }
+
     case "spawn_node": {
       const guard = guardEdgeManagement(options, "spawn_node");
       if (guard) {
@@ -420,6 +1358,46 @@ export async function executeToolCall(
       }
     }
 
+    case "spawn_subagent": {
+      const guard = guardEdgeManagement(options, "spawn_subagent");
+      if (guard) {
+        return { ok: false, output: "", error: guard };
+      }
+      if (!options.spawnSubagent) {
+        return { ok: false, output: "", error: "spawn_subagent not supported" };
+      }
+      const label = typeof tool.args.label === "string" ? tool.args.label.trim() : "";
+      const instructions = typeof tool.args.instructions === "string" ? tool.args.instructions.trim() : "";
+      if (!label || !instructions) {
+        return { ok: false, output: "", error: "spawn_subagent requires label and instructions" };
+      }
+      const request: SpawnSubagentRequest = { label, instructions };
+      if (typeof tool.args.roleTemplate === "string" && tool.args.roleTemplate.trim().length > 0) {
+        request.roleTemplate = tool.args.roleTemplate.trim();
+      }
+      if (typeof tool.args.provider === "string" && tool.args.provider.trim().length > 0) {
+        request.provider = tool.args.provider.trim() as ProviderName;
+      }
+      if (isRecord(tool.args.capabilities)) {
+        request.capabilities = tool.args.capabilities as SpawnSubagentRequest["capabilities"];
+      }
+      if (typeof tool.args.timeoutMs === "number" && tool.args.timeoutMs > 0) {
+        request.timeoutMs = tool.args.timeoutMs;
+      }
+      try {
+        const result = await options.spawnSubagent(request);
+        const header = `subagent ${result.nodeId} (${label}) ${result.status}`;
+        return {
+          ok: result.status !== "failed",
+          output: result.summary.length > 0 ? `${header}\n\n${result.summary}` : header,
+          error: result.status === "failed" ? "subagent turn failed" : undefined
+        };
+      } catch (error) {
+        const message = error instanceof Error ? error.message : String(error);
+        return { ok: false, output: "", error: message };
+      }
+    }
+
     case "create_edge": {
       const guard = guardEdgeManagement(options, "create_edge");
       if (guard) {
@@ -500,8 +1478,20 @@ export async function executeToolCall(
       };
     }
 
-    default:
+    default: {
+      const plugin = findPluginTool(tool.name);
+      if (plugin) {
+        const args = isRecord(tool.args) ? tool.args : {};
+        try {
+          const result = await invokePluginTool(plugin, args, root, options.logger);
+          return { ok: result.ok, output: result.output, error: result.error };
+        } catch (error) {
+          const message = error instanceof Error ? error.message : String(error);
+          return { ok: false, output: "", error: message };
+        }
+      }
       options.logger?.warn("unsupported tool call", { tool: tool.name, toolId: tool.id });
       return { ok: false, output: "", error: `unsupported tool: ${tool.name}` };
+    }
   }
 }
@@ -1,6 +1,7 @@
 import type {
   ApprovalResolution,
   ArtifactRef,
+  AutoApproveRule,
   CliPermissionsMode,
   EdgeType,
   EventEnvelope,
@@ -11,9 +12,11 @@ import type {
   NodePermissions,
   NodeSessionConfig,
   ProviderName,
+  TodoItem,
   UUID
 } from "@vuhlp/contracts";
 import type { JsonObject } from "./json.js";
+import type { WorkspacePolicy } from "./utils/workspace-policy.js";
 
 export type PromptKind = "full" | "delta";
 
@@ -86,6 +89,27 @@ export interface SendHandoffResult {
 
 export type SendHandoffHandler = (request: SendHandoffRequest) => Promise<SendHandoffResult>;
 
+export type UpdateTodosHandler = (todos: TodoItem[]) => void;
+
+export interface SpawnSubagentRequest {
+  label: string;
+  instructions: string;
+  roleTemplate?: string;
+  provider?: ProviderName;
+  /** Constraints for the child; edge management is always forced off. */
+  capabilities?: Partial<NodeCapabilities>;
+  /** Budget for the child's turn; the parent resumes on expiry. */
+  timeoutMs?: number;
+}
+
+export interface SpawnSubagentResult {
+  nodeId: UUID;
+  status: "completed" | "failed" | "timeout";
+  summary: string;
+}
+
+export type SpawnSubagentHandler = (request: SpawnSubagentRequest) => Promise<SpawnSubagentResult>;
+
 export type NativeToolHandling = "vuhlp" | "provider";
 
 interface ProviderConfigBase {
@@ -94,11 +118,23 @@ interface ProviderConfigBase {
   provider: ProviderName;
   cwd?: string;
   env?: Record<string, string>;
+  /** Named env set (VUHLP_ENV_SET_<NAME>) injected into tool execution. */
+  envSet?: string;
+  /** Docker image; when set, command-running tools execute in a sandbox container. */
+  sandboxImage?: string;
+  /** Rejects every write/exec tool, for zero-risk explain-the-codebase sessions. */
+  readOnly?: boolean;
+  /** Policy loaded from .vuhlp/policy.json at the workspace root. */
+  policy?: WorkspacePolicy;
+  /** Session-scoped auto-approve rules, checked before the approval gate. */
+  autoApprove?: AutoApproveRule[];
   permissionsMode: CliPermissionsMode;
   agentManagementRequiresApproval?: boolean;
   spawnNode?: SpawnNodeHandler;
   createEdge?: CreateEdgeHandler;
   sendHandoff?: SendHandoffHandler;
+  spawnSubagent?: SpawnSubagentHandler;
+  updateTodos?: UpdateTodosHandler;
   resume: boolean;
   resetCommands: string[];
   capabilities?: NodeCapabilities;
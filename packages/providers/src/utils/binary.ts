@@ -0,0 +1,46 @@
+import path from "node:path";
+
+/**
+ * Binary handling for read_file: decoding an image or archive as UTF-8
+ * feeds the model mojibake. Images are instead returned base64 so API
+ * providers can attach them as vision parts; other binaries get a
+ * metadata summary.
+ */
+
+const IMAGE_MIME_BY_EXT: Record<string, string> = {
+    ".png": "image/png",
+    ".jpg": "image/jpeg",
+    ".jpeg": "image/jpeg",
+    ".gif": "image/gif",
+    ".webp": "image/webp",
+    ".bmp": "image/bmp"
+};
+
+const BINARY_SNIFF_BYTES = 8_192;
+
+/** Returns the image MIME type for the path, or null for non-images. */
+export function imageMimeType(filePath: string): string | null {
+    return IMAGE_MIME_BY_EXT[path.extname(filePath).toLowerCase()] ?? null;
+}
+
+/**
+ * Heuristic binary check: a NUL byte in the first 8 KiB. Matches what
+ * git and grep use, and never flags valid UTF-8 text.
+ */
+export function isBinaryContent(buffer: Buffer): boolean {
+    const end = Math.min(buffer.length, BINARY_SNIFF_BYTES);
+    for (let i = 0; i < end; i += 1) {
+        if (buffer[i] === 0) {
+            return true;
+        }
+    }
+    return false;
+}
+
+/** One-line metadata summary for a binary file the model cannot read. */
+export function describeBinary(relPath: string, buffer: Buffer): string {
+    const ext = path.extname(relPath).toLowerCase();
+    const kind = ext ? `${ext.slice(1)} file` : "file";
+    const magic = buffer.subarray(0, 8).toString("hex");
+    return `binary ${kind} ${relPath}: ${buffer.length} bytes (magic ${magic}); content not shown`;
+}
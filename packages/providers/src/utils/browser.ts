@@ -0,0 +1,121 @@
+import { exec as execCallback } from "node:child_process";
+import { promisify } from "node:util";
+import type { Logger } from "../logger.js";
+
+const exec = promisify(execCallback);
+
+/**
+ * browser_open execution: drive a headless Chromium against a URL (usually
+ * a dev server the workspace is running), capture a screenshot into the
+ * workspace, and collect console errors from the browser log so
+ * vision-capable models can verify UI changes without a human in the loop.
+ *
+ * VUHLP_BROWSER_BIN names the Chromium/Chrome binary (default "chromium");
+ * VUHLP_BROWSER_TIMEOUT caps the page load in ms (default 30000).
+ */
+
+const DEFAULT_TIMEOUT_MS = 30_000;
+const VIRTUAL_TIME_BUDGET_MS = 5_000;
+const WINDOW_SIZE = "1280,800";
+const MAX_CONSOLE_LINES = 50;
+
+export interface BrowserCaptureResult {
+    /** Workspace-relative path the screenshot was written to. */
+    screenshotPath: string;
+    /** Console error/warning lines emitted while loading the page. */
+    consoleErrors: string[];
+}
+
+function browserBin(): string {
+    const raw = process.env.VUHLP_BROWSER_BIN;
+    return raw && raw.trim().length > 0 ? raw.trim() : "chromium";
+}
+
+function browserTimeoutMs(): number {
+    const raw = process.env.VUHLP_BROWSER_TIMEOUT;
+    if (raw === undefined || raw.trim().length === 0) {
+        return DEFAULT_TIMEOUT_MS;
+    }
+    const parsed = Number(raw);
+    return Number.isFinite(parsed) && parsed > 0 ? parsed : DEFAULT_TIMEOUT_MS;
+}
+
+function shellQuote(value: string): string {
+    return `'${value.replace(/'/g, "'\\''")}'`;
+}
+
+/**
+ * Chromium with --enable-logging=stderr prints page console messages as
+ * lines like `[123:456:0101/...:INFO:CONSOLE(1)] "msg", source: ...`.
+ * Keep CONSOLE lines plus hard ERROR/FATAL lines and drop the pid/time
+ * prefix so the model sees the message, not the plumbing.
+ */
+function extractConsoleErrors(stderr: string): string[] {
+    const lines: string[] = [];
+    for (const line of stderr.split(/\r?\n/)) {
+        if (!/CONSOLE|:ERROR:|:FATAL:/.test(line)) {
+            continue;
+        }
+        const cleaned = line.replace(/^\[[^\]]*\]\s*/, "").trim();
+        if (cleaned.length > 0) {
+            lines.push(cleaned);
+        }
+        if (lines.length >= MAX_CONSOLE_LINES) {
+            lines.push("... (console output truncated)");
+            break;
+        }
+    }
+    return lines;
+}
+
+/**
+ * Loads the URL headlessly and writes a PNG screenshot to
+ * screenshotAbsPath. Throws with a readable message when the browser
+ * binary is missing or the load times out; console errors on a page that
+ * did load are returned, not thrown — a broken page is a valid finding.
+ */
+export async function browserCapture(
+    url: string,
+    screenshotAbsPath: string,
+    screenshotRelPath: string,
+    logger?: Logger
+): Promise<BrowserCaptureResult> {
+    const bin = browserBin();
+    const cmd = [
+        shellQuote(bin),
+        "--headless=new",
+        "--disable-gpu",
+        "--hide-scrollbars",
+        `--window-size=${WINDOW_SIZE}`,
+        `--virtual-time-budget=${VIRTUAL_TIME_BUDGET_MS}`,
+        "--enable-logging=stderr",
+        `--screenshot=${shellQuote(screenshotAbsPath)}`,
+        shellQuote(url)
+    ].join(" ");
+    try {
+        const result = await exec(cmd, {
+            timeout: browserTimeoutMs(),
+            maxBuffer: 10 * 1024 * 1024
+        });
+        const consoleErrors = extractConsoleErrors(result.stderr ?? "");
+        logger?.info("browser capture completed", {
+            url,
+            screenshot: screenshotRelPath,
+            consoleErrors: consoleErrors.length
+        });
+        return { screenshotPath: screenshotRelPath, consoleErrors };
+    } catch (error) {
+        const killed = (error as { killed?: boolean }).killed === true;
+        if (killed) {
+            throw new Error(`browser_open timed out after ${browserTimeoutMs()}ms: ${url}`);
+        }
+        const stderr = (error as { stderr?: string }).stderr ?? "";
+        const message = error instanceof Error ? error.message : String(error);
+        if (/not found|ENOENT/i.test(message)) {
+            throw new Error(`browser binary "${bin}" not found; set VUHLP_BROWSER_BIN to a Chromium/Chrome binary`);
+        }
+        logger?.warn("browser capture failed", { url, message });
+        const consoleErrors = extractConsoleErrors(stderr);
+        throw new Error(consoleErrors.length > 0 ? `${message}\n${consoleErrors.join("\n")}` : message);
+    }
+}
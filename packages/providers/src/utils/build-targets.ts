@@ -0,0 +1,116 @@
+import { promises as fs } from "node:fs";
+import path from "node:path";
+
+/**
+ * Build-target discovery for the targets tool: enumerates runnable
+ * commands from the workspace's Makefile, package.json scripts, Taskfile,
+ * and justfile, so the agent invokes what the repo actually defines
+ * instead of guessing "make test" on an npm project.
+ */
+
+export interface BuildTarget {
+    source: string;
+    name: string;
+    detail?: string;
+}
+
+async function readIfPresent(file: string): Promise<string | null> {
+    try {
+        return await fs.readFile(file, "utf8");
+    } catch {
+        return null;
+    }
+}
+
+function makefileTargets(content: string, source: string): BuildTarget[] {
+    const targets: BuildTarget[] = [];
+    const lines = content.split("\n");
+    for (let index = 0; index < lines.length; index += 1) {
+        const match = lines[index].match(/^([A-Za-z0-9_./-]+):(?!=)/);
+        if (!match || match[1].startsWith(".") || match[1].includes("%")) {
+            continue;
+        }
+        const inline = lines[index].match(/##\s*(.+)$/);
+        const preceding = index > 0 ? lines[index - 1].match(/^#+\s*(.+)$/) : null;
+        const detail = inline?.[1] ?? preceding?.[1];
+        targets.push({ source, name: match[1], detail });
+    }
+    return targets;
+}
+
+function packageScripts(content: string, source: string): BuildTarget[] {
+    let parsed: { scripts?: Record<string, string> };
+    try {
+        parsed = JSON.parse(content) as { scripts?: Record<string, string> };
+    } catch {
+        return [];
+    }
+    if (!parsed.scripts || typeof parsed.scripts !== "object") {
+        return [];
+    }
+    return Object.entries(parsed.scripts)
+        .filter((entry): entry is [string, string] => typeof entry[1] === "string")
+        .map(([name, command]) => ({ source, name, detail: command }));
+}
+
+function taskfileTasks(content: string, source: string): BuildTarget[] {
+    const targets: BuildTarget[] = [];
+    let inTasks = false;
+    for (const line of content.split("\n")) {
+        if (/^tasks:\s*$/.test(line)) {
+            inTasks = true;
+            continue;
+        }
+        if (inTasks && /^\S/.test(line)) {
+            inTasks = false;
+        }
+        if (!inTasks) {
+            continue;
+        }
+        const match = line.match(/^ {2}([A-Za-z0-9_:-]+):\s*$/);
+        if (match) {
+            targets.push({ source, name: match[1] });
+        }
+    }
+    return targets;
+}
+
+function justfileRecipes(content: string, source: string): BuildTarget[] {
+    const targets: BuildTarget[] = [];
+    for (const line of content.split("\n")) {
+        const match = line.match(/^([A-Za-z0-9_-]+)(?:\s+[A-Za-z0-9_ +*='"-]*)?:(?!=)/);
+        if (match && !match[1].startsWith("_")) {
+            targets.push({ source, name: match[1] });
+        }
+    }
+    return targets;
+}
+
+/** All discovered targets, grouped in source order. */
+export async function discoverBuildTargets(root: string): Promise<BuildTarget[]> {
+    const targets: BuildTarget[] = [];
+    for (const name of ["Makefile", "makefile", "GNUmakefile"]) {
+        const content = await readIfPresent(path.join(root, name));
+        if (content) {
+            targets.push(...makefileTargets(content, `${name} (make <target>)`));
+            break;
+        }
+    }
+    const pkg = await readIfPresent(path.join(root, "package.json"));
+    if (pkg) {
+        const runner = (await readIfPresent(path.join(root, "pnpm-lock.yaml"))) ? "pnpm" : "npm";
+        targets.push(...packageScripts(pkg, `package.json (${runner} run <script>)`));
+    }
+    for (const name of ["Taskfile.yml", "Taskfile.yaml"]) {
+        const content = await readIfPresent(path.join(root, name));
+        if (content) {
+            targets.push(...taskfileTasks(content, `${name} (task <name>)`));
+            break;
+        }
+    }
+    const just = await readIfPresent(path.join(root, "justfile")) ?? (await readIfPresent(path.join(root, "Justfile")));
+    if (just) {
+        targets.push(...justfileRecipes(just, "justfile (just <recipe>)"));
+    }
+    return targets;
+}
@@ -0,0 +1,139 @@
+import type { Logger } from "../logger.js";
+
+/**
+ * Model-backed review for the code_review tool: the current git diff is
+ * sent to an OpenAI-compatible model (usually a different one than the
+ * author, so the author does not grade its own work) which returns
+ * structured findings.
+ *
+ * Configured via VUHLP_REVIEW_API_KEY / VUHLP_REVIEW_MODEL /
+ * VUHLP_REVIEW_API_URL, falling back to the VUHLP_EVAL_* settings.
+ * Disabled when neither key is set.
+ */
+
+const REVIEW_TIMEOUT_MS = 60_000;
+const MAX_DIFF_CHARS = 60_000;
+
+const SYSTEM_PROMPT = [
+    "You review a unified git diff for defects, not style preferences.",
+    "Respond with a single JSON array and nothing else; each finding is",
+    '{ "severity": "info"|"warn"|"error", "file": "<path>", "line": <number|null>, "comment": "<one sentence>" }.',
+    "error = will break or is unsafe; warn = likely bug or missing handling; info = worth knowing.",
+    "An empty array means the diff looks fine. Do not invent findings to fill the array."
+].join("\n");
+
+export type ReviewSeverity = "info" | "warn" | "error";
+
+export interface ReviewFinding {
+    severity: ReviewSeverity;
+    file: string;
+    line?: number;
+    comment: string;
+}
+
+export function reviewConfigured(): boolean {
+    return Boolean(process.env.VUHLP_REVIEW_API_KEY ?? process.env.VUHLP_EVAL_API_KEY);
+}
+
+/**
+ * Reviews the diff and returns findings, or null when the call failed or
+ * the response could not be parsed (the caller degrades with a visible
+ * error rather than a silent pass).
+ */
+export async function reviewDiff(diff: string, logger?: Logger): Promise<ReviewFinding[] | null> {
+    const apiKey = process.env.VUHLP_REVIEW_API_KEY ?? process.env.VUHLP_EVAL_API_KEY;
+    if (!apiKey) {
+        return null;
+    }
+    const model = process.env.VUHLP_REVIEW_MODEL ?? process.env.VUHLP_EVAL_MODEL ?? "gpt-4o-mini";
+    const baseUrl = (
+        process.env.VUHLP_REVIEW_API_URL ??
+        process.env.VUHLP_EVAL_API_URL ??
+        "https://api.openai.com/v1"
+    ).replace(/\/+$/, "");
+    const body =
+        diff.length > MAX_DIFF_CHARS ? `${diff.slice(0, MAX_DIFF_CHARS)}\n[diff truncated]` : diff;
+
+    try {
+        const controller = new AbortController();
+        const timer = setTimeout(() => controller.abort(), REVIEW_TIMEOUT_MS);
+        try {
+            const response = await fetch(`${baseUrl}/chat/completions`, {
+                method: "POST",
+                headers: {
+                    "Content-Type": "application/json",
+                    Authorization: `Bearer ${apiKey}`
+                },
+                body: JSON.stringify({
+                    model,
+                    messages: [
+                        { role: "system", content: SYSTEM_PROMPT },
+                        { role: "user", content: body }
+                    ],
+                    temperature: 0
+                }),
+                signal: controller.signal
+            });
+            if (!response.ok) {
+                const detail = await response.text();
+                logger?.warn("code review request rejected", { status: response.status, detail });
+                return null;
+            }
+            const payload = (await response.json()) as {
+                choices?: Array<{ message?: { content?: string } }>;
+            };
+            const content = payload.choices?.[0]?.message?.content;
+            if (!content) {
+                logger?.warn("code review response missing content");
+                return null;
+            }
+            return parseFindings(content, logger);
+        } finally {
+            clearTimeout(timer);
+        }
+    } catch (error) {
+        const message = error instanceof Error ? error.message : String(error);
+        logger?.warn("code review failed", { model, message });
+        return null;
+    }
+}
+
+function parseFindings(content: string, logger?: Logger): ReviewFinding[] | null {
+    const start = content.indexOf("[");
+    const end = content.lastIndexOf("]");
+    if (start === -1 || end <= start) {
+        logger?.warn("code review response is not a JSON array", { content });
+        return null;
+    }
+    let parsed: unknown;
+    try {
+        parsed = JSON.parse(content.slice(start, end + 1));
+    } catch (error) {
+        const message = error instanceof Error ? error.message : String(error);
+        logger?.warn("failed to parse code review JSON", { content, message });
+        return null;
+    }
+    if (!Array.isArray(parsed)) {
+        return null;
+    }
+    const findings: ReviewFinding[] = [];
+    for (const raw of parsed) {
+        if (typeof raw !== "object" || raw === null) {
+            continue;
+        }
+        const item = raw as Record<string, unknown>;
+        const severity = item.severity;
+        if (severity !== "info" && severity !== "warn" && severity !== "error") {
+            continue;
+        }
+        if (typeof item.file !== "string" || typeof item.comment !== "string") {
+            continue;
+        }
+        const finding: ReviewFinding = { severity, file: item.file, comment: item.comment };
+        if (typeof item.line === "number" && Number.isFinite(item.line)) {
+            finding.line = item.line;
+        }
+        findings.push(finding);
+    }
+    return findings;
+}
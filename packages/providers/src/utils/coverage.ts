@@ -0,0 +1,111 @@
+/**
+ * Coverage reporting for the coverage tool: runs the project's coverage
+ * command, extracts per-package numbers from the runner's output, and -
+ * when a Go coverprofile is produced - intersects uncovered statements
+ * with the lines touched by the current diff, so specs can require "new
+ * code is covered" rather than a raw percentage.
+ */
+
+export interface CoverageSummaryLine {
+    subject: string;
+    percent: number;
+}
+
+export interface UncoveredRange {
+    file: string;
+    startLine: number;
+    endLine: number;
+}
+
+/**
+ * Pulls per-package/per-file percentages out of runner output. Understands
+ * `go test -cover` ("ok <pkg> ... coverage: 82.1% of statements"),
+ * pytest-cov table rows, and the jest/vitest text summary ("All files").
+ */
+export function parseCoverageSummary(output: string): CoverageSummaryLine[] {
+    const lines: CoverageSummaryLine[] = [];
+    for (const raw of output.split("\n")) {
+        const go = raw.match(/^(?:ok\s+)?(\S+)\s.*coverage:\s+([\d.]+)%/);
+        if (go) {
+            lines.push({ subject: go[1], percent: Number(go[2]) });
+            continue;
+        }
+        const table = raw.match(/^\s*([\w./-]+\.\w+|TOTAL|All files)\s.*?([\d.]+)%?\s*\|?\s*$/);
+        if (table && raw.includes("%")) {
+            const percent = Number(table[2]);
+            if (Number.isFinite(percent) && percent >= 0 && percent <= 100) {
+                lines.push({ subject: table[1], percent });
+            }
+        }
+    }
+    return lines;
+}
+
+/**
+ * Parses a Go coverprofile ("file:startLine.col,endLine.col stmts count")
+ * and returns the ranges with a zero hit count.
+ */
+export function parseGoCoverProfile(content: string): UncoveredRange[] {
+    const uncovered: UncoveredRange[] = [];
+    for (const raw of content.split("\n")) {
+        const match = raw.match(/^(.+):(\d+)\.\d+,(\d+)\.\d+\s+\d+\s+(\d+)$/);
+        if (!match) {
+            continue;
+        }
+        if (Number(match[4]) === 0) {
+            uncovered.push({ file: match[1], startLine: Number(match[2]), endLine: Number(match[3]) });
+        }
+    }
+    return uncovered;
+}
+
+/** Changed line numbers per file from `git diff -U0` output. */
+export function parseChangedLines(diff: string): Map<string, Set<number>> {
+    const changed = new Map<string, Set<number>>();
+    let file: string | null = null;
+    for (const raw of diff.split("\n")) {
+        const header = raw.match(/^\+\+\+ b\/(.+)$/);
+        if (header) {
+            file = header[1];
+            continue;
+        }
+        const hunk = raw.match(/^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@/);
+        if (hunk && file) {
+            const start = Number(hunk[1]);
+            const count = hunk[2] !== undefined ? Number(hunk[2]) : 1;
+            const lines = changed.get(file) ?? new Set<number>();
+            for (let line = start; line < start + count; line += 1) {
+                lines.add(line);
+            }
+            changed.set(file, lines);
+        }
+    }
+    return changed;
+}
+
+/**
+ * Uncovered ranges that overlap lines touched by the diff. Profile paths
+ * are module-qualified (e.g. example.com/mod/pkg/file.go), so matching is
+ * by path suffix against the repo-relative diff paths.
+ */
+export function uncoveredInDiff(
+    uncovered: UncoveredRange[],
+    changed: Map<string, Set<number>>
+): UncoveredRange[] {
+    const hits: UncoveredRange[] = [];
+    for (const range of uncovered) {
+        for (const [file, lines] of changed) {
+            if (!range.file.endsWith(file)) {
+                continue;
+            }
+            for (const line of lines) {
+                if (line >= range.startLine && line <= range.endLine) {
+                    hits.push({ file, startLine: range.startLine, endLine: range.endLine });
+                    break;
+                }
+            }
+            break;
+        }
+    }
+    return hits;
+}
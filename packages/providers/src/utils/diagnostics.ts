@@ -0,0 +1,90 @@
+import { exec as execCallback } from "node:child_process";
+import { promisify } from "node:util";
+import type { Logger } from "../logger.js";
+
+const exec = promisify(execCallback);
+
+/**
+ * Optional post-edit diagnostics: after a write tool succeeds, run a
+ * configured command (gopls check, tsc --noEmit, ...) against the touched
+ * files and feed the errors back to the model in the same turn — far
+ * cheaper than waiting for a full verify cycle to catch a type error.
+ *
+ * VUHLP_DIAGNOSTICS_CMD is a shell command template; "{files}" expands to
+ * the space-separated touched paths (omit it to run against the whole
+ * workspace). VUHLP_DIAGNOSTICS_TIMEOUT caps the run in ms (default
+ * 30000). Unset VUHLP_DIAGNOSTICS_CMD disables the feature.
+ */
+
+const DEFAULT_TIMEOUT_MS = 30_000;
+const MAX_OUTPUT_CHARS = 8_000;
+
+function diagnosticsCommand(): string | null {
+    const raw = process.env.VUHLP_DIAGNOSTICS_CMD;
+    return raw && raw.trim().length > 0 ? raw.trim() : null;
+}
+
+function diagnosticsTimeoutMs(): number {
+    const raw = process.env.VUHLP_DIAGNOSTICS_TIMEOUT;
+    if (raw === undefined || raw.trim().length === 0) {
+        return DEFAULT_TIMEOUT_MS;
+    }
+    const parsed = Number(raw);
+    return Number.isFinite(parsed) && parsed > 0 ? parsed : DEFAULT_TIMEOUT_MS;
+}
+
+export function diagnosticsEnabled(): boolean {
+    return diagnosticsCommand() !== null;
+}
+
+function shellQuote(value: string): string {
+    return `'${value.replace(/'/g, "'\\''")}'`;
+}
+
+/**
+ * Runs the configured diagnostics command for the given files. Returns
+ * the trimmed diagnostics output, or null when the command is not
+ * configured, produced nothing, or failed to launch (launch failures are
+ * logged and swallowed — diagnostics must never fail the edit itself).
+ */
+export async function runDiagnostics(
+    cwd: string,
+    files: string[],
+    logger?: Logger
+): Promise<string | null> {
+    const template = diagnosticsCommand();
+    if (!template || files.length === 0) {
+        return null;
+    }
+    const fileList = files.map(shellQuote).join(" ");
+    const cmd = template.includes("{files}") ? template.replaceAll("{files}", fileList) : template;
+    try {
+        const result = await exec(cmd, {
+            cwd,
+            timeout: diagnosticsTimeoutMs(),
+            maxBuffer: 10 * 1024 * 1024
+        });
+        return formatOutput(result.stdout, result.stderr);
+    } catch (error) {
+        // Non-zero exit is the interesting case: the diagnostics themselves.
+        const stdout = (error as { stdout?: string }).stdout ?? "";
+        const stderr = (error as { stderr?: string }).stderr ?? "";
+        const output = formatOutput(stdout, stderr);
+        if (output) {
+            return output;
+        }
+        const message = error instanceof Error ? error.message : String(error);
+        logger?.warn("diagnostics command failed to produce output", { cmd, message });
+        return null;
+    }
+}
+
+function formatOutput(stdout: string, stderr: string): string | null {
+    const combined = [stdout, stderr].filter(Boolean).join("\n").trim();
+    if (combined.length === 0) {
+        return null;
+    }
+    return combined.length > MAX_OUTPUT_CHARS
+        ? `${combined.slice(0, MAX_OUTPUT_CHARS)}\n... (diagnostics truncated)`
+        : combined;
+}
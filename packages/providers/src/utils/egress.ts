@@ -0,0 +1,59 @@
+/**
+ * Network egress policy for exec tools. VUHLP_EGRESS_POLICY picks the
+ * stance per workspace:
+ *
+ * - "allow" (default): no additional restriction
+ * - "allowlist": only hosts in VUHLP_EGRESS_ALLOWED_HOSTS are reachable
+ *   from http_fetch; sandboxed commands run with networking disabled
+ *   since docker cannot filter per-host
+ * - "deny": http_fetch is refused and sandboxed commands run with
+ *   networking disabled
+ *
+ * Host commands outside the sandbox cannot be network-isolated from
+ * here; use the sandbox for untrusted execution.
+ */
+
+export type EgressPolicy = "allow" | "allowlist" | "deny";
+
+export function egressPolicy(): EgressPolicy {
+    const raw = process.env.VUHLP_EGRESS_POLICY?.trim().toLowerCase();
+    return raw === "allowlist" || raw === "deny" ? raw : "allow";
+}
+
+function egressAllowedHosts(): string[] {
+    const raw = process.env.VUHLP_EGRESS_ALLOWED_HOSTS;
+    if (!raw) {
+        return [];
+    }
+    return raw
+        .split(",")
+        .map((entry) => entry.trim().toLowerCase())
+        .filter((entry) => entry.length > 0);
+}
+
+/**
+ * Returns null when the egress policy permits reaching the host,
+ * otherwise a denial reason suitable for the tool error. An allowlist
+ * entry also matches its subdomains.
+ */
+export function checkEgressAllowed(hostname: string): string | null {
+    const policy = egressPolicy();
+    if (policy === "allow") {
+        return null;
+    }
+    if (policy === "deny") {
+        return "egress policy denies network access (VUHLP_EGRESS_POLICY=deny)";
+    }
+    const host = hostname.toLowerCase();
+    for (const allowed of egressAllowedHosts()) {
+        if (host === allowed || host.endsWith(`.${allowed}`)) {
+            return null;
+        }
+    }
+    return `egress policy blocks host: ${hostname} (add it to VUHLP_EGRESS_ALLOWED_HOSTS)`;
+}
+
+/** Docker network flags for sandboxed commands under the policy. */
+export function sandboxNetworkFlags(): string[] {
+    return egressPolicy() === "allow" ? [] : ["--network none"];
+}
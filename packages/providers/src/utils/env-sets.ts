@@ -0,0 +1,75 @@
+import type { Logger } from "../logger.js";
+
+/**
+ * Named environment sets for tool execution: VUHLP_ENV_SET_<NAME> holds a
+ * comma list of KEY=VALUE pairs, e.g.
+ *
+ *   VUHLP_ENV_SET_STAGING_AWS="AWS_PROFILE=staging,AWS_SECRET_ACCESS_KEY=env:STAGING_AWS_SECRET"
+ *
+ * A run that requests envSet "staging-aws" gets those variables injected
+ * into command/run_tests/start_process. Values written as `env:VAR` are
+ * resolved from the daemon's environment at call time and treated as
+ * secrets: they are redacted from tool output before it reaches events,
+ * artifacts, or the model. Keychain-backed secrets work by pointing the
+ * env: reference at a variable populated by the keychain at daemon start.
+ */
+
+export interface ResolvedEnvSet {
+    name: string;
+    env: Record<string, string>;
+    /** Values that must never appear in logs/events/artifacts. */
+    secrets: string[];
+}
+
+function envSetVar(name: string): string {
+    return `VUHLP_ENV_SET_${name.toUpperCase().replace(/-/g, "_")}`;
+}
+
+/**
+ * Resolves the named set, or null when it is not configured. Unresolvable
+ * env: references are logged and skipped rather than injected empty — a
+ * missing secret should fail loudly at the tool, not silently auth as
+ * nobody.
+ */
+export function resolveEnvSet(name: string, logger?: Logger): ResolvedEnvSet | null {
+    const raw = process.env[envSetVar(name)];
+    if (!raw || raw.trim().length === 0) {
+        return null;
+    }
+    const env: Record<string, string> = {};
+    const secrets: string[] = [];
+    for (const pair of raw.split(",")) {
+        const eq = pair.indexOf("=");
+        if (eq <= 0) {
+            logger?.warn("ignoring malformed env set entry", { envSet: name, entry: pair.trim() });
+            continue;
+        }
+        const key = pair.slice(0, eq).trim();
+        const value = pair.slice(eq + 1).trim();
+        if (value.startsWith("env:")) {
+            const ref = value.slice(4);
+            const resolved = process.env[ref];
+            if (resolved === undefined || resolved.length === 0) {
+                logger?.warn("env set secret reference is unset; skipping", { envSet: name, key, ref });
+                continue;
+            }
+            env[key] = resolved;
+            secrets.push(resolved);
+        } else {
+            env[key] = value;
+        }
+    }
+    return { name, env, secrets };
+}
+
+/** Replaces every secret value occurrence so it never leaves the tool. */
+export function redactSecrets(text: string, secrets: string[]): string {
+    let result = text;
+    for (const secret of secrets) {
+        if (secret.length === 0) {
+            continue;
+        }
+        result = result.split(secret).join("[redacted]");
+    }
+    return result;
+}
@@ -0,0 +1,95 @@
+import { promises as fs } from "node:fs";
+import path from "node:path";
+
+/**
+ * Dependency-free glob matching with doublestar semantics: `**` spans
+ * directories, `*` and `?` stay within one segment, `{a,b}` alternates.
+ * Used by the glob tool; plain filepath matching on basenames cannot
+ * express patterns like `internal/**\/*_test.go`.
+ */
+
+const SKIPPED_DIRS: ReadonlySet<string> = new Set([".git", "node_modules", "dist", ".vuhlp"]);
+
+export function globToRegExp(pattern: string): RegExp {
+    let regex = "";
+    let i = 0;
+    while (i < pattern.length) {
+        const char = pattern[i];
+        if (char === "*") {
+            if (pattern[i + 1] === "*") {
+                // "**/" or trailing "**" spans any number of segments.
+                if (pattern[i + 2] === "/") {
+                    regex += "(?:[^/]+/)*";
+                    i += 3;
+                } else {
+                    regex += ".*";
+                    i += 2;
+                }
+            } else {
+                regex += "[^/]*";
+                i += 1;
+            }
+            continue;
+        }
+        if (char === "?") {
+            regex += "[^/]";
+        } else if (char === "{") {
+            const end = pattern.indexOf("}", i);
+            if (end === -1) {
+                regex += "\\{";
+            } else {
+                const options = pattern
+                    .slice(i + 1, end)
+                    .split(",")
+                    .map((option) => option.replace(/[.+^$()|[\]\\]/g, "\\$&"));
+                regex += `(?:${options.join("|")})`;
+                i = end + 1;
+                continue;
+            }
+        } else if (/[.+^$()|[\]\\]/.test(char)) {
+            regex += `\\${char}`;
+        } else {
+            regex += char;
+        }
+        i += 1;
+    }
+    return new RegExp(`^${regex}$`);
+}
+
+/**
+ * Walks root and returns workspace-relative paths (forward slashes)
+ * matching the pattern, sorted, capped at limit. Common vendor and VCS
+ * directories are skipped.
+ */
+export async function globPaths(root: string, pattern: string, limit = 1_000): Promise<string[]> {
+    const matcher = globToRegExp(pattern.replace(/\\/g, "/"));
+    const matches: string[] = [];
+    const walk = async (dir: string): Promise<void> => {
+        if (matches.length >= limit) {
+            return;
+        }
+        let entries;
+        try {
+            entries = await fs.readdir(dir, { withFileTypes: true });
+        } catch {
+            return;
+        }
+        for (const entry of entries) {
+            if (matches.length >= limit) {
+                return;
+            }
+            const absolute = path.join(dir, entry.name);
+            const relative = path.relative(root, absolute).split(path.sep).join("/");
+            if (entry.isDirectory()) {
+                if (!SKIPPED_DIRS.has(entry.name)) {
+                    await walk(absolute);
+                }
+            } else if (matcher.test(relative)) {
+                matches.push(relative);
+            }
+        }
+    };
+    await walk(root);
+    matches.sort();
+    return matches;
+}
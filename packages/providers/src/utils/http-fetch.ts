@@ -0,0 +1,268 @@
+import type { Logger } from "../logger.js";
+import { checkEgressAllowed } from "./egress.js";
+
+/**
+ * http_fetch execution: GET/HEAD against an allowlisted domain with a
+ * response size cap and optional HTML-to-text conversion so agents can
+ * read documentation pages without pulling megabytes of markup into the
+ * context window.
+ *
+ * VUHLP_HTTP_ALLOWED_DOMAINS is a comma list of hostnames; an entry also
+ * matches its subdomains (docs.example.com matches example.com). Unset
+ * means no domain is reachable — the allowlist is deny-by-default.
+ * Redirects are followed manually and every hop is re-checked against
+ * the allowlist and egress policy, so an allowlisted host cannot bounce
+ * the fetch to an arbitrary one. VUHLP_HTTP_MAX_BYTES caps the returned
+ * body (default 512000) — reading stops at the cap rather than buffering
+ * the full response — and VUHLP_HTTP_TIMEOUT caps the request in ms
+ * (default 30000).
+ */
+
+const DEFAULT_MAX_BYTES = 512_000;
+const DEFAULT_TIMEOUT_MS = 30_000;
+const MAX_REDIRECTS = 5;
+
+export type HttpFetchMethod = "GET" | "HEAD";
+
+export interface HttpFetchResult {
+    status: number;
+    contentType: string;
+    /** Body text, HTML converted to plain text; empty for HEAD. */
+    body: string;
+    truncated: boolean;
+}
+
+function allowedDomains(): string[] {
+    const raw = process.env.VUHLP_HTTP_ALLOWED_DOMAINS;
+    if (!raw) {
+        return [];
+    }
+    return raw
+        .split(",")
+        .map((entry) => entry.trim().toLowerCase())
+        .filter((entry) => entry.length > 0);
+}
+
+function maxBytes(override?: number): number {
+    if (override !== undefined && Number.isFinite(override) && override > 0) {
+        return Math.floor(override);
+    }
+    const raw = process.env.VUHLP_HTTP_MAX_BYTES;
+    if (raw === undefined || raw.trim().length === 0) {
+        return DEFAULT_MAX_BYTES;
+    }
+    const parsed = Number(raw);
+    return Number.isFinite(parsed) && parsed > 0 ? parsed : DEFAULT_MAX_BYTES;
+}
+
+function timeoutMs(): number {
+    const raw = process.env.VUHLP_HTTP_TIMEOUT;
+    if (raw === undefined || raw.trim().length === 0) {
+        return DEFAULT_TIMEOUT_MS;
+    }
+    const parsed = Number(raw);
+    return Number.isFinite(parsed) && parsed > 0 ? parsed : DEFAULT_TIMEOUT_MS;
+}
+
+/**
+ * Returns null when the host is allowlisted, otherwise a human-readable
+ * denial reason suitable for the tool error.
+ */
+export function checkDomainAllowed(hostname: string): string | null {
+    const domains = allowedDomains();
+    if (domains.length === 0) {
+        return "http_fetch is disabled: set VUHLP_HTTP_ALLOWED_DOMAINS to a comma list of allowed hostnames";
+    }
+    const host = hostname.toLowerCase();
+    for (const domain of domains) {
+        if (host === domain || host.endsWith(`.${domain}`)) {
+            return null;
+        }
+    }
+    return `domain not allowlisted: ${hostname}`;
+}
+
+const ENTITY_MAP: Record<string, string> = {
+    "&amp;": "&",
+    "&lt;": "<",
+    "&gt;": ">",
+    "&quot;": '"',
+    "&#39;": "'",
+    "&apos;": "'",
+    "&nbsp;": " "
+};
+
+function decodeEntities(text: string): string {
+    return text
+        .replace(/&#(\d+);/g, (_, code: string) => String.fromCodePoint(Number(code)))
+        .replace(/&[a-z]+;|&#39;/gi, (entity) => ENTITY_MAP[entity.toLowerCase()] ?? entity);
+}
+
+/**
+ * Crude but dependency-free HTML-to-text: drop script/style/head, turn
+ * block boundaries into newlines, strip the remaining tags, decode the
+ * common entities, and collapse whitespace.
+ */
+export function htmlToText(html: string): string {
+    const withoutBlocks = html
+        .replace(/<script\b[\s\S]*?<\/script>/gi, "")
+        .replace(/<style\b[\s\S]*?<\/style>/gi, "")
+        .replace(/<head\b[\s\S]*?<\/head>/gi, "")
+        .replace(/<!--[\s\S]*?-->/g, "");
+    const withBreaks = withoutBlocks
+        .replace(/<\/(p|div|li|h[1-6]|tr|blockquote|pre|section|article)>/gi, "\n")
+        .replace(/<(br|hr)\s*\/?>/gi, "\n");
+    const stripped = withBreaks.replace(/<[^>]+>/g, "");
+    return decodeEntities(stripped)
+        .split("\n")
+        .map((line) => line.replace(/\s+/g, " ").trim())
+        .filter((line, index, lines) => line.length > 0 || (index > 0 && lines[index - 1].length > 0))
+        .join("\n")
+        .trim();
+}
+
+/**
+ * Throws the allowlist/egress/scheme denial for the target URL, logging
+ * it first. Run against the initial URL and again on every redirect hop.
+ */
+function checkUrlAllowed(target: URL, logger?: Logger): void {
+    if (target.protocol !== "http:" && target.protocol !== "https:") {
+        throw new Error(`unsupported scheme: ${target.protocol}`);
+    }
+    const egressDenial = checkEgressAllowed(target.hostname);
+    if (egressDenial) {
+        logger?.warn("http_fetch denied by egress policy", { url: target.href, reason: egressDenial });
+        throw new Error(egressDenial);
+    }
+    const denial = checkDomainAllowed(target.hostname);
+    if (denial) {
+        logger?.warn("http_fetch denied", { url: target.href, reason: denial });
+        throw new Error(denial);
+    }
+}
+
+/**
+ * Follows redirects by hand so each Location target goes back through
+ * checkUrlAllowed — `redirect: "follow"` would only validate the first
+ * URL and let an allowlisted host 302 anywhere, including internal
+ * addresses.
+ */
+async function fetchFollowingRedirects(
+    url: URL,
+    method: HttpFetchMethod,
+    signal: AbortSignal,
+    logger?: Logger
+): Promise<Response> {
+    let current = url;
+    for (let hop = 0; ; hop += 1) {
+        const response = await fetch(current, { method, redirect: "manual", signal });
+        const location = response.headers.get("location");
+        if (response.status < 300 || response.status >= 400 || !location) {
+            return response;
+        }
+        // The redirect body is never read; release it before the next hop.
+        void response.body?.cancel();
+        if (hop >= MAX_REDIRECTS) {
+            throw new Error(`too many redirects (more than ${MAX_REDIRECTS}): ${url.href}`);
+        }
+        let next: URL;
+        try {
+            next = new URL(location, current);
+        } catch {
+            throw new Error(`invalid redirect location from ${current.hostname}: ${location}`);
+        }
+        checkUrlAllowed(next, logger);
+        logger?.debug("http_fetch following redirect", {
+            from: current.href,
+            to: next.href,
+            status: response.status
+        });
+        current = next;
+    }
+}
+
+/**
+ * Reads at most `limit` bytes of the body, cancelling the stream once
+ * the cap is hit so a multi-GB response never lands in daemon memory.
+ */
+async function readBodyCapped(
+    response: Response,
+    limit: number
+): Promise<{ text: string; bytes: number; truncated: boolean }> {
+    if (!response.body) {
+        return { text: "", bytes: 0, truncated: false };
+    }
+    const reader = response.body.getReader();
+    const decoder = new TextDecoder();
+    let text = "";
+    let bytes = 0;
+    let truncated = false;
+    for (;;) {
+        const { done, value } = await reader.read();
+        if (done) {
+            break;
+        }
+        const remaining = limit - bytes;
+        if (value.byteLength > remaining) {
+            text += decoder.decode(value.subarray(0, remaining), { stream: true });
+            bytes = limit;
+            truncated = true;
+            await reader.cancel();
+            break;
+        }
+        text += decoder.decode(value, { stream: true });
+        bytes += value.byteLength;
+    }
+    text += decoder.decode();
+    return { text, bytes, truncated };
+}
+
+/**
+ * Fetches the URL and returns status plus body text. Throws with a
+ * readable message for allowlist denials, bad URLs, unsupported schemes,
+ * and timeouts — the tool runner surfaces the message as the tool error.
+ */
+export async function httpFetch(
+    rawUrl: string,
+    method: HttpFetchMethod,
+    maxBytesOverride?: number,
+    logger?: Logger
+): Promise<HttpFetchResult> {
+    let url: URL;
+    try {
+        url = new URL(rawUrl);
+    } catch {
+        throw new Error(`invalid url: ${rawUrl}`);
+    }
+    checkUrlAllowed(url, logger);
+
+    const limit = maxBytes(maxBytesOverride);
+    const controller = new AbortController();
+    const timer = setTimeout(() => controller.abort(), timeoutMs());
+    try {
+        const response = await fetchFollowingRedirects(url, method, controller.signal, logger);
+        const contentType = response.headers.get("content-type") ?? "";
+        if (method === "HEAD") {
+            return { status: response.status, contentType, body: "", truncated: false };
+        }
+        const raw = await readBodyCapped(response, limit);
+        const text = /text\/html|application\/xhtml/i.test(contentType) ? htmlToText(raw.text) : raw.text;
+        const truncated = raw.truncated || text.length > limit;
+        const clipped = text.length > limit ? text.slice(0, limit) : text;
+        const body = truncated ? `${clipped}\n... (response truncated)` : clipped;
+        logger?.info("http_fetch completed", {
+            url: rawUrl,
+            status: response.status,
+            bytes: raw.bytes,
+            truncated
+        });
+        return { status: response.status, contentType, body, truncated };
+    } catch (error) {
+        if (error instanceof Error && error.name === "AbortError") {
+            throw new Error(`http_fetch timed out after ${timeoutMs()}ms: ${rawUrl}`);
+        }
+        throw error;
+    } finally {
+        clearTimeout(timer);
+    }
+}
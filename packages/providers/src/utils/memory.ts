@@ -0,0 +1,64 @@
+import { createHash } from "node:crypto";
+import { promises as fs } from "node:fs";
+import path from "node:path";
+import type { Logger } from "../logger.js";
+
+/**
+ * Persistent scratch memory for the memory_read/memory_write tools: a
+ * markdown notes file per workspace under dataDir/memory/, so facts
+ * learned in one session (build quirks, flaky tests, local conventions)
+ * survive into later sessions without polluting AGENTS.md or the repo.
+ *
+ * The file is keyed by the resolved workspace root, not the run, so every
+ * run against the same checkout shares one memory.
+ */
+
+const DEFAULT_MEMORY_MAX_BYTES = 65_536;
+
+function memoryMaxBytes(): number {
+    const raw = Number(process.env.VUHLP_MEMORY_MAX_BYTES);
+    return Number.isFinite(raw) && raw > 0 ? raw : DEFAULT_MEMORY_MAX_BYTES;
+}
+
+/** dataDir/memory/<basename>-<hash>.md for the given workspace root. */
+export function memoryFilePath(root: string): string {
+    const dataDir = process.env.VUHLP_DATA_DIR || "data";
+    const resolved = path.resolve(root);
+    const hash = createHash("sha256").update(resolved).digest("hex").slice(0, 12);
+    const basename = path.basename(resolved).replace(/[^a-zA-Z0-9._-]/g, "_") || "workspace";
+    return path.join(dataDir, "memory", `${basename}-${hash}.md`);
+}
+
+/** Returns the workspace's notes, or "" when nothing has been recorded. */
+export async function readMemory(root: string): Promise<string> {
+    try {
+        return await fs.readFile(memoryFilePath(root), "utf8");
+    } catch (error) {
+        const code = (error as NodeJS.ErrnoException).code;
+        if (code === "ENOENT") {
+            return "";
+        }
+        throw error;
+    }
+}
+
+/**
+ * Appends a dated note. The file is capped: when it outgrows the limit the
+ * oldest lines are dropped, so memory stays a digest rather than a log.
+ */
+export async function appendMemory(root: string, note: string, logger?: Logger): Promise<string> {
+    const file = memoryFilePath(root);
+    await fs.mkdir(path.dirname(file), { recursive: true });
+    const existing = await readMemory(root);
+    const date = new Date().toISOString().slice(0, 10);
+    const entry = `- [${date}] ${note.trim().replace(/\s*\n\s*/g, " ")}\n`;
+    let content = existing.length > 0 && !existing.endsWith("\n") ? `${existing}\n${entry}` : existing + entry;
+    const max = memoryMaxBytes();
+    while (Buffer.byteLength(content, "utf8") > max && content.includes("\n")) {
+        content = content.slice(content.indexOf("\n") + 1);
+        logger?.warn("workspace memory over limit; dropping oldest note", { file });
+    }
+    await fs.writeFile(file, content, "utf8");
+    logger?.info("workspace memory updated", { file, bytes: Buffer.byteLength(content, "utf8") });
+    return file;
+}
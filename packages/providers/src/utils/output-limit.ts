@@ -0,0 +1,83 @@
+import { promises as fs } from "node:fs";
+import path from "node:path";
+import type { Logger } from "../logger.js";
+
+/**
+ * Tool output truncation: huge results (full test logs, big file reads)
+ * blow up the context window. Outputs over VUHLP_TOOL_OUTPUT_LIMIT chars
+ * (default 30000) are cut; the full text is spooled to
+ * .vuhlp/tool-output/<toolId>.txt in the workspace and the truncation
+ * notice tells the model to page the remainder with read_tool_output.
+ */
+
+const DEFAULT_OUTPUT_LIMIT = 30_000;
+
+export const TOOL_OUTPUT_DIR = path.join(".vuhlp", "tool-output");
+
+export function toolOutputLimit(): number {
+    const raw = process.env.VUHLP_TOOL_OUTPUT_LIMIT;
+    if (raw === undefined || raw.trim().length === 0) {
+        return DEFAULT_OUTPUT_LIMIT;
+    }
+    const parsed = Number(raw);
+    return Number.isFinite(parsed) && parsed > 0 ? parsed : DEFAULT_OUTPUT_LIMIT;
+}
+
+function spoolPath(root: string, toolId: string): string {
+    // Tool ids come from the model; keep only safe filename characters.
+    const safe = toolId.replace(/[^a-zA-Z0-9_-]/g, "_");
+    return path.join(root, TOOL_OUTPUT_DIR, `${safe}.txt`);
+}
+
+/**
+ * Truncates over-limit output, spooling the full text for paging. On
+ * spool failure the output is still truncated (the context must be
+ * protected either way) but the notice omits the continuation handle.
+ */
+export async function applyOutputLimit<T extends { output: string }>(
+    toolId: string,
+    result: T,
+    root: string,
+    logger?: Logger,
+    limitOverride?: number | null
+): Promise<T> {
+    const limit = limitOverride ?? toolOutputLimit();
+    if (result.output.length <= limit) {
+        return result;
+    }
+    const total = result.output.length;
+    let handle: string | null = null;
+    try {
+        const target = spoolPath(root, toolId);
+        await fs.mkdir(path.dirname(target), { recursive: true });
+        await fs.writeFile(target, result.output, "utf8");
+        handle = toolId;
+    } catch (error) {
+        const message = error instanceof Error ? error.message : String(error);
+        logger?.warn("failed to spool truncated tool output", { toolId, message });
+    }
+    logger?.info("tool output truncated", { toolId, total, limit, spooled: handle !== null });
+    const notice = handle
+        ? `... (output truncated: showing ${limit} of ${total} chars; ` +
+          `page the rest with read_tool_output {"id":"${handle}","offset":${limit}})`
+        : `... (output truncated: showing ${limit} of ${total} chars)`;
+    return { ...result, output: `${result.output.slice(0, limit)}\n${notice}` };
+}
+
+/** Reads a page of a spooled output; offset/limit are char positions. */
+export async function readSpooledOutput(
+    root: string,
+    toolId: string,
+    offset: number,
+    limit: number
+): Promise<{ chunk: string; offset: number; total: number; remaining: number }> {
+    const content = await fs.readFile(spoolPath(root, toolId), "utf8");
+    const start = Math.max(0, Math.min(offset, content.length));
+    const end = Math.min(start + limit, content.length);
+    return {
+        chunk: content.slice(start, end),
+        offset: start,
+        total: content.length,
+        remaining: content.length - end
+    };
+}
@@ -0,0 +1,195 @@
+/**
+ * Pure-TS unified-diff application for the apply_patch tool. Deliberately
+ * has no git dependency: runs against exported tarballs or scratch
+ * directories that are not git repositories still apply patches, where
+ * shelling out to `git apply` would refuse.
+ *
+ * Application is strict - every context and deletion line must match the
+ * file, with only a small positional drift allowed - so a stale patch
+ * fails loudly instead of corrupting the file.
+ */
+
+import { promises as fs } from "node:fs";
+import path from "node:path";
+
+const MAX_DRIFT = 50;
+
+export interface PatchHunk {
+    oldStart: number;
+    lines: string[];
+}
+
+export interface FilePatch {
+    oldPath: string | null;
+    newPath: string | null;
+    hunks: PatchHunk[];
+}
+
+function stripPathPrefix(raw: string): string | null {
+    const trimmed = raw.trim().split("\t")[0];
+    if (trimmed === "/dev/null") {
+        return null;
+    }
+    return trimmed.replace(/^[ab]\//, "");
+}
+
+/** Splits a unified diff into per-file patches; throws on malformed input. */
+export function parseUnifiedDiff(patch: string): FilePatch[] {
+    const files: FilePatch[] = [];
+    const lines = patch.split("\n");
+    let current: FilePatch | null = null;
+    let hunk: PatchHunk | null = null;
+    for (let index = 0; index < lines.length; index += 1) {
+        const line = lines[index];
+        if (line.startsWith("--- ")) {
+            const next = lines[index + 1];
+            if (!next || !next.startsWith("+++ ")) {
+                throw new Error(`malformed patch: "--- " without "+++ " at line ${index + 1}`);
+            }
+            current = { oldPath: stripPathPrefix(line.slice(4)), newPath: stripPathPrefix(next.slice(4)), hunks: [] };
+            files.push(current);
+            hunk = null;
+            index += 1;
+            continue;
+        }
+        const header = line.match(/^@@ -(\d+)(?:,\d+)? \+\d+(?:,\d+)? @@/);
+        if (header) {
+            if (!current) {
+                throw new Error(`malformed patch: hunk header before file header at line ${index + 1}`);
+            }
+            hunk = { oldStart: Number(header[1]), lines: [] };
+            current.hunks.push(hunk);
+            continue;
+        }
+        if (hunk && (line.startsWith(" ") || line.startsWith("+") || line.startsWith("-"))) {
+            hunk.lines.push(line);
+            continue;
+        }
+        if (line.startsWith("\\ No newline")) {
+            continue;
+        }
+        hunk = null;
+    }
+    if (files.length === 0) {
+        throw new Error("patch contains no file headers (--- / +++)");
+    }
+    return files;
+}
+
+function hunkMatchesAt(fileLines: string[], hunk: PatchHunk, position: number): boolean {
+    let offset = position;
+    for (const line of hunk.lines) {
+        if (line.startsWith("+")) {
+            continue;
+        }
+        if (fileLines[offset] !== line.slice(1)) {
+            return false;
+        }
+        offset += 1;
+    }
+    return true;
+}
+
+/** Applies hunks to file content, tolerating small positional drift. */
+export function applyHunks(content: string, hunks: PatchHunk[], file: string): string {
+    const fileLines = content.split("\n");
+    let shift = 0;
+    for (const hunk of hunks) {
+        const expected = hunk.oldStart - 1 + shift;
+        let position = -1;
+        for (let drift = 0; drift <= MAX_DRIFT; drift += 1) {
+            for (const candidate of drift === 0 ? [expected] : [expected - drift, expected + drift]) {
+                if (candidate >= 0 && candidate <= fileLines.length && hunkMatchesAt(fileLines, hunk, candidate)) {
+                    position = candidate;
+                    break;
+                }
+            }
+            if (position !== -1) {
+                break;
+            }
+        }
+        if (position === -1) {
+            throw new Error(`hunk @@ -${hunk.oldStart} does not match ${file}; the patch may be stale`);
+        }
+        const replacement: string[] = [];
+        let removed = 0;
+        for (const line of hunk.lines) {
+            if (line.startsWith("-")) {
+                removed += 1;
+            } else if (line.startsWith("+")) {
+                replacement.push(line.slice(1));
+            } else {
+                replacement.push(line.slice(1));
+                removed += 1;
+            }
+        }
+        fileLines.splice(position, removed, ...replacement);
+        // Later hunks are offset by where this one landed plus its growth.
+        shift = position - (hunk.oldStart - 1) + replacement.length - removed;
+    }
+    return fileLines.join("\n");
+}
+
+export interface PatchPreviewEntry {
+    file: string;
+    additions: number;
+    deletions: number;
+    status: "ok" | "create" | "delete" | "conflict" | "missing";
+}
+
+/**
+ * Dry-run equivalent of `git apply --check --stat`: per-file +/- counts
+ * and whether each hunk still applies to the workspace, without touching
+ * any file. Used to enrich approval requests with what the reviewer is
+ * actually approving.
+ */
+export async function previewPatch(root: string, patchText: string): Promise<PatchPreviewEntry[]> {
+    const entries: PatchPreviewEntry[] = [];
+    for (const filePatch of parseUnifiedDiff(patchText)) {
+        const file = filePatch.newPath ?? filePatch.oldPath ?? "(unknown)";
+        let additions = 0;
+        let deletions = 0;
+        for (const hunk of filePatch.hunks) {
+            for (const line of hunk.lines) {
+                if (line.startsWith("+")) {
+                    additions += 1;
+                } else if (line.startsWith("-")) {
+                    deletions += 1;
+                }
+            }
+        }
+        const resolved = path.resolve(root, file);
+        const existing = await fs.readFile(resolved, "utf8").catch(() => null);
+        let status: PatchPreviewEntry["status"];
+        if (filePatch.oldPath === null) {
+            status = existing === null ? "create" : "conflict";
+        } else if (filePatch.newPath === null) {
+            status = existing === null ? "missing" : "delete";
+        } else if (existing === null) {
+            status = "missing";
+        } else {
+            try {
+                applyHunks(existing, filePatch.hunks, file);
+                status = "ok";
+            } catch {
+                status = "conflict";
+            }
+        }
+        entries.push({ file, additions, deletions, status });
+    }
+    return entries;
+}
+
+/** New-file content from a creation patch (every line an addition). */
+export function contentFromCreation(hunks: PatchHunk[], file: string): string {
+    const lines: string[] = [];
+    for (const hunk of hunks) {
+        for (const line of hunk.lines) {
+            if (!line.startsWith("+")) {
+                throw new Error(`creation patch for ${file} contains non-addition lines`);
+            }
+            lines.push(line.slice(1));
+        }
+    }
+    return `${lines.join("\n")}\n`;
+}
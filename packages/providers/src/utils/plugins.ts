@@ -0,0 +1,209 @@
+import { spawn } from "node:child_process";
+import type { Logger } from "../logger.js";
+import { getVuhlpToolNames } from "./tools.js";
+
+/**
+ * External tool plugins: executables listed in VUHLP_PLUGINS (comma list
+ * of commands) speak a JSON-over-stdio protocol and get registered as
+ * tools at daemon startup, so teams can add org-specific tools without
+ * recompiling.
+ *
+ * Protocol:
+ * - `<cmd> describe` prints a JSON manifest on stdout:
+ *   { "name": "...", "description": "...", "kind": "workspace" | "net",
+ *     "argsSchema": "name: { ... }", "requiresApproval": true }
+ * - `<cmd> invoke` receives { "name", "args", "cwd" } on stdin and prints
+ *   { "ok": boolean, "output": string, "error"?: string } on stdout.
+ */
+
+const DESCRIBE_TIMEOUT_MS = 10_000;
+const DEFAULT_INVOKE_TIMEOUT_MS = 120_000;
+const MAX_OUTPUT_CHARS = 100_000;
+
+export interface PluginTool {
+    name: string;
+    description: string;
+    kind: "workspace" | "net";
+    /** One-line args schema injected into the tool protocol prompt. */
+    protocolSchema: string;
+    requiresApproval: boolean;
+    command: string;
+}
+
+export interface PluginInvokeResult {
+    ok: boolean;
+    output: string;
+    error?: string;
+}
+
+const plugins = new Map<string, PluginTool>();
+
+function pluginCommands(): string[] {
+    const raw = process.env.VUHLP_PLUGINS;
+    if (!raw) {
+        return [];
+    }
+    return raw
+        .split(",")
+        .map((entry) => entry.trim())
+        .filter((entry) => entry.length > 0);
+}
+
+function invokeTimeoutMs(): number {
+    const raw = process.env.VUHLP_PLUGIN_TIMEOUT;
+    if (raw === undefined || raw.trim().length === 0) {
+        return DEFAULT_INVOKE_TIMEOUT_MS;
+    }
+    const parsed = Number(raw);
+    return Number.isFinite(parsed) && parsed > 0 ? parsed : DEFAULT_INVOKE_TIMEOUT_MS;
+}
+
+interface SpawnResult {
+    code: number | null;
+    stdout: string;
+    stderr: string;
+}
+
+function runPlugin(command: string, arg: string, stdin: string | null, timeoutMs: number): Promise<SpawnResult> {
+    return new Promise((resolve, reject) => {
+        const child = spawn(command, [arg], { shell: false });
+        let stdout = "";
+        let stderr = "";
+        const timer = setTimeout(() => {
+            child.kill("SIGKILL");
+            reject(new Error(`plugin timed out after ${timeoutMs}ms: ${command} ${arg}`));
+        }, timeoutMs);
+        child.stdout.on("data", (chunk: Buffer) => {
+            if (stdout.length < MAX_OUTPUT_CHARS) {
+                stdout += chunk.toString("utf8");
+            }
+        });
+        child.stderr.on("data", (chunk: Buffer) => {
+            if (stderr.length < MAX_OUTPUT_CHARS) {
+                stderr += chunk.toString("utf8");
+            }
+        });
+        child.on("error", (error) => {
+            clearTimeout(timer);
+            reject(error);
+        });
+        child.on("close", (code) => {
+            clearTimeout(timer);
+            resolve({ code, stdout, stderr });
+        });
+        if (stdin !== null) {
+            child.stdin.write(stdin);
+        }
+        child.stdin.end();
+    });
+}
+
+function parseManifest(command: string, stdout: string): PluginTool | null {
+    let parsed: unknown;
+    try {
+        parsed = JSON.parse(stdout);
+    } catch {
+        return null;
+    }
+    if (typeof parsed !== "object" || parsed === null || Array.isArray(parsed)) {
+        return null;
+    }
+    const manifest = parsed as Record<string, unknown>;
+    const name = typeof manifest.name === "string" ? manifest.name.trim() : "";
+    const description = typeof manifest.description === "string" ? manifest.description.trim() : "";
+    if (!/^[a-z][a-z0-9_]*$/i.test(name) || description.length === 0) {
+        return null;
+    }
+    const kind = manifest.kind === "net" ? "net" : "workspace";
+    const argsSchema = typeof manifest.argsSchema === "string" ? manifest.argsSchema.trim() : "";
+    return {
+        name,
+        description,
+        kind,
+        protocolSchema: argsSchema.length > 0 ? argsSchema : `${name}: { ... }`,
+        requiresApproval: manifest.requiresApproval === true,
+        command
+    };
+}
+
+/**
+ * Probes each configured plugin's describe output and registers the
+ * valid ones. Broken plugins are logged and skipped — a bad plugin must
+ * not take the daemon down. Safe to call again (config reload): the
+ * registry is rebuilt from scratch.
+ */
+export async function loadPlugins(logger?: Logger): Promise<PluginTool[]> {
+    plugins.clear();
+    const builtins = new Set<string>(getVuhlpToolNames());
+    for (const command of pluginCommands()) {
+        try {
+            const result = await runPlugin(command, "describe", null, DESCRIBE_TIMEOUT_MS);
+            if (result.code !== 0) {
+                logger?.warn("plugin describe failed", { command, code: result.code, stderr: result.stderr.trim() });
+                continue;
+            }
+            const manifest = parseManifest(command, result.stdout);
+            if (!manifest) {
+                logger?.warn("plugin describe returned an invalid manifest", { command });
+                continue;
+            }
+            if (builtins.has(manifest.name) || plugins.has(manifest.name)) {
+                logger?.warn("plugin name collides with an existing tool; skipped", {
+                    command,
+                    name: manifest.name
+                });
+                continue;
+            }
+            plugins.set(manifest.name, manifest);
+            logger?.info("plugin tool registered", {
+                name: manifest.name,
+                kind: manifest.kind,
+                requiresApproval: manifest.requiresApproval,
+                command
+            });
+        } catch (error) {
+            const message = error instanceof Error ? error.message : String(error);
+            logger?.warn("plugin failed to load", { command, message });
+        }
+    }
+    return [...plugins.values()];
+}
+
+export function getPluginTools(): PluginTool[] {
+    return [...plugins.values()];
+}
+
+export function findPluginTool(name: string): PluginTool | null {
+    return plugins.get(name) ?? null;
+}
+
+/** Invokes the plugin with the tool args; protocol errors become tool errors. */
+export async function invokePluginTool(
+    plugin: PluginTool,
+    args: Record<string, unknown>,
+    cwd: string,
+    logger?: Logger
+): Promise<PluginInvokeResult> {
+    const payload = JSON.stringify({ name: plugin.name, args, cwd });
+    const result = await runPlugin(plugin.command, "invoke", payload, invokeTimeoutMs());
+    try {
+        const parsed = JSON.parse(result.stdout) as Record<string, unknown>;
+        if (typeof parsed.ok === "boolean") {
+            return {
+                ok: parsed.ok,
+                output: typeof parsed.output === "string" ? parsed.output : "",
+                error: typeof parsed.error === "string" ? parsed.error : undefined
+            };
+        }
+    } catch {
+        // Fall through: plugins may print raw text and rely on exit code.
+    }
+    logger?.warn("plugin invoke returned non-protocol output; using exit code", {
+        name: plugin.name,
+        code: result.code
+    });
+    const combined = [result.stdout, result.stderr].filter(Boolean).join("");
+    return result.code === 0
+        ? { ok: true, output: combined }
+        : { ok: false, output: combined, error: `plugin exited with code ${result.code}` };
+}
@@ -0,0 +1,129 @@
+import { spawn, type ChildProcess } from "node:child_process";
+import type { Logger } from "../logger.js";
+
+/**
+ * Background process management for the start_process / check_process /
+ * stop_process tools: launch a dev server, poll it, run tests against
+ * it, then shut it down. Every process is tracked by id so leftovers can
+ * be killed when the owning session is disposed or the daemon shuts
+ * down — an orphaned dev server holding a port is a miserable failure
+ * mode to debug.
+ */
+
+const MAX_LOG_CHARS = 100_000;
+const DEFAULT_TAIL_CHARS = 4_000;
+
+export interface ManagedProcess {
+    id: string;
+    cmd: string;
+    cwd: string;
+    /** Session/node that started the process; used for cleanup. */
+    ownerId?: string;
+    pid?: number;
+    startedAt: string;
+    exitCode: number | null;
+    exited: boolean;
+    child: ChildProcess;
+    log: string;
+}
+
+const processes = new Map<string, ManagedProcess>();
+let nextId = 1;
+
+export function startProcess(
+    cmd: string,
+    cwd: string,
+    ownerId?: string,
+    logger?: Logger,
+    env?: NodeJS.ProcessEnv
+): ManagedProcess {
+    const id = `proc-${nextId}`;
+    nextId += 1;
+    const child = spawn(cmd, { shell: true, cwd, detached: true, env });
+    const record: ManagedProcess = {
+        id,
+        cmd,
+        cwd,
+        ownerId,
+        pid: child.pid,
+        startedAt: new Date().toISOString(),
+        exitCode: null,
+        exited: false,
+        child,
+        log: ""
+    };
+    const append = (chunk: Buffer): void => {
+        record.log += chunk.toString("utf8");
+        if (record.log.length > MAX_LOG_CHARS) {
+            record.log = record.log.slice(record.log.length - MAX_LOG_CHARS);
+        }
+    };
+    child.stdout?.on("data", append);
+    child.stderr?.on("data", append);
+    child.on("error", (error) => {
+        record.exited = true;
+        record.log += `\n[spawn error] ${error.message}`;
+        logger?.warn("background process failed to start", { id, cmd, message: error.message });
+    });
+    child.on("exit", (code) => {
+        record.exited = true;
+        record.exitCode = code;
+        logger?.info("background process exited", { id, cmd, code });
+    });
+    processes.set(id, record);
+    logger?.info("background process started", { id, cmd, cwd, pid: child.pid, ownerId });
+    return record;
+}
+
+export function getProcess(id: string): ManagedProcess | null {
+    return processes.get(id) ?? null;
+}
+
+export function listProcesses(): ManagedProcess[] {
+    return [...processes.values()];
+}
+
+export function tailLog(record: ManagedProcess, chars = DEFAULT_TAIL_CHARS): string {
+    return record.log.length > chars ? record.log.slice(record.log.length - chars) : record.log;
+}
+
+export function stopProcess(id: string, logger?: Logger): boolean {
+    const record = processes.get(id);
+    if (!record) {
+        return false;
+    }
+    if (!record.exited && record.child.pid !== undefined) {
+        try {
+            // Negative pid kills the whole detached process group, not
+            // just the shell wrapping the command.
+            process.kill(-record.child.pid, "SIGTERM");
+        } catch {
+            record.child.kill("SIGTERM");
+        }
+    }
+    processes.delete(id);
+    logger?.info("background process stopped", { id, cmd: record.cmd });
+    return true;
+}
+
+/** Kills every process started by the owner (session teardown). */
+export function stopProcessesForOwner(ownerId: string, logger?: Logger): number {
+    let stopped = 0;
+    for (const record of [...processes.values()]) {
+        if (record.ownerId === ownerId && stopProcess(record.id, logger)) {
+            stopped += 1;
+        }
+    }
+    return stopped;
+}
+
+/** Kills every tracked process (daemon shutdown). */
+export function stopAllProcesses(logger?: Logger): number {
+    let stopped = 0;
+    for (const record of [...processes.values()]) {
+        if (stopProcess(record.id, logger)) {
+            stopped += 1;
+        }
+    }
+    return stopped;
+}
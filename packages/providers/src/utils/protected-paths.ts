@@ -0,0 +1,67 @@
+/**
+ * Protected paths: VUHLP_PROTECTED_PATHS is a comma list of globs (e.g.
+ * `infra/**,.github/workflows/**`) that write and patch tools may not
+ * touch without an explicit approval. Enforcement lives in the write
+ * guard, not the model's judgment: unapproved writes fail with a
+ * visible error and gated sessions force an approval round-trip.
+ */
+
+function globToRegExp(pattern: string): RegExp {
+    // A trailing `/**` also matches the directory itself, so `infra/**`
+    // protects the path `infra` as well as everything beneath it.
+    const escaped = pattern
+        .replace(/\/\*\*$/, "\u0001")
+        .replace(/[.+?^${}()|[\]\\]/g, "\\$&")
+        .replace(/\*\*/g, "\u0000")
+        .replace(/\*/g, "[^/]*")
+        .replace(/\u0000/g, ".*")
+        .replace(/\u0001/g, "(/.*)?");
+    return new RegExp(`^${escaped}$`);
+}
+
+/**
+ * True when the pattern matches the path or any leading directory prefix
+ * of it — a glob that matches a directory protects the whole tree below
+ * (`infra` protects `infra/deploy.tf`).
+ */
+function matchesPath(pattern: RegExp, normalized: string): boolean {
+    if (pattern.test(normalized)) {
+        return true;
+    }
+    const segments = normalized.split("/");
+    for (let end = 1; end < segments.length; end += 1) {
+        if (pattern.test(segments.slice(0, end).join("/"))) {
+            return true;
+        }
+    }
+    return false;
+}
+
+export function protectedPatterns(): RegExp[] {
+    const raw = process.env.VUHLP_PROTECTED_PATHS;
+    if (!raw) {
+        return [];
+    }
+    return raw
+        .split(",")
+        .map((entry) => entry.trim())
+        .filter((entry) => entry.length > 0)
+        .map(globToRegExp);
+}
+
+/**
+ * Matches the workspace-relative path (forward slashes) against the
+ * protected globs. A glob also protects everything beneath a matching
+ * directory prefix. Extra patterns (e.g. from a workspace policy file)
+ * are checked alongside the environment ones.
+ */
+export function isProtectedPath(relPath: string, extraPatterns?: string[]): boolean {
+    const normalized = relPath.split("\\").join("/").replace(/^\.\//, "");
+    if (protectedPatterns().some((pattern) => matchesPath(pattern, normalized))) {
+        return true;
+    }
+    return (extraPatterns ?? [])
+        .map((entry) => entry.trim())
+        .filter((entry) => entry.length > 0)
+        .some((entry) => matchesPath(globToRegExp(entry), normalized));
+}
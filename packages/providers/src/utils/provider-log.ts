@@ -0,0 +1,66 @@
+import { promises as fs } from "node:fs";
+import path from "node:path";
+import type { Logger } from "../logger.js";
+
+export interface ProviderCallLogEntry {
+    ts: string;
+    provider: string;
+    model: string;
+    runId: string;
+    nodeId: string;
+    latencyMs: number;
+    outcome: "ok" | "error";
+    toolCallCount?: number;
+    usage?: { promptTokens: number; completionTokens: number; totalTokens: number };
+    error?: string;
+    /** Response text; only present when VUHLP_PROVIDER_LOG_VERBOSE is set. */
+    responseText?: string;
+}
+
+/**
+ * Optional NDJSON log of provider call metadata (model, token counts,
+ * latency, outcome) for escalating provider support tickets.
+ *
+ * Enabled by pointing VUHLP_PROVIDER_LOG at a file path. Message content is
+ * scrubbed unless VUHLP_PROVIDER_LOG_VERBOSE=1.
+ */
+export class ProviderCallLog {
+    private readonly filePath: string;
+    private readonly verbose: boolean;
+    private readonly logger?: Logger;
+
+    private constructor(filePath: string, verbose: boolean, logger?: Logger) {
+        this.filePath = filePath;
+        this.verbose = verbose;
+        this.logger = logger;
+    }
+
+    static fromEnv(logger?: Logger): ProviderCallLog | null {
+        const filePath = process.env.VUHLP_PROVIDER_LOG?.trim();
+        if (!filePath) {
+            return null;
+        }
+        const verbose = process.env.VUHLP_PROVIDER_LOG_VERBOSE === "1";
+        return new ProviderCallLog(path.resolve(filePath), verbose, logger);
+    }
+
+    record(entry: ProviderCallLogEntry): void {
+        const scrubbed: ProviderCallLogEntry = this.verbose
+            ? entry
+            : { ...entry, responseText: undefined };
+        void this.append(scrubbed);
+    }
+
+    private async append(entry: ProviderCallLogEntry): Promise<void> {
+        try {
+            await fs.mkdir(path.dirname(this.filePath), { recursive: true });
+            await fs.appendFile(this.filePath, `${JSON.stringify(entry)}\n`, "utf8");
+        } catch (error) {
+            const message = error instanceof Error ? error.message : String(error);
+            this.logger?.warn("failed to append provider call log", {
+                filePath: this.filePath,
+                message
+            });
+        }
+    }
+}
@@ -0,0 +1,72 @@
+/**
+ * Resource limits for command-running tools: a model-written fork bomb
+ * or runaway test suite should exhaust its own budget, not the host.
+ * Host commands are wrapped with shell rlimits (ulimit); sandboxed
+ * commands get the equivalent cgroup flags on `docker run`.
+ */
+
+export interface ResourceLimits {
+    /** CPU time cap in seconds (ulimit -t). */
+    cpuSeconds: number | null;
+    /** Virtual memory cap in MiB (ulimit -v / docker --memory). */
+    memoryMb: number | null;
+    /** Process-count cap (ulimit -u / docker --pids-limit). */
+    maxProcs: number | null;
+}
+
+function limitFromEnv(name: string): number | null {
+    const raw = process.env[name];
+    if (raw === undefined || raw.trim().length === 0) {
+        return null;
+    }
+    const parsed = Number(raw);
+    return Number.isFinite(parsed) && parsed > 0 ? Math.floor(parsed) : null;
+}
+
+export function resourceLimits(): ResourceLimits {
+    return {
+        cpuSeconds: limitFromEnv("VUHLP_EXEC_CPU_SECONDS"),
+        memoryMb: limitFromEnv("VUHLP_EXEC_MEMORY_MB"),
+        maxProcs: limitFromEnv("VUHLP_EXEC_MAX_PROCS")
+    };
+}
+
+/**
+ * Wraps a host command in a subshell that applies the configured
+ * rlimits before running it. Returns the command unchanged when no
+ * limits are set or on platforms without ulimit.
+ */
+export function wrapWithRlimits(cmd: string): string {
+    if (process.platform === "win32") {
+        return cmd;
+    }
+    const limits = resourceLimits();
+    const flags: string[] = [];
+    if (limits.cpuSeconds !== null) {
+        flags.push(`-t ${limits.cpuSeconds}`);
+    }
+    if (limits.memoryMb !== null) {
+        flags.push(`-v ${limits.memoryMb * 1024}`);
+    }
+    if (limits.maxProcs !== null) {
+        flags.push(`-u ${limits.maxProcs}`);
+    }
+    if (flags.length === 0) {
+        return cmd;
+    }
+    const ulimits = flags.map((flag) => `ulimit ${flag} 2>/dev/null;`).join(" ");
+    return `sh -c '${`${ulimits} ${cmd}`.replace(/'/g, `'\\''`)}'`;
+}
+
+/** Cgroup-backed equivalents for sandboxed execution. */
+export function dockerLimitFlags(): string[] {
+    const limits = resourceLimits();
+    const flags: string[] = [];
+    if (limits.memoryMb !== null) {
+        flags.push(`--memory ${limits.memoryMb}m`);
+    }
+    if (limits.maxProcs !== null) {
+        flags.push(`--pids-limit ${limits.maxProcs}`);
+    }
+    return flags;
+}
@@ -0,0 +1,54 @@
+import path from "node:path";
+import { sandboxNetworkFlags } from "./egress.js";
+import { dockerLimitFlags } from "./resource-limits.js";
+
+/**
+ * Docker sandbox execution: when a run sets sandboxImage, the command
+ * and run_tests tools execute inside a throwaway container with the
+ * workspace bind-mounted at /workspace instead of on the host. Only the
+ * run's env set crosses into the container - host environment and
+ * credentials do not.
+ */
+
+const IMAGE_PATTERN = /^[a-zA-Z0-9][a-zA-Z0-9._:\/@-]*$/;
+const ENV_KEY_PATTERN = /^[A-Za-z_][A-Za-z0-9_]*$/;
+
+/** Validates an image reference before it is spliced into a shell command. */
+export function validSandboxImage(image: string): boolean {
+    return IMAGE_PATTERN.test(image);
+}
+
+function shellQuote(value: string): string {
+    return `'${value.replace(/'/g, `'\\''`)}'`;
+}
+
+/**
+ * Builds the docker invocation that runs `cmd` in the sandbox. The cwd
+ * (already resolved inside root) is mapped to the matching path under
+ * /workspace.
+ */
+export function sandboxCommand(
+    image: string,
+    root: string,
+    cwd: string,
+    cmd: string,
+    env?: Record<string, string>
+): string {
+    const rel = path.relative(root, cwd);
+    const workdir =
+        rel.length > 0 && !rel.startsWith("..")
+            ? path.posix.join("/workspace", ...rel.split(path.sep))
+            : "/workspace";
+    const parts = [
+        `docker run --rm -v "${root}:/workspace" -w "${workdir}"`,
+        ...dockerLimitFlags(),
+        ...sandboxNetworkFlags()
+    ];
+    for (const [key, value] of Object.entries(env ?? {})) {
+        if (ENV_KEY_PATTERN.test(key)) {
+            parts.push(`-e ${key}=${shellQuote(value)}`);
+        }
+    }
+    parts.push(`"${image}"`, `sh -c ${shellQuote(cmd)}`);
+    return parts.join(" ");
+}
@@ -0,0 +1,136 @@
+import { spawn } from "node:child_process";
+import type { Logger } from "../logger.js";
+
+/**
+ * sql_query execution: DSNs are named in config (VUHLP_SQL_DSN_<NAME>,
+ * or VUHLP_SQL_DSN for the default) and queries run through the matching
+ * client CLI — psql, mysql, or sqlite3 — so the daemon carries no
+ * database drivers. Reads are free; mutations are refused unless the
+ * call sets allowWrite, and the runner additionally gates them behind
+ * approval.
+ */
+
+const DEFAULT_TIMEOUT_MS = 30_000;
+const MAX_OUTPUT_CHARS = 100_000;
+
+export interface SqlInvocation {
+    command: string;
+    args: string[];
+    /** Query delivered on stdin; keeps it out of process listings. */
+    stdin: string;
+}
+
+function sqlTimeoutMs(): number {
+    const raw = process.env.VUHLP_SQL_TIMEOUT;
+    if (raw === undefined || raw.trim().length === 0) {
+        return DEFAULT_TIMEOUT_MS;
+    }
+    const parsed = Number(raw);
+    return Number.isFinite(parsed) && parsed > 0 ? parsed : DEFAULT_TIMEOUT_MS;
+}
+
+export function sqlDsnFor(name: string): string | null {
+    const key = name === "default" ? "VUHLP_SQL_DSN" : `VUHLP_SQL_DSN_${name.toUpperCase().replace(/-/g, "_")}`;
+    const raw = process.env[key];
+    return raw && raw.trim().length > 0 ? raw.trim() : null;
+}
+
+const READ_KEYWORDS = new Set(["select", "with", "explain", "show", "describe", "desc", "pragma", "values"]);
+
+/**
+ * Statement-level mutation check: strips comments, splits on semicolons,
+ * and flags any statement whose leading keyword is not a read. WITH
+ * CTEs that feed INSERT/UPDATE are caught by scanning the whole
+ * statement for mutation keywords, erring on the side of "mutation".
+ */
+export function isSqlMutation(sql: string): boolean {
+    const stripped = sql
+        .replace(/--[^\n]*/g, " ")
+        .replace(/\/\*[\s\S]*?\*\//g, " ");
+    for (const statement of stripped.split(";")) {
+        const trimmed = statement.trim();
+        if (trimmed.length === 0) {
+            continue;
+        }
+        const keyword = trimmed.split(/\s+/)[0].toLowerCase();
+        if (!READ_KEYWORDS.has(keyword)) {
+            return true;
+        }
+        if (keyword === "with" && /\b(insert|update|delete|merge)\b/i.test(trimmed)) {
+            return true;
+        }
+    }
+    return false;
+}
+
+/** Maps the DSN to a client CLI invocation; sqlite DSNs are file paths. */
+export function buildSqlInvocation(dsn: string, query: string): SqlInvocation {
+    if (dsn.startsWith("postgres://") || dsn.startsWith("postgresql://")) {
+        return { command: "psql", args: [dsn, "--no-psqlrc", "-P", "pager=off", "-v", "ON_ERROR_STOP=1"], stdin: query };
+    }
+    if (dsn.startsWith("mysql://")) {
+        const url = new URL(dsn);
+        const args: string[] = ["--batch", `--host=${url.hostname}`];
+        if (url.port) {
+            args.push(`--port=${url.port}`);
+        }
+        if (url.username) {
+            args.push(`--user=${decodeURIComponent(url.username)}`);
+        }
+        if (url.password) {
+            args.push(`--password=${decodeURIComponent(url.password)}`);
+        }
+        const database = url.pathname.replace(/^\//, "");
+        if (database) {
+            args.push(database);
+        }
+        return { command: "mysql", args, stdin: query };
+    }
+    const file = dsn.startsWith("sqlite://") ? dsn.slice("sqlite://".length) : dsn;
+    return { command: "sqlite3", args: ["-header", "-column", file], stdin: query };
+}
+
+export interface SqlQueryResult {
+    ok: boolean;
+    output: string;
+    error?: string;
+}
+
+export function runSqlQuery(dsn: string, query: string, logger?: Logger): Promise<SqlQueryResult> {
+    const invocation = buildSqlInvocation(dsn, query);
+    return new Promise((resolve) => {
+        const child = spawn(invocation.command, invocation.args, { shell: false });
+        let stdout = "";
+        let stderr = "";
+        const timer = setTimeout(() => {
+            child.kill("SIGKILL");
+            resolve({ ok: false, output: "", error: `sql_query timed out after ${sqlTimeoutMs()}ms` });
+        }, sqlTimeoutMs());
+        child.stdout.on("data", (chunk: Buffer) => {
+            if (stdout.length < MAX_OUTPUT_CHARS) {
+                stdout += chunk.toString("utf8");
+            }
+        });
+        child.stderr.on("data", (chunk: Buffer) => {
+            if (stderr.length < MAX_OUTPUT_CHARS) {
+                stderr += chunk.toString("utf8");
+            }
+        });
+        child.on("error", (error) => {
+            clearTimeout(timer);
+            const hint = `sql client "${invocation.command}" not found; install it or fix the DSN`;
+            logger?.warn("sql client failed to launch", { command: invocation.command, message: error.message });
+            resolve({ ok: false, output: "", error: /ENOENT/.test(error.message) ? hint : error.message });
+        });
+        child.on("close", (code) => {
+            clearTimeout(timer);
+            if (code === 0) {
+                resolve({ ok: true, output: stdout });
+            } else {
+                resolve({ ok: false, output: stdout, error: stderr.trim() || `${invocation.command} exited with code ${code}` });
+            }
+        });
+        child.stdin.write(invocation.stdin);
+        child.stdin.end();
+    });
+}
@@ -1,4 +1,56 @@
 
+/**
+ * Thrown when a streaming response stops producing chunks for longer than
+ * the inactivity timeout (VUHLP_STREAM_STALL_TIMEOUT ms, default 120000,
+ * 0 disables). Without it a network hiccup hangs the turn indefinitely.
+ */
+export class StreamStallError extends Error {
+    readonly elapsedMs: number;
+    readonly timeoutMs: number;
+
+    constructor(elapsedMs: number, timeoutMs: number) {
+        super(`stream stalled: no chunks for ${elapsedMs}ms (timeout ${timeoutMs}ms)`);
+        this.name = "StreamStallError";
+        this.elapsedMs = elapsedMs;
+        this.timeoutMs = timeoutMs;
+    }
+}
+
+function stallTimeoutMs(): number {
+    const raw = process.env.VUHLP_STREAM_STALL_TIMEOUT;
+    if (raw === undefined || raw.trim().length === 0) {
+        return 120_000;
+    }
+    const parsed = Number(raw);
+    return Number.isFinite(parsed) && parsed >= 0 ? parsed : 120_000;
+}
+
+async function readChunk(
+    reader: ReadableStreamDefaultReader<Uint8Array>,
+    timeoutMs: number
+): Promise<ReadableStreamReadResult<Uint8Array>> {
+    if (timeoutMs <= 0) {
+        return reader.read();
+    }
+    const startedAt = Date.now();
+    let timer: ReturnType<typeof setTimeout> | undefined;
+    try {
+        return await Promise.race([
+            reader.read(),
+            new Promise<never>((_, reject) => {
+                timer = setTimeout(() => {
+                    void reader.cancel().catch(() => undefined);
+                    reject(new StreamStallError(Date.now() - startedAt, timeoutMs));
+                }, timeoutMs);
+            })
+        ]);
+    } finally {
+        if (timer !== undefined) {
+            clearTimeout(timer);
+        }
+    }
+}
+
 export async function streamSse(response: Response, onData: (data: string) => void): Promise<void> {
     const body = response.body;
     if (!body) {
@@ -29,8 +81,9 @@ export async function streamSse(response: Response, onData: (data: string) => vo
         }
     };
 
+    const timeoutMs = stallTimeoutMs();
     while (true) {
-        const { value, done } = await reader.read();
+        const { value, done } = await readChunk(reader, timeoutMs);
         if (done) {
             break;
         }
@@ -82,8 +135,9 @@ export async function streamJsonLines(response: Response, onLine: (line: string)
         }
     };
 
+    const timeoutMs = stallTimeoutMs();
     while (true) {
-        const { value, done } = await reader.read();
+        const { value, done } = await readChunk(reader, timeoutMs);
         if (done) {
             break;
         }
@@ -0,0 +1,132 @@
+/**
+ * Structured test-output parsing for the run_tests tool. Instead of a
+ * wall of raw verify output the model (and the UI) gets pass/fail counts
+ * and the failing test names, which is what actually drives the next fix.
+ */
+
+export type TestFramework = "go" | "pytest" | "jest";
+
+export interface TestRunSummary {
+    framework: TestFramework;
+    passed: number;
+    failed: number;
+    skipped: number;
+    /** Failing test identifiers, e.g. "pkg/foo TestBar" or "path::test_x". */
+    failures: string[];
+}
+
+const MAX_FAILURES = 100;
+
+function pushFailure(summary: TestRunSummary, name: string): void {
+    if (summary.failures.length < MAX_FAILURES && !summary.failures.includes(name)) {
+        summary.failures.push(name);
+    }
+}
+
+/** Parses `go test -json` event lines (one JSON object per line). */
+function parseGoTestJson(output: string): TestRunSummary {
+    const summary: TestRunSummary = { framework: "go", passed: 0, failed: 0, skipped: 0, failures: [] };
+    for (const line of output.split(/\r?\n/)) {
+        const trimmed = line.trim();
+        if (!trimmed.startsWith("{")) {
+            continue;
+        }
+        let event: { Action?: string; Test?: string; Package?: string };
+        try {
+            event = JSON.parse(trimmed) as { Action?: string; Test?: string; Package?: string };
+        } catch {
+            continue;
+        }
+        if (!event.Test) {
+            continue;
+        }
+        if (event.Action === "pass") {
+            summary.passed += 1;
+        } else if (event.Action === "fail") {
+            summary.failed += 1;
+            pushFailure(summary, event.Package ? `${event.Package} ${event.Test}` : event.Test);
+        } else if (event.Action === "skip") {
+            summary.skipped += 1;
+        }
+    }
+    return summary;
+}
+
+/** Parses pytest terminal output: FAILED lines plus the === summary ===. */
+function parsePytest(output: string): TestRunSummary {
+    const summary: TestRunSummary = { framework: "pytest", passed: 0, failed: 0, skipped: 0, failures: [] };
+    for (const line of output.split(/\r?\n/)) {
+        const failed = line.match(/^(?:FAILED|ERROR)\s+(\S+)/);
+        if (failed) {
+            pushFailure(summary, failed[1]);
+        }
+    }
+    const counts = output.match(/=+\s+(.*?)\s+in\s+[\d.]+s.*=+/);
+    if (counts) {
+        for (const part of counts[1].split(",")) {
+            const entry = part.trim().match(/^(\d+)\s+(passed|failed|skipped|error|errors)/);
+            if (!entry) {
+                continue;
+            }
+            const count = Number(entry[1]);
+            if (entry[2] === "passed") {
+                summary.passed = count;
+            } else if (entry[2] === "skipped") {
+                summary.skipped = count;
+            } else {
+                summary.failed += count;
+            }
+        }
+    }
+    if (summary.failed === 0 && summary.failures.length > 0) {
+        summary.failed = summary.failures.length;
+    }
+    return summary;
+}
+
+/** Parses jest output: the "Tests:" summary line plus ● failure headers. */
+function parseJest(output: string): TestRunSummary {
+    const summary: TestRunSummary = { framework: "jest", passed: 0, failed: 0, skipped: 0, failures: [] };
+    const counts = output.match(/Tests:\s+(.*)/);
+    if (counts) {
+        for (const part of counts[1].split(",")) {
+            const entry = part.trim().match(/^(\d+)\s+(passed|failed|skipped|todo)/);
+            if (!entry) {
+                continue;
+            }
+            const count = Number(entry[1]);
+            if (entry[2] === "passed") {
+                summary.passed = count;
+            } else if (entry[2] === "failed") {
+                summary.failed = count;
+            } else {
+                summary.skipped += count;
+            }
+        }
+    }
+    for (const line of output.split(/\r?\n/)) {
+        const failure = line.match(/^\s*[●✕]\s+(.+)$/);
+        if (failure && !/Console|Test suite failed/.test(failure[1])) {
+            pushFailure(summary, failure[1].trim());
+        }
+    }
+    return summary;
+}
+
+/**
+ * Picks a parser from the command line (go test / pytest / jest) falling
+ * back to sniffing the output; returns null when nothing matches so the
+ * caller can degrade to raw output.
+ */
+export function parseTestOutput(cmd: string, output: string): TestRunSummary | null {
+    if (/\bgo test\b/.test(cmd) || /"Action":/.test(output)) {
+        return parseGoTestJson(output);
+    }
+    if (/\bpytest\b|\bpy\.test\b/.test(cmd) || /=+ .*(passed|failed).* in [\d.]+s/.test(output)) {
+        return parsePytest(output);
+    }
+    if (/\bjest\b|\bvitest\b/.test(cmd) || /Tests:\s+\d+/.test(output)) {
+        return parseJest(output);
+    }
+    return null;
+}
@@ -0,0 +1,36 @@
+/**
+ * Per-tool configuration, read from the environment like everything else:
+ *
+ *   VUHLP_TOOLS_DISABLED          comma list of tool names to disable
+ *   VUHLP_TOOL_TIMEOUT_<NAME>     per-tool execution cap in ms
+ *   VUHLP_TOOL_OUTPUT_LIMIT_<NAME>  per-tool output cap in chars
+ *
+ * Disabled tools disappear from the protocol and definitions the model
+ * sees, and calling one anyway fails with a visible error. Timeouts wrap
+ * the whole tool execution; output limits override the global
+ * VUHLP_TOOL_OUTPUT_LIMIT for that tool only.
+ */
+
+function envSuffix(name: string): string {
+    return name.toUpperCase().replace(/-/g, "_");
+}
+
+export function toolEnabled(name: string): boolean {
+    const raw = process.env.VUHLP_TOOLS_DISABLED;
+    if (!raw || raw.trim().length === 0) {
+        return true;
+    }
+    return !raw.split(",").some((entry) => entry.trim() === name);
+}
+
+/** Per-tool timeout in ms, or null when none is configured. */
+export function toolTimeout(name: string): number | null {
+    const raw = Number(process.env[`VUHLP_TOOL_TIMEOUT_${envSuffix(name)}`]);
+    return Number.isFinite(raw) && raw > 0 ? raw : null;
+}
+
+/** Per-tool output limit in chars, or null to use the global limit. */
+export function toolOutputLimitOverride(name: string): number | null {
+    const raw = Number(process.env[`VUHLP_TOOL_OUTPUT_LIMIT_${envSuffix(name)}`]);
+    return Number.isFinite(raw) && raw > 0 ? raw : null;
+}
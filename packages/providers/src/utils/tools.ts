@@ -3,6 +3,8 @@
  * Single source of truth - no more duplicated tool schemas.
  */
 
+import { toolEnabled } from "./tool-config.js";
+
 // ============================================================================
 // Types
 // ============================================================================
@@ -26,13 +28,38 @@ export type VuhlpToolName =
     | "command"
     | "read_file"
     | "write_file"
+    | "edit_file"
+    | "apply_patch"
     | "list_files"
+    | "read_tool_output"
     | "delete_file"
+    | "glob"
+    | "move_file"
+    | "make_dir"
+    | "git_branch"
+    | "run_tests"
+    | "coverage"
+    | "targets"
+    | "go_refactor"
+    | "start_process"
+    | "check_process"
+    | "stop_process"
+    | "sql_query"
+    | "code_review"
+    | "memory_read"
+    | "memory_write"
+    | "docker"
+    | "http_fetch"
+    | "browser_open"
+    | "update_todos"
     | "spawn_node"
+    | "spawn_subagent"
     | "create_edge"
-    | "send_handoff";
+    | "send_handoff"
+    | "propose_options"
+    | "ask_user";
 
-export type ToolKind = "workspace" | "vuhlp-only";
+export type ToolKind = "workspace" | "net" | "vuhlp-only";
 
 interface CanonicalTool {
     name: VuhlpToolName;
@@ -85,7 +112,7 @@ const TOOL_REGISTRY: ReadonlyArray<CanonicalTool> = [
     },
     {
         name: "read_file",
-        description: "Read a file from the repository.",
+        description: "Read a file from the repository. Images are attached for vision models; other binaries return a metadata summary.",
         kind: "workspace",
         protocolSchema: "read_file: { path: string }",
         parameters: {
@@ -110,6 +137,37 @@ const TOOL_REGISTRY: ReadonlyArray<CanonicalTool> = [
             required: ["path", "content"]
         }
     },
+    {
+        name: "edit_file",
+        description:
+            "Replace an exact string in a file. old_str must match exactly once (include surrounding lines to disambiguate); set replaceAll to replace every occurrence.",
+        kind: "workspace",
+        protocolSchema: "edit_file: { path: string, old_str: string, new_str: string, replaceAll?: boolean }",
+        parameters: {
+            type: "object",
+            properties: {
+                path: { type: "string", description: "Path relative to repo root." },
+                old_str: { type: "string", description: "Exact text to replace; must be unique unless replaceAll." },
+                new_str: { type: "string", description: "Replacement text." },
+                replaceAll: { type: "boolean", description: "Replace every occurrence instead of requiring uniqueness." }
+            },
+            required: ["path", "old_str", "new_str"]
+        }
+    },
+    {
+        name: "apply_patch",
+        description:
+            "Apply a unified diff to the workspace. Works without git, so patches apply in exported tarballs and scratch directories too.",
+        kind: "workspace",
+        protocolSchema: "apply_patch: { patch: string } (unified diff; creates, edits, and deletes files)",
+        parameters: {
+            type: "object",
+            properties: {
+                patch: { type: "string", description: "Unified diff text (--- / +++ / @@ hunks)." }
+            },
+            required: ["patch"]
+        }
+    },
     {
         name: "list_files",
         description: "List files in a directory.",
@@ -135,6 +193,333 @@ const TOOL_REGISTRY: ReadonlyArray<CanonicalTool> = [
             required: ["path"]
         }
     },
+    {
+        name: "read_tool_output",
+        description:
+            "Page through the full output of an earlier tool call that was truncated. The truncation notice carries the id to use.",
+        kind: "workspace",
+        protocolSchema: "read_tool_output: { id: string, offset?: number, limit?: number }",
+        parameters: {
+            type: "object",
+            properties: {
+                id: { type: "string", description: "Tool call id from the truncation notice." },
+                offset: { type: "number", description: "Char position to read from (default 0)." },
+                limit: { type: "number", description: "Max chars to return (default the output limit)." }
+            },
+            required: ["id"]
+        }
+    },
+    {
+        name: "glob",
+        description: "Find files matching a glob pattern (doublestar semantics, e.g. src/**/*.ts).",
+        kind: "workspace",
+        protocolSchema: "glob: { pattern: string, limit?: number }",
+        parameters: {
+            type: "object",
+            properties: {
+                pattern: { type: "string", description: "Glob pattern relative to repo root; ** spans directories." },
+                limit: { type: "number", description: "Maximum number of matches (default 1000)." }
+            },
+            required: ["pattern"]
+        }
+    },
+    {
+        name: "move_file",
+        description: "Move or rename a file within the repository.",
+        kind: "workspace",
+        protocolSchema: "move_file: { from: string, to: string }",
+        parameters: {
+            type: "object",
+            properties: {
+                from: { type: "string", description: "Source path relative to repo root." },
+                to: { type: "string", description: "Destination path relative to repo root." }
+            },
+            required: ["from", "to"]
+        }
+    },
+    {
+        name: "make_dir",
+        description: "Create a directory (including parents) in the repository.",
+        kind: "workspace",
+        protocolSchema: "make_dir: { path: string }",
+        parameters: {
+            type: "object",
+            properties: {
+                path: { type: "string", description: "Directory path relative to repo root." }
+            },
+            required: ["path"]
+        }
+    },
+    {
+        name: "git_branch",
+        description:
+            "Manage git branches in the repository: create or switch branches, stash/unstash changes, or report the current branch. Switching refuses to discard uncommitted changes; stash first.",
+        kind: "workspace",
+        protocolSchema:
+            'git_branch: { action: "create" | "switch" | "stash" | "unstash" | "current", branch?: string }',
+        parameters: {
+            type: "object",
+            properties: {
+                action: {
+                    type: "string",
+                    enum: ["create", "switch", "stash", "unstash", "current"],
+                    description: "Branch operation to perform."
+                },
+                branch: { type: "string", description: "Branch name (required for create/switch)." }
+            },
+            required: ["action"]
+        }
+    },
+    {
+        name: "run_tests",
+        description:
+            "Run the test suite and return structured pass/fail counts plus failing test names. Understands go test -json, pytest, and jest output.",
+        kind: "workspace",
+        protocolSchema: "run_tests: { cmd: string, cwd?: string }",
+        parameters: {
+            type: "object",
+            properties: {
+                cmd: { type: "string", description: "Test command, e.g. go test -json ./... or pytest -q." },
+                cwd: { type: "string", description: "Optional working directory." }
+            },
+            required: ["cmd"]
+        }
+    },
+    {
+        name: "coverage",
+        description:
+            "Run the project's coverage command and report per-package coverage plus uncovered lines touched by the current diff (Go coverprofiles).",
+        kind: "workspace",
+        protocolSchema: "coverage: { cmd?: string, profile?: string, cwd?: string }",
+        parameters: {
+            type: "object",
+            properties: {
+                cmd: {
+                    type: "string",
+                    description: "Coverage command (default: go test ./... -coverprofile=coverage.out when go.mod exists)."
+                },
+                profile: { type: "string", description: "Coverprofile path to analyze (default coverage.out)." },
+                cwd: { type: "string", description: "Working directory relative to repo root." }
+            },
+            required: []
+        }
+    },
+    {
+        name: "targets",
+        description:
+            "List the build/test commands the workspace actually defines (Makefile targets, package.json scripts, Taskfile tasks, justfile recipes).",
+        kind: "workspace",
+        protocolSchema: "targets: {}",
+        parameters: {
+            type: "object",
+            properties: {},
+            required: []
+        }
+    },
+    {
+        name: "go_refactor",
+        description:
+            "AST-aware Go refactors via the Go toolchain: rename a symbol (gopls, type-checked across packages) or apply a gofmt -r rewrite pattern. Safer than textual patches for large-scale Go edits.",
+        kind: "workspace",
+        protocolSchema:
+            'go_refactor: { action: "rename" | "rewrite", file?: string, line?: number, col?: number, newName?: string, pattern?: string, path?: string }',
+        parameters: {
+            type: "object",
+            properties: {
+                action: {
+                    type: "string",
+                    enum: ["rename", "rewrite"],
+                    description: "rename uses gopls at file:line:col; rewrite applies a gofmt -r pattern."
+                },
+                file: { type: "string", description: "File containing the symbol (rename)." },
+                line: { type: "number", description: "1-based line of the symbol (rename)." },
+                col: { type: "number", description: "1-based column of the symbol (rename)." },
+                newName: { type: "string", description: "New identifier name (rename)." },
+                pattern: { type: "string", description: 'gofmt rewrite rule, e.g. "a.Close() -> a.Shutdown()" (rewrite).' },
+                path: { type: "string", description: "Directory to rewrite (default repo root)." }
+            },
+            required: ["action"]
+        }
+    },
+    {
+        name: "start_process",
+        description:
+            "Start a long-running background process (e.g. a dev server) and get an id for polling. Stop it with stop_process when done.",
+        kind: "workspace",
+        protocolSchema: "start_process: { cmd: string, cwd?: string }",
+        parameters: {
+            type: "object",
+            properties: {
+                cmd: { type: "string", description: "Shell command to run in the background." },
+                cwd: { type: "string", description: "Optional working directory." }
+            },
+            required: ["cmd"]
+        }
+    },
+    {
+        name: "check_process",
+        description: "Check a background process: running state, exit code, and recent output.",
+        kind: "workspace",
+        protocolSchema: "check_process: { id: string, tail?: number }",
+        parameters: {
+            type: "object",
+            properties: {
+                id: { type: "string", description: "Process id from start_process." },
+                tail: { type: "number", description: "Chars of recent output to return (default 4000)." }
+            },
+            required: ["id"]
+        }
+    },
+    {
+        name: "stop_process",
+        description: "Stop a background process started with start_process.",
+        kind: "workspace",
+        protocolSchema: "stop_process: { id: string }",
+        parameters: {
+            type: "object",
+            properties: {
+                id: { type: "string", description: "Process id from start_process." }
+            },
+            required: ["id"]
+        }
+    },
+    {
+        name: "sql_query",
+        description:
+            "Run SQL against a configured database (sqlite/Postgres/MySQL via their CLIs). Read-only by default; mutations need allowWrite and go through approval.",
+        kind: "workspace",
+        protocolSchema: "sql_query: { query: string, db?: string, allowWrite?: boolean }",
+        parameters: {
+            type: "object",
+            properties: {
+                query: { type: "string", description: "SQL to execute." },
+                db: { type: "string", description: 'Named DSN from config (default "default").' },
+                allowWrite: { type: "boolean", description: "Required for INSERT/UPDATE/DDL statements." }
+            },
+            required: ["query"]
+        }
+    },
+    {
+        name: "code_review",
+        description:
+            "Send the current git diff to a review model and get structured findings (severity, file, line, comment). Use before declaring implementation work done.",
+        kind: "workspace",
+        protocolSchema: "code_review: { base?: string } (reviews the diff against base, default uncommitted changes)",
+        parameters: {
+            type: "object",
+            properties: {
+                base: { type: "string", description: "Git ref to diff against (default: HEAD, i.e. uncommitted changes)." }
+            },
+            required: []
+        }
+    },
+    {
+        name: "memory_read",
+        description:
+            "Read the persistent scratch notes for this workspace - facts recorded by earlier sessions (build quirks, flaky tests).",
+        kind: "workspace",
+        protocolSchema: "memory_read: {}",
+        parameters: {
+            type: "object",
+            properties: {},
+            required: []
+        }
+    },
+    {
+        name: "memory_write",
+        description:
+            "Record a durable fact about this workspace for future sessions. Keep notes short and specific; they persist across runs.",
+        kind: "workspace",
+        protocolSchema: "memory_write: { note: string }",
+        parameters: {
+            type: "object",
+            properties: {
+                note: { type: "string", description: "One fact worth remembering next session." }
+            },
+            required: ["note"]
+        }
+    },
+    {
+        name: "docker",
+        description:
+            "Build an image from a workspace Dockerfile or run an image with docker run --rm, returning the image id / container logs. Always approval-gated.",
+        kind: "workspace",
+        protocolSchema:
+            'docker: { action: "build" | "run", context?: string, dockerfile?: string, tag?: string, image?: string, cmd?: string }',
+        parameters: {
+            type: "object",
+            properties: {
+                action: { type: "string", enum: ["build", "run"], description: "Docker operation." },
+                context: { type: "string", description: "Build context directory relative to repo root (build; default .)." },
+                dockerfile: { type: "string", description: "Dockerfile path relative to repo root (build)." },
+                tag: { type: "string", description: "Image tag to apply (build)." },
+                image: { type: "string", description: "Image to run (run)." },
+                cmd: { type: "string", description: "Command passed to the container (run)." }
+            },
+            required: ["action"]
+        }
+    },
+    {
+        name: "http_fetch",
+        description:
+            "Fetch a URL over HTTP (GET or HEAD) and return the response as text; HTML pages are converted to plain text. Only allowlisted domains are reachable.",
+        kind: "net",
+        protocolSchema: 'http_fetch: { url: string, method?: "GET" | "HEAD", maxBytes?: number }',
+        parameters: {
+            type: "object",
+            properties: {
+                url: { type: "string", description: "Absolute http(s) URL to fetch." },
+                method: { type: "string", enum: ["GET", "HEAD"], description: "Request method (default GET)." },
+                maxBytes: { type: "number", description: "Response size cap in characters (default 512000)." }
+            },
+            required: ["url"]
+        }
+    },
+    {
+        name: "browser_open",
+        description:
+            "Open a URL in a headless browser, save a screenshot into the workspace, and return console errors. Use it to verify UI changes against a running dev server.",
+        kind: "net",
+        protocolSchema: "browser_open: { url: string, screenshotPath?: string }",
+        parameters: {
+            type: "object",
+            properties: {
+                url: { type: "string", description: "URL to load, e.g. http://localhost:3000/." },
+                screenshotPath: {
+                    type: "string",
+                    description: "Workspace-relative PNG path (default .vuhlp/screenshots/<timestamp>.png)."
+                }
+            },
+            required: ["url"]
+        }
+    },
+    {
+        name: "update_todos",
+        description:
+            "Replace the node's task list. Send the full list every time; the dashboard renders it live as items change status.",
+        kind: "vuhlp-only",
+        protocolSchema:
+            'update_todos: { todos: Array<{ content: string, status: "pending" | "in_progress" | "completed", activeForm: string }> }',
+        parameters: {
+            type: "object",
+            properties: {
+                todos: {
+                    type: "array",
+                    description: "Full task list in order; omitted items are dropped.",
+                    items: {
+                        type: "object",
+                        properties: {
+                            content: { type: "string", description: "Imperative task description." },
+                            status: { type: "string", enum: ["pending", "in_progress", "completed"] },
+                            activeForm: { type: "string", description: "Present-continuous form shown while in progress." }
+                        },
+                        required: ["content", "status", "activeForm"]
+                    }
+                }
+            },
+            required: ["todos"]
+        }
+    },
     {
         name: "spawn_node",
         description: "Create a new agent/node in the current run for delegation.",
@@ -180,6 +565,33 @@ const TOOL_REGISTRY: ReadonlyArray<CanonicalTool> = [
             required: ["label", "roleTemplate"]
         }
     },
+    {
+        name: "spawn_subagent",
+        description:
+            "Delegate a bounded subtask to a child agent and wait for its result. The child gets its own tool loop with constrained capabilities and a time budget; its summary comes back to this turn.",
+        kind: "vuhlp-only",
+        protocolSchema:
+            "spawn_subagent: { label: string, instructions: string, roleTemplate?: string, provider?: string, capabilities?: object, timeoutMs?: number }",
+        parameters: {
+            type: "object",
+            properties: {
+                label: { type: "string", description: "Child node display label." },
+                instructions: { type: "string", description: "The delegated subtask, self-contained." },
+                roleTemplate: { type: "string", description: "Role template for the child (default implementer)." },
+                provider: { type: "string", description: "Provider for the child (default: same as parent)." },
+                capabilities: {
+                    type: "object",
+                    properties: {
+                        writeCode: { type: "boolean" },
+                        writeDocs: { type: "boolean" },
+                        runCommands: { type: "boolean" }
+                    }
+                },
+                timeoutMs: { type: "number", description: "Budget in ms before the parent resumes without the result." }
+            },
+            required: ["label", "instructions"]
+        }
+    },
     {
         name: "create_edge",
         description: "Create an edge between two nodes in the current run.",
@@ -241,6 +653,47 @@ const TOOL_REGISTRY: ReadonlyArray<CanonicalTool> = [
             },
             required: ["to", "message"]
         }
+    },
+    {
+        name: "propose_options",
+        description:
+            "Present 2-3 alternative approaches and pause until the user selects one. The selected option becomes the directive for the rest of the turn.",
+        kind: "vuhlp-only",
+        protocolSchema:
+            'propose_options: { options: [{ id?: string, label: string, summary: string }] } (2-3 options; pauses for user selection)',
+        parameters: {
+            type: "object",
+            properties: {
+                options: {
+                    type: "array",
+                    items: {
+                        type: "object",
+                        properties: {
+                            id: { type: "string", description: "Short identifier, e.g. A / B / C." },
+                            label: { type: "string", description: "One-line name of the approach." },
+                            summary: { type: "string", description: "What this approach would do and its trade-offs." }
+                        },
+                        required: ["label", "summary"]
+                    }
+                }
+            },
+            required: ["options"]
+        }
+    },
+    {
+        name: "ask_user",
+        description:
+            "Ask the user a clarifying question and pause until they answer. Use when requirements are ambiguous instead of guessing.",
+        kind: "vuhlp-only",
+        protocolSchema: "ask_user: { question: string, context?: string } (pauses the turn until the user answers)",
+        parameters: {
+            type: "object",
+            properties: {
+                question: { type: "string", description: "The question the user must answer before work can continue." },
+                context: { type: "string", description: "Optional background that helps the user answer." }
+            },
+            required: ["question"]
+        }
     }
 ];
 
@@ -251,6 +704,9 @@ const VUHLP_ONLY_TOOL_NAMES: ReadonlyArray<VuhlpToolName> = TOOL_REGISTRY
 const PROVIDER_NATIVE_TOOL_NAMES: ReadonlyArray<VuhlpToolName> = TOOL_REGISTRY
     .filter((tool) => tool.kind === "workspace")
     .map((tool) => tool.name);
+const NET_TOOL_NAMES: ReadonlyArray<VuhlpToolName> = TOOL_REGISTRY
+    .filter((tool) => tool.kind === "net")
+    .map((tool) => tool.name);
 
 // ============================================================================
 // Provider Transformers
@@ -287,16 +743,18 @@ function toGemini(tool: CanonicalTool): GeminiTool {
 // Exported Functions (Backward Compatible API)
 // ============================================================================
 
+// Tools disabled via VUHLP_TOOLS_DISABLED are filtered here so the model
+// never sees their definitions; calling one anyway fails in the runner.
 export function openAiToolDefinitions(): OpenAITool[] {
-    return TOOL_REGISTRY.map(toOpenAI);
+    return TOOL_REGISTRY.filter((tool) => toolEnabled(tool.name)).map(toOpenAI);
 }
 
 export function claudeToolDefinitions(): ClaudeTool[] {
-    return TOOL_REGISTRY.map(toClaude);
+    return TOOL_REGISTRY.filter((tool) => toolEnabled(tool.name)).map(toClaude);
 }
 
 export function geminiToolDefinitions(): GeminiTool[] {
-    return TOOL_REGISTRY.map(toGemini);
+    return TOOL_REGISTRY.filter((tool) => toolEnabled(tool.name)).map(toGemini);
 }
 
 export function getToolRegistry(): ReadonlyArray<CanonicalTool> {
@@ -304,15 +762,20 @@ export function getToolRegistry(): ReadonlyArray<CanonicalTool> {
 }
 
 export function getVuhlpToolNames(): ReadonlyArray<VuhlpToolName> {
-    return TOOL_NAMES;
+    return TOOL_NAMES.filter((name) => toolEnabled(name));
 }
 
 export function getVuhlpOnlyToolNames(): ReadonlyArray<VuhlpToolName> {
-    return VUHLP_ONLY_TOOL_NAMES;
+    return VUHLP_ONLY_TOOL_NAMES.filter((name) => toolEnabled(name));
 }
 
 export function getProviderNativeToolNames(): ReadonlyArray<VuhlpToolName> {
-    return PROVIDER_NATIVE_TOOL_NAMES;
+    return PROVIDER_NATIVE_TOOL_NAMES.filter((name) => toolEnabled(name));
+}
+
+/** Network-reaching tools, so approval policy can gate them as a class. */
+export function getNetToolNames(): ReadonlyArray<VuhlpToolName> {
+    return NET_TOOL_NAMES.filter((name) => toolEnabled(name));
 }
 
 // Export canonical tools for direct access if needed
@@ -0,0 +1,151 @@
+import { promises as fs } from "fs";
+import path from "path";
+import type { Logger } from "../logger.js";
+
+/**
+ * Per-workspace policy loaded from .vuhlp/policy.json at the workspace
+ * root, so verify commands, command rules, protected paths, and tool
+ * restrictions travel with the codebase instead of living only in daemon
+ * env. File rules apply on top of the matching VUHLP_* environment
+ * settings; whichever side is stricter wins.
+ *
+ *   {
+ *     "verify": ["pnpm lint", "pnpm test"],
+ *     "commands": { "deny": ["curl *"], "approve": ["git push*"], "allow": ["pnpm *"] },
+ *     "protectedPaths": ["infra/**", ".github/workflows/**"],
+ *     "toolsDisabled": ["sql_query"],
+ *     "approvals": { "command": 2 }
+ *   }
+ */
+
+export interface WorkspaceCommandRules {
+    deny?: string[];
+    approve?: string[];
+    allow?: string[];
+}
+
+export interface WorkspacePolicy {
+    /** Commands the agent is told to run before reporting completion. */
+    verify?: string[];
+    /** Command pattern lists with VUHLP_COMMAND_* semantics. */
+    commands?: WorkspaceCommandRules;
+    /** Globs with VUHLP_PROTECTED_PATHS semantics. */
+    protectedPaths?: string[];
+    /** Tool names rejected at execution, like VUHLP_TOOLS_DISABLED. */
+    toolsDisabled?: string[];
+    /** Tool name to required distinct approvers, like VUHLP_APPROVAL_POLICY. */
+    approvals?: Record<string, number>;
+}
+
+const POLICY_FILE = path.join(".vuhlp", "policy.json");
+
+const cache = new Map<string, { mtimeMs: number; policy: WorkspacePolicy }>();
+
+function stringList(value: unknown, field: string, logger?: Logger): string[] | undefined {
+    if (value === undefined) {
+        return undefined;
+    }
+    if (!Array.isArray(value)) {
+        logger?.warn("workspace policy field must be a string array; ignoring", { field });
+        return undefined;
+    }
+    const entries = value
+        .filter((entry): entry is string => typeof entry === "string")
+        .map((entry) => entry.trim())
+        .filter((entry) => entry.length > 0);
+    if (entries.length !== value.length) {
+        logger?.warn("workspace policy field has non-string entries; keeping the rest", { field });
+    }
+    return entries;
+}
+
+function commandRules(value: unknown, logger?: Logger): WorkspaceCommandRules | undefined {
+    if (value === undefined) {
+        return undefined;
+    }
+    if (typeof value !== "object" || value === null || Array.isArray(value)) {
+        logger?.warn("workspace policy commands must be an object; ignoring", {});
+        return undefined;
+    }
+    const rules = value as Record<string, unknown>;
+    return {
+        deny: stringList(rules.deny, "commands.deny", logger),
+        approve: stringList(rules.approve, "commands.approve", logger),
+        allow: stringList(rules.allow, "commands.allow", logger)
+    };
+}
+
+function approvalCounts(value: unknown, logger?: Logger): Record<string, number> | undefined {
+    if (value === undefined) {
+        return undefined;
+    }
+    if (typeof value !== "object" || value === null || Array.isArray(value)) {
+        logger?.warn("workspace policy approvals must be an object; ignoring", {});
+        return undefined;
+    }
+    const counts: Record<string, number> = {};
+    for (const [tool, raw] of Object.entries(value as Record<string, unknown>)) {
+        const count = Number(raw);
+        if (!Number.isFinite(count) || count < 1) {
+            logger?.warn("ignoring invalid workspace policy approval count", { tool });
+            continue;
+        }
+        counts[tool] = Math.floor(count);
+    }
+    return counts;
+}
+
+/**
+ * Loads the workspace policy for a root, or undefined when the file does
+ * not exist. Cached by file mtime so edits are picked up on the next
+ * turn without re-reading on every call. A file that exists but cannot
+ * be parsed is treated as no policy, with a loud log - a broken policy
+ * should not silently open the workspace up.
+ */
+export async function loadWorkspacePolicy(root: string, logger?: Logger): Promise<WorkspacePolicy | undefined> {
+    const filePath = path.resolve(root, POLICY_FILE);
+    let mtimeMs: number;
+    try {
+        mtimeMs = (await fs.stat(filePath)).mtimeMs;
+    } catch (error) {
+        if ((error as NodeJS.ErrnoException).code === "ENOENT") {
+            cache.delete(filePath);
+            return undefined;
+        }
+        const message = error instanceof Error ? error.message : String(error);
+        logger?.error("failed to stat workspace policy", { filePath, message });
+        return undefined;
+    }
+    const cached = cache.get(filePath);
+    if (cached && cached.mtimeMs === mtimeMs) {
+        return cached.policy;
+    }
+    let parsed: unknown;
+    try {
+        parsed = JSON.parse(await fs.readFile(filePath, "utf8"));
+    } catch (error) {
+        const message = error instanceof Error ? error.message : String(error);
+        logger?.error("failed to parse workspace policy", { filePath, message });
+        return undefined;
+    }
+    if (typeof parsed !== "object" || parsed === null || Array.isArray(parsed)) {
+        logger?.error("workspace policy must be a JSON object", { filePath });
+        return undefined;
+    }
+    const raw = parsed as Record<string, unknown>;
+    const policy: WorkspacePolicy = {
+        verify: stringList(raw.verify, "verify", logger),
+        commands: commandRules(raw.commands, logger),
+        protectedPaths: stringList(raw.protectedPaths, "protectedPaths", logger),
+        toolsDisabled: stringList(raw.toolsDisabled, "toolsDisabled", logger),
+        approvals: approvalCounts(raw.approvals, logger)
+    };
+    cache.set(filePath, { mtimeMs, policy });
+    logger?.info("workspace policy loaded", {
+        filePath,
+        verify: policy.verify?.length ?? 0,
+        protectedPaths: policy.protectedPaths?.length ?? 0,
+        toolsDisabled: policy.toolsDisabled?.length ?? 0
+    });
+    return policy;
+}
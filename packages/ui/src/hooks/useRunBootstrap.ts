@@ -57,6 +57,15 @@ export function useRunBootstrap(): RunBootstrapState {
         let storedRunId: string | null = null;
         let lastErrorMessage: string | null = null;
 
+        // Deep links (notifications, shared URLs) select a run with ?run=<id>;
+        // it wins over the locally remembered selection.
+        let requestedRunId: string | null = null;
+        try {
+          requestedRunId = new URLSearchParams(window.location.search).get('run');
+        } catch (error) {
+          console.warn('[bootstrap] failed to read query params', error);
+        }
+
         try {
           storedRunId = localStorage.getItem(RUN_STORAGE_KEY);
         } catch (error) {
@@ -70,9 +79,14 @@ export function useRunBootstrap(): RunBootstrapState {
           } else {
             const sorted = [...runs].sort((a, b) => b.updatedAt.localeCompare(a.updatedAt));
             const targetId =
-              storedRunId && runs.some((item) => item.id === storedRunId)
-                ? storedRunId
-                : (sorted[0]?.id ?? runs[0]?.id ?? null);
+              requestedRunId && runs.some((item) => item.id === requestedRunId)
+                ? requestedRunId
+                : storedRunId && runs.some((item) => item.id === storedRunId)
+                  ? storedRunId
+                  : (sorted[0]?.id ?? runs[0]?.id ?? null);
+            if (requestedRunId && targetId !== requestedRunId) {
+              console.warn('[bootstrap] requested run not found, falling back', { requestedRunId });
+            }
             if (targetId) {
               try {
                 nextRun = await getRun(targetId);
@@ -44,7 +44,7 @@ export interface Edge {
 // Run Types
 // ============================================================================
 
-export type RunStatus = 'queued' | 'running' | 'completed' | 'failed' | 'stopped' | 'paused';
+export type RunStatus = 'queued' | 'running' | 'completed' | 'failed' | 'stopped' | 'paused' | 'budget_exceeded';
 export type RunMode = 'AUTO' | 'INTERACTIVE';
 export type GlobalMode = 'PLANNING' | 'IMPLEMENTATION';
 export type RunPhase =
@@ -0,0 +1,219 @@
+import { spawnSync } from "node:child_process";
+import { existsSync, readFileSync, readdirSync, statSync, writeFileSync } from "node:fs";
+import path from "node:path";
+
+// Renders a spec.md (plus its diagrams directory) into a single
+// self-contained HTML file for review by non-engineers, optionally
+// printing to PDF via a locally installed headless chromium.
+//
+// Usage: node scripts/render-spec.mjs <spec.md> [-o out.html] [--pdf out.pdf]
+
+const IMAGE_MIME = {
+  ".png": "image/png",
+  ".jpg": "image/jpeg",
+  ".jpeg": "image/jpeg",
+  ".gif": "image/gif",
+  ".svg": "image/svg+xml",
+  ".webp": "image/webp",
+};
+
+const escapeHtml = (value) =>
+  value
+    .replaceAll("&", "&amp;")
+    .replaceAll("<", "&lt;")
+    .replaceAll(">", "&gt;")
+    .replaceAll('"', "&quot;");
+
+const inlineImage = (specDir, src) => {
+  if (/^https?:/i.test(src)) {
+    return src;
+  }
+  const filePath = path.resolve(specDir, src);
+  const ext = path.extname(filePath).toLowerCase();
+  const mime = IMAGE_MIME[ext];
+  if (!mime || !existsSync(filePath)) {
+    console.error(`[spec:render] image not inlined (missing or unsupported): ${src}`);
+    return src;
+  }
+  const data = readFileSync(filePath).toString("base64");
+  return `data:${mime};base64,${data}`;
+};
+
+const renderInline = (text, specDir) => {
+  let html = escapeHtml(text);
+  html = html.replace(/!\[([^\]]*)\]\(([^)\s]+)\)/g, (_match, alt, src) => {
+    return `<img src="${inlineImage(specDir, src)}" alt="${alt}">`;
+  });
+  html = html.replace(/\[([^\]]+)\]\(([^)\s]+)\)/g, '<a href="$2">$1</a>');
+  html = html.replace(/`([^`]+)`/g, "<code>$1</code>");
+  html = html.replace(/\*\*([^*]+)\*\*/g, "<strong>$1</strong>");
+  html = html.replace(/(^|\W)\*([^*]+)\*(?=\W|$)/g, "$1<em>$2</em>");
+  return html;
+};
+
+const renderMarkdown = (markdown, specDir) => {
+  const lines = markdown.split(/\r?\n/);
+  const out = [];
+  let inCode = false;
+  let inList = false;
+  let paragraph = [];
+
+  const flushParagraph = () => {
+    if (paragraph.length > 0) {
+      out.push(`<p>${renderInline(paragraph.join(" "), specDir)}</p>`);
+      paragraph = [];
+    }
+  };
+  const closeList = () => {
+    if (inList) {
+      out.push("</ul>");
+      inList = false;
+    }
+  };
+
+  for (const line of lines) {
+    if (line.startsWith("```")) {
+      flushParagraph();
+      closeList();
+      if (inCode) {
+        out.push("</code></pre>");
+      } else {
+        out.push("<pre><code>");
+      }
+      inCode = !inCode;
+      continue;
+    }
+    if (inCode) {
+      out.push(escapeHtml(line));
+      continue;
+    }
+    const heading = line.match(/^(#{1,6})\s+(.*)$/);
+    if (heading) {
+      flushParagraph();
+      closeList();
+      const level = heading[1].length;
+      out.push(`<h${level}>${renderInline(heading[2], specDir)}</h${level}>`);
+      continue;
+    }
+    const listItem = line.match(/^\s*[-*]\s+(.*)$/);
+    if (listItem) {
+      flushParagraph();
+      if (!inList) {
+        out.push("<ul>");
+        inList = true;
+      }
+      out.push(`<li>${renderInline(listItem[1], specDir)}</li>`);
+      continue;
+    }
+    if (line.trim() === "" || line.trim() === "---") {
+      flushParagraph();
+      closeList();
+      if (line.trim() === "---") {
+        out.push("<hr>");
+      }
+      continue;
+    }
+    closeList();
+    paragraph.push(line.trim());
+  }
+  flushParagraph();
+  closeList();
+  if (inCode) {
+    out.push("</code></pre>");
+  }
+  return out.join("\n");
+};
+
+const renderDiagramsAppendix = (specDir, markdown) => {
+  const diagramsDir = path.join(specDir, "diagrams");
+  if (!existsSync(diagramsDir) || !statSync(diagramsDir).isDirectory()) {
+    return "";
+  }
+  const sections = [];
+  for (const name of readdirSync(diagramsDir).sort()) {
+    if (!(path.extname(name).toLowerCase() in IMAGE_MIME)) {
+      continue;
+    }
+    const relative = path.join("diagrams", name);
+    if (markdown.includes(relative) || markdown.includes(`diagrams/${name}`)) {
+      continue; // already inlined where the spec references it
+    }
+    sections.push(
+      `<h3>${escapeHtml(name)}</h3><img src="${inlineImage(specDir, relative)}" alt="${escapeHtml(name)}">`
+    );
+  }
+  if (sections.length === 0) {
+    return "";
+  }
+  return `<hr><h2>Diagrams</h2>\n${sections.join("\n")}`;
+};
+
+const STYLE = `
+body { font-family: -apple-system, "Segoe UI", sans-serif; max-width: 52rem; margin: 2rem auto; padding: 0 1rem; color: #1a1a1a; line-height: 1.55; }
+pre { background: #f5f5f5; padding: 0.75rem 1rem; overflow-x: auto; border-radius: 6px; }
+code { font-family: ui-monospace, "SF Mono", monospace; font-size: 0.9em; }
+img { max-width: 100%; }
+h1, h2, h3 { line-height: 1.25; }
+hr { border: none; border-top: 1px solid #ddd; margin: 2rem 0; }
+`;
+
+const renderPdf = (htmlPath, pdfPath) => {
+  const candidates = [
+    process.env.CHROME_BIN,
+    "chromium",
+    "chromium-browser",
+    "google-chrome",
+    "/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+  ].filter(Boolean);
+  for (const binary of candidates) {
+    const result = spawnSync(
+      binary,
+      ["--headless", "--disable-gpu", `--print-to-pdf=${pdfPath}`, `file://${path.resolve(htmlPath)}`],
+      { stdio: "ignore" }
+    );
+    if (result.status === 0) {
+      return binary;
+    }
+  }
+  return null;
+};
+
+const args = process.argv.slice(2);
+const specPath = args.find((arg) => !arg.startsWith("-"));
+if (!specPath || !existsSync(specPath)) {
+  console.error("[spec:render] usage: node scripts/render-spec.mjs <spec.md> [-o out.html] [--pdf out.pdf]");
+  process.exit(1);
+}
+const outIndex = args.indexOf("-o");
+const htmlPath = outIndex !== -1 ? args[outIndex + 1] : specPath.replace(/\.md$/i, "") + ".html";
+const pdfIndex = args.indexOf("--pdf");
+const pdfPath = pdfIndex !== -1 ? args[pdfIndex + 1] : null;
+
+const markdown = readFileSync(specPath, "utf8");
+const specDir = path.dirname(path.resolve(specPath));
+const title = (markdown.match(/^#\s+(.*)$/m) ?? [null, path.basename(specPath)])[1];
+const html = `<!doctype html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>${escapeHtml(title)}</title>
+<style>${STYLE}</style>
+</head>
+<body>
+${renderMarkdown(markdown, specDir)}
+${renderDiagramsAppendix(specDir, markdown)}
+</body>
+</html>
+`;
+writeFileSync(htmlPath, html, "utf8");
+console.log(`[spec:render] wrote ${htmlPath}`);
+
+if (pdfPath) {
+  const binary = renderPdf(htmlPath, pdfPath);
+  if (binary) {
+    console.log(`[spec:render] wrote ${pdfPath} via ${binary}`);
+  } else {
+    console.error("[spec:render] no headless chromium found; set CHROME_BIN or install chromium");
+    process.exit(1);
+  }
+}